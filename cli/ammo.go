@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	httpConfig "github.com/yandex/pandora/components/providers/http/config"
+	"github.com/yandex/pandora/components/providers/http/decoders"
+	"github.com/yandex/pandora/components/providers/http/decoders/ammo"
+	scenarioConfig "github.com/yandex/pandora/components/providers/scenario/config"
+)
+
+const ammoCommand = "ammo"
+
+// ammoFormatScenario is HTTP scenario ammo: YAML, or HCL for input with .hcl extension.
+const ammoFormatScenario = "scenario"
+
+// ammoScenarioName is name of scenario, that converted requests make.
+const ammoScenarioName = "ammo"
+
+// ammoSubcommands are subcommands of ammo command, like `pandora ammo convert`.
+var ammoSubcommands = map[string]func(args []string) int{
+	"convert": runAmmoConvert,
+	"check":   runAmmoCheck,
+}
+
+func runAmmo(args []string) int {
+	if len(args) == 0 || ammoSubcommands[args[0]] == nil {
+		fmt.Fprintf(os.Stderr, "Usage of Pandora ammo: pandora ammo <command> [flags] <file>\n"+
+			"Commands: convert, check\n")
+		return exitError
+	}
+	return ammoSubcommands[args[0]](args[1:])
+}
+
+func runAmmoConvert(args []string) int {
+	fs := flag.NewFlagSet(ammoCommand+" convert", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of Pandora ammo convert: pandora ammo convert --from <format> --to <format> <in> [out]\n"+
+			"Converts HTTP ammo between formats. Output is STDOUT, if out is not set.\n"+
+			"Input formats: uri, uripost, raw, jsonline, har, accesslog, curl, pcap, scenario\n"+
+			"Output formats: uri, uripost, raw, jsonline, har, scenario\n")
+		fs.PrintDefaults()
+	}
+	var from, to string
+	fs.StringVar(&from, "from", "", "input ammo format")
+	fs.StringVar(&to, "to", "", "output ammo format")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if from == "" || to == "" || fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return exitError
+	}
+	if from != ammoFormatScenario && !httpConfig.DecoderType(from).IsValid() {
+		fmt.Fprintf(os.Stderr, "Unknown input format %q\n", from)
+		return exitError
+	}
+	newEncoder := newScenarioEncoder
+	if to != ammoFormatScenario {
+		// Encoder is checked before input read, that can be long.
+		if _, err := decoders.NewEncoder(httpConfig.DecoderType(to), io.Discard); err != nil {
+			fmt.Fprintf(os.Stderr, "Unknown output format %q\n", to)
+			return exitError
+		}
+		newEncoder = func(w io.Writer) (decoders.Encoder, error) {
+			return decoders.NewEncoder(httpConfig.DecoderType(to), w)
+		}
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ammo read failed: %s\n", err)
+		return exitError
+	}
+	defer f.Close()
+	return writeGenOutput(fs.Arg(1), func(w io.Writer) error {
+		enc, err := newEncoder(w)
+		if err != nil {
+			return err
+		}
+		var n int
+		err = readAmmo(f, from, func(a decoders.DecodedAmmo) error {
+			n++
+			if err := enc.Encode(a); err != nil {
+				return fmt.Errorf("ammo #%d: %w", n, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return enc.Close()
+	})
+}
+
+func runAmmoCheck(args []string) int {
+	fs := flag.NewFlagSet(ammoCommand+" check", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of Pandora ammo check: pandora ammo check --format <format> <file>\n"+
+			"Reads whole HTTP ammo file by provider decoder and reports malformed entries and ammo statistics.\n"+
+			"Formats: uri, uripost, raw, jsonline, har, accesslog, curl, pcap\n")
+		fs.PrintDefaults()
+	}
+	var (
+		format    string
+		maxErrors int
+	)
+	fs.StringVar(&format, "format", "", "ammo format")
+	fs.IntVar(&maxErrors, "max-errors", 100, "stop after this number of errors; 0 is unlimited")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if format == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return exitError
+	}
+	if !httpConfig.DecoderType(format).IsValid() {
+		fmt.Fprintf(os.Stderr, "Unknown ammo format %q\n", format)
+		return exitError
+	}
+
+	path := fs.Arg(0)
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ammo read failed: %s\n", err)
+		return exitError
+	}
+	defer f.Close()
+	d, err := decoders.NewDecoder(httpConfig.Config{Decoder: httpConfig.DecoderType(format), File: path, Passes: 1}, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ammo read failed: %s\n", err)
+		return exitError
+	}
+	report := decoders.Check(context.Background(), d, maxErrors)
+	if err := writeCheckReport(os.Stdout, report, maxErrors); err != nil {
+		fmt.Fprintf(os.Stderr, "Report write failed: %s\n", err)
+		return exitError
+	}
+	if len(report.Errors) > 0 || report.Ammo == 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+func writeCheckReport(w io.Writer, r decoders.CheckReport, maxErrors int) error {
+	for _, err := range r.Errors {
+		fmt.Fprintf(w, "ERROR: %s\n", err)
+	}
+	if maxErrors > 0 && len(r.Errors) >= maxErrors {
+		fmt.Fprintf(w, "Check stopped after %d errors\n", len(r.Errors))
+	}
+	if len(r.Errors) > 0 {
+		fmt.Fprintln(w)
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Ammo:\t%d\n", r.Ammo)
+	fmt.Fprintf(tw, "Errors:\t%d\n", len(r.Errors))
+	if r.Skipped > 0 {
+		fmt.Fprintf(tw, "Skipped:\t%d\n", r.Skipped)
+	}
+	fmt.Fprintf(tw, "Preload memory:\t~%s\n", formatBytes(r.PreloadMemory))
+	writeCheckDistribution(tw, "TAG", r.Tags, "(empty)")
+	writeCheckDistribution(tw, "METHOD", r.Methods, "")
+	writeCheckDistribution(tw, "HOST", r.Hosts, "(none)")
+	fmt.Fprintf(tw, "\nBODY SIZE\tCOUNT\n")
+	for i, count := range r.BodySizes {
+		var bucket string
+		switch {
+		case i == 0:
+			bucket = "0"
+		case i < len(decoders.BodySizeBuckets):
+			bucket = "<= " + formatBytes(int64(decoders.BodySizeBuckets[i]))
+		default:
+			bucket = "> " + formatBytes(int64(decoders.BodySizeBuckets[i-1]))
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", bucket, count)
+	}
+	return tw.Flush()
+}
+
+// writeCheckDistribution writes ammo counts per value, the most frequent first.
+func writeCheckDistribution(w io.Writer, name string, counts map[string]int, empty string) {
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	fmt.Fprintf(w, "\n%s\tCOUNT\n", name)
+	for _, v := range values {
+		value := v
+		if value == "" {
+			value = empty
+		}
+		fmt.Fprintf(w, "%s\t%d\n", value, counts[v])
+	}
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// readAmmo reads ammo of file by decoder of HTTP provider, or from scenario ammo, and passes it to fn one by one.
+// Decoded ammo is released after fn returns, so fn should not keep it.
+func readAmmo(f *os.File, format string, fn func(a decoders.DecodedAmmo) error) error {
+	if format == ammoFormatScenario {
+		ammos, err := readScenarioAmmo(f)
+		if err != nil {
+			return err
+		}
+		for _, a := range ammos {
+			if err := fn(a); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	d, err := decoders.NewDecoder(httpConfig.Config{Decoder: httpConfig.DecoderType(format), File: f.Name(), Passes: 1}, f)
+	if err != nil {
+		return err
+	}
+	for {
+		a, err := d.Scan(context.Background())
+		if errors.Is(err, decoders.ErrPassLimit) || errors.Is(err, decoders.ErrNoAmmo) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = fn(a)
+		d.Release(a)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readScenarioAmmo makes ammo of every scenario request in order of declaration.
+// Requests are not templated, so templates are sent as is; processors are dropped.
+func readScenarioAmmo(f *os.File) ([]decoders.DecodedAmmo, error) {
+	var (
+		hcl scenarioConfig.AmmoHCL
+		err error
+	)
+	if strings.HasSuffix(strings.ToLower(f.Name()), ".hcl") {
+		hcl, err = scenarioConfig.ParseHCLFile(f)
+	} else {
+		hcl, err = scenarioConfig.ReadYAML(f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ammos []decoders.DecodedAmmo
+	for _, r := range hcl.Requests {
+		if r.Preprocessor != nil || len(r.Postprocessors) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: processors of request %q are dropped\n", r.Name)
+		}
+		header := http.Header{}
+		for k, v := range r.Headers {
+			header.Set(k, v)
+		}
+		var body []byte
+		if r.Body != nil && *r.Body != "" {
+			body = []byte(*r.Body)
+		}
+		var tag string
+		if r.Tag != nil {
+			tag = *r.Tag
+		}
+		a := &ammo.Ammo{}
+		if err := a.Setup(r.Method, "http://"+header.Get("Host")+r.URI, body, header, tag); err != nil {
+			return nil, fmt.Errorf("request %q: %w", r.Name, err)
+		}
+		ammos = append(ammos, a)
+	}
+	return ammos, nil
+}
+
+// scenarioEncoder writes YAML scenario ammo with single scenario, that sends requests in order.
+// Requests are kept till Close, because scenario ammo is single document.
+type scenarioEncoder struct {
+	w   io.Writer
+	hcl scenarioConfig.AmmoHCL
+}
+
+func newScenarioEncoder(w io.Writer) (decoders.Encoder, error) {
+	return &scenarioEncoder{w: w}, nil
+}
+
+func (e *scenarioEncoder) Encode(a decoders.DecodedAmmo) error {
+	req, err := a.BuildRequest()
+	if err != nil {
+		return err
+	}
+	r := scenarioConfig.RequestHCL{
+		Name:    fmt.Sprintf("request_%d", len(e.hcl.Requests)+1),
+		Method:  req.Method,
+		URI:     req.URL.RequestURI(),
+		Headers: map[string]string{},
+	}
+	for k, vv := range req.Header {
+		if k != "Content-Length" {
+			r.Headers[k] = strings.Join(vv, ", ")
+		}
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	if host != "" {
+		r.Headers["Host"] = host
+	}
+	if tag := a.Tag(); tag != "" {
+		r.Tag = &tag
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		if len(body) > 0 {
+			s := string(body)
+			r.Body = &s
+		}
+	}
+	e.hcl.Requests = append(e.hcl.Requests, r)
+	return nil
+}
+
+func (e *scenarioEncoder) Close() error {
+	if len(e.hcl.Requests) > 0 {
+		scenario := scenarioConfig.ScenarioHCL{Name: ammoScenarioName}
+		for _, r := range e.hcl.Requests {
+			scenario.Requests = append(scenario.Requests, r.Name)
+		}
+		e.hcl.Scenarios = []scenarioConfig.ScenarioHCL{scenario}
+	}
+	return scenarioConfig.WriteYAML(e.w, e.hcl)
+}
@@ -31,6 +31,14 @@ const stdinConfigSelector = "-"
 
 var configSearchDirs = []string{"./", "./config", "/etc/pandora"}
 
+// commands are auxiliary subcommands, that don't run engine. Command gets arguments after its name,
+// and returns process exit code.
+var commands = map[string]func(args []string) int{
+	compareCommand: runCompare,
+	genCommand:     runGen,
+	ammoCommand:    runAmmo,
+}
+
 type CliConfig struct {
 	Engine     engine.Config    `config:",squash"`
 	Log        logConfig        `config:"log"`
@@ -85,6 +93,11 @@ func DefaultConfig() *CliConfig {
 func Run() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of Pandora: pandora [<config_filename>]\n"+"<config_filename> is './%s.(yaml|json|...)' by default\n", defaultConfigFile)
+		fmt.Fprintf(os.Stderr, "       pandora %s [flags] <baseline.phout> <candidate.phout>\n", compareCommand)
+		fmt.Fprintf(os.Stderr, "       pandora %s openapi [flags] <spec.yaml>\n", genCommand)
+		fmt.Fprintf(os.Stderr, "       pandora %s postman [flags] <collection.json>\n", genCommand)
+		fmt.Fprintf(os.Stderr, "       pandora %s convert --from <format> --to <format> <in> [out]\n", ammoCommand)
+		fmt.Fprintf(os.Stderr, "       pandora %s check --format <format> <file>\n", ammoCommand)
 		flag.PrintDefaults()
 	}
 	var (
@@ -111,6 +124,10 @@ func Run() {
 		return
 	}
 
+	if command, ok := commands[flag.Arg(0)]; ok {
+		os.Exit(command(flag.Args()[1:]))
+	}
+
 	ReadConfigAndRunEngine()
 }
 
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/yandex/pandora/lib/phout"
+)
+
+const compareCommand = "compare"
+
+const (
+	exitOK         = 0
+	exitRegression = 1
+	exitError      = 2
+)
+
+func runCompare(args []string) int {
+	conf := phout.DefaultCompareConfig()
+	fs := flag.NewFlagSet(compareCommand, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of Pandora compare: pandora compare [flags] <baseline> <candidate>\n"+
+			"Results can be in phout or jsonlines format.\n"+
+			"Exits with code %d, if candidate has regression against baseline.\n", exitRegression)
+		fs.PrintDefaults()
+	}
+	var (
+		format    string
+		quantiles string
+	)
+	fs.StringVar(&format, "format", "table", "output format: table or json")
+	fs.StringVar(&quantiles, "quantiles", "50,90,95,99", "comma separated RTT percentiles to compare")
+	fs.Float64Var(&conf.Tolerances.Quantile, "quantile-tolerance", conf.Tolerances.Quantile,
+		"maximum relative RTT quantile growth; negative disables check")
+	fs.Float64Var(&conf.Tolerances.RPS, "rps-tolerance", conf.Tolerances.RPS,
+		"maximum relative RPS drop; negative disables check")
+	fs.Float64Var(&conf.Tolerances.ErrorRate, "error-rate-tolerance", conf.Tolerances.ErrorRate,
+		"maximum absolute error rate growth; negative disables check")
+	fs.BoolVar(&conf.AllowMissing, "allow-missing", conf.AllowMissing,
+		"don't consider tags, that are missing in candidate, as regression")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+	var err error
+	conf.Quantiles, err = parsePercentiles(quantiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid quantiles: %s\n", err)
+		return exitError
+	}
+	if format != "table" && format != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown format %q\n", format)
+		return exitError
+	}
+
+	baseline, err := readPhoutStats(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Baseline read failed: %s\n", err)
+		return exitError
+	}
+	candidate, err := readPhoutStats(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Candidate read failed: %s\n", err)
+		return exitError
+	}
+
+	res := phout.Compare(baseline, candidate, conf)
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(res)
+	} else {
+		err = writeComparisonTable(os.Stdout, res, conf.Quantiles)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Comparison write failed: %s\n", err)
+		return exitError
+	}
+	if res.Regression {
+		return exitRegression
+	}
+	return exitOK
+}
+
+func readPhoutStats(path string) (*phout.Stats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := phout.NewAutoReader(f)
+	if err != nil {
+		return nil, err
+	}
+	return phout.CollectStats(r)
+}
+
+func parsePercentiles(s string) ([]float64, error) {
+	var res []float64
+	for _, field := range strings.Split(s, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		if p <= 0 || p > 100 {
+			return nil, fmt.Errorf("percentile %v is out of (0, 100]", p)
+		}
+		res = append(res, p/100)
+	}
+	return res, nil
+}
+
+func writeComparisonTable(w io.Writer, res *phout.Comparison, quantiles []float64) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	header := []string{"TAG", "STATUS", "COUNT", "RPS", "ERRORS"}
+	for _, q := range quantiles {
+		header = append(header, fmt.Sprintf("Q%g, ms", q*100))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, tag := range res.Tags {
+		row := []string{
+			tag.Tag,
+			tag.Status,
+			formatCountDelta(tag.Baseline, tag.Candidate),
+			formatDelta(tag, func(s *phout.TagSummary) string { return strconv.FormatFloat(s.RPS, 'f', 1, 64) }, tag.RPSDelta, true),
+			formatDelta(tag, func(s *phout.TagSummary) string { return strconv.FormatFloat(s.ErrorRate*100, 'f', 2, 64) + "%" }, tag.ErrorRateDelta, false),
+		}
+		for i := range quantiles {
+			var d float64
+			if i < len(tag.Quantiles) {
+				d = tag.Quantiles[i].Delta
+			}
+			row = append(row, formatDelta(tag, func(s *phout.TagSummary) string {
+				return strconv.FormatFloat(float64(s.Quantiles[i])/1000, 'f', 2, 64)
+			}, d, true))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+	for _, tag := range res.Tags {
+		for _, r := range tag.Regressions {
+			fmt.Fprintf(w, "REGRESSION %s: %s\n", tag.Tag, r)
+		}
+	}
+	return nil
+}
+
+func formatCountDelta(baseline, candidate *phout.TagSummary) string {
+	return formatPair(baseline, candidate, func(s *phout.TagSummary) string { return strconv.Itoa(s.Count) })
+}
+
+func formatDelta(tag phout.TagDelta, value func(s *phout.TagSummary) string, delta float64, relative bool) string {
+	res := formatPair(tag.Baseline, tag.Candidate, value)
+	if tag.Baseline == nil || tag.Candidate == nil {
+		return res
+	}
+	if relative {
+		return fmt.Sprintf("%s (%+.1f%%)", res, delta*100)
+	}
+	return fmt.Sprintf("%s (%+.2fpp)", res, delta*100)
+}
+
+func formatPair(baseline, candidate *phout.TagSummary, value func(s *phout.TagSummary) string) string {
+	b, c := "-", "-"
+	if baseline != nil {
+		b = value(baseline)
+	}
+	if candidate != nil {
+		c = value(candidate)
+	}
+	return b + " -> " + c
+}
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	scenarioConfig "github.com/yandex/pandora/components/providers/scenario/config"
+	"github.com/yandex/pandora/components/providers/scenario/postman"
+	"github.com/yandex/pandora/lib/openapi"
+)
+
+const genCommand = "gen"
+
+const (
+	genFormatHCL      = "hcl"
+	genFormatYAML     = "yaml"
+	genFormatJSONLine = "jsonline"
+)
+
+// genSources are sources of gen command, like `pandora gen openapi`.
+var genSources = map[string]func(args []string) int{
+	"openapi": runGenOpenAPI,
+	"postman": runGenPostman,
+}
+
+func runGen(args []string) int {
+	if len(args) == 0 || genSources[args[0]] == nil {
+		fmt.Fprintf(os.Stderr, "Usage of Pandora gen: pandora gen <source> [flags] <file>\n"+
+			"Sources: openapi, postman\n")
+		return exitError
+	}
+	return genSources[args[0]](args[1:])
+}
+
+func runGenOpenAPI(args []string) int {
+	fs := flag.NewFlagSet(genCommand+" openapi", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of Pandora gen openapi: pandora gen openapi [flags] <spec.yaml>\n"+
+			"Makes request for every operation of OpenAPI 3 document.\n"+
+			"Parameters and bodies are taken from examples, or generated from schemas.\n")
+		fs.PrintDefaults()
+	}
+	var (
+		format string
+		output string
+	)
+	weights := map[string]int64{}
+	fs.StringVar(&format, "format", genFormatHCL, "output format: hcl (scenario) or jsonline (http/json ammo)")
+	fs.StringVar(&output, "o", "", "output file; STDOUT, if empty")
+	fs.Func("weight", "operation weight as operationId=N; can be repeated; default weight is 1", func(s string) error {
+		name, value, ok := strings.Cut(s, "=")
+		weight, err := strconv.ParseInt(value, 10, 64)
+		if !ok || err != nil || weight < 1 {
+			return fmt.Errorf("weight should be operationId=N, where N is positive integer")
+		}
+		weights[name] = weight
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitError
+	}
+	if format != genFormatHCL && format != genFormatJSONLine {
+		fmt.Fprintf(os.Stderr, "Unknown format %q\n", format)
+		return exitError
+	}
+
+	requests, err := readOpenAPIRequests(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "OpenAPI document read failed: %s\n", err)
+		return exitError
+	}
+	for name := range weights {
+		if !hasRequest(requests, name) {
+			fmt.Fprintf(os.Stderr, "Unknown operation %q in weights\n", name)
+			return exitError
+		}
+	}
+
+	return writeGenOutput(output, func(w io.Writer) error {
+		if format == genFormatHCL {
+			return writeScenarioHCL(w, requests, weights)
+		}
+		return writeJSONLineAmmo(w, requests, weights)
+	})
+}
+
+func runGenPostman(args []string) int {
+	fs := flag.NewFlagSet(genCommand+" postman", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of Pandora gen postman: pandora gen postman [flags] <collection.json>\n"+
+			"Converts Postman v2.1 collection to HTTP scenario ammo.\n"+
+			"Folders become scenarios, {{variable}} references become variable source references.\n")
+		fs.PrintDefaults()
+	}
+	var (
+		format  string
+		output  string
+		envPath string
+	)
+	fs.StringVar(&format, "format", genFormatHCL, "output format: hcl or yaml")
+	fs.StringVar(&output, "o", "", "output file; STDOUT, if empty")
+	fs.StringVar(&envPath, "env", "", "Postman environment file")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitError
+	}
+	if format != genFormatHCL && format != genFormatYAML {
+		fmt.Fprintf(os.Stderr, "Unknown format %q\n", format)
+		return exitError
+	}
+
+	collection, err := readPostmanFile(fs.Arg(0), postman.ParseCollection)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Postman collection read failed: %s\n", err)
+		return exitError
+	}
+	var env *postman.Environment
+	if envPath != "" {
+		env, err = readPostmanFile(envPath, postman.ParseEnvironment)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Postman environment read failed: %s\n", err)
+			return exitError
+		}
+	}
+	ammo, warnings, err := postman.Convert(collection, env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Postman collection convert failed: %s\n", err)
+		return exitError
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	return writeGenOutput(output, func(w io.Writer) error {
+		if format == genFormatHCL {
+			return scenarioConfig.WriteHCL(w, ammo)
+		}
+		return scenarioConfig.WriteYAML(w, ammo)
+	})
+}
+
+func readPostmanFile[T any](path string, parse func(io.Reader) (*T, error)) (*T, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f)
+}
+
+// writeGenOutput writes to output file, or to STDOUT, if output is empty.
+func writeGenOutput(output string, write func(w io.Writer) error) int {
+	w := io.Writer(os.Stdout)
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Output create failed: %s\n", err)
+			return exitError
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := write(w); err != nil {
+		fmt.Fprintf(os.Stderr, "Output write failed: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func readOpenAPIRequests(path string) ([]openapi.Request, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	doc, err := openapi.Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Requests()
+}
+
+func hasRequest(requests []openapi.Request, name string) bool {
+	for _, r := range requests {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func requestWeight(weights map[string]int64, name string) int64 {
+	if w, ok := weights[name]; ok {
+		return w
+	}
+	return 1
+}
+
+// writeScenarioHCL writes request and scenario with single request for every operation.
+func writeScenarioHCL(w io.Writer, requests []openapi.Request, weights map[string]int64) error {
+	var ammo scenarioConfig.AmmoHCL
+	for _, r := range requests {
+		r := r
+		req := scenarioConfig.RequestHCL{
+			Name:    r.Name,
+			Method:  r.Method,
+			URI:     r.URI,
+			Headers: r.Headers,
+			Tag:     &r.Name,
+		}
+		if r.Body != "" {
+			req.Body = &r.Body
+		}
+		weight := requestWeight(weights, r.Name)
+		ammo.Requests = append(ammo.Requests, req)
+		ammo.Scenarios = append(ammo.Scenarios, scenarioConfig.ScenarioHCL{
+			Name:     r.Name,
+			Weight:   &weight,
+			Requests: []string{r.Name + "(1)"},
+		})
+	}
+	return scenarioConfig.WriteHCL(w, ammo)
+}
+
+// jsonLineAmmo is http/json ammo line.
+type jsonLineAmmo struct {
+	Method  string            `json:"method"`
+	URI     string            `json:"uri"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Tag     string            `json:"tag"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// writeJSONLineAmmo writes every operation request as many times, as its weight is.
+func writeJSONLineAmmo(w io.Writer, requests []openapi.Request, weights map[string]int64) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, r := range requests {
+		a := jsonLineAmmo{Method: r.Method, URI: r.URI, Headers: r.Headers, Tag: r.Name, Body: r.Body}
+		for i := requestWeight(weights, r.Name); i > 0; i-- {
+			if err := enc.Encode(a); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,372 @@
+// Package clickhouse contains aggregator, that stores netsample samples in ClickHouse table
+// using ClickHouse HTTP interface.
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+	"github.com/yandex/pandora/core/coreutil"
+	"github.com/yandex/pandora/lib/errutil"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	// URL of ClickHouse HTTP interface. Example: http://localhost:8123
+	URL      string `config:"url" validate:"required,url"`
+	Database string `config:"database" validate:"required"`
+	Table    string `config:"table" validate:"required"`
+	User     string `config:"user"`
+	Password string `config:"password"`
+	// CreateTable creates MergeTree table on start, if it doesn't exist.
+	CreateTable bool `config:"create-table"`
+	// RunID marks all samples of this run. Random UUID by default.
+	RunID string `config:"run-id"`
+	// Instance marks samples of this pandora instance. Hostname by default.
+	Instance string `config:"instance"`
+	// BatchSize is maximum number of rows in one INSERT.
+	BatchSize     int           `config:"batch-size" validate:"min=1"`
+	FlushInterval time.Duration `config:"flush-interval" validate:"min-time=1ms"`
+	// MaxPendingBatches limits number of batches waiting for insert.
+	// When limit is reached, new batches are dropped.
+	MaxPendingBatches int           `config:"max-pending-batches" validate:"min=1"`
+	Retries           int           `config:"retries" validate:"min=0"`
+	RetryInterval     time.Duration `config:"retry-interval"`
+	Timeout           time.Duration `config:"timeout"`
+
+	ReporterConfig aggregator.ReporterConfig `config:",squash"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Database:          "default",
+		Table:             "pandora_samples",
+		CreateTable:       true,
+		BatchSize:         10000,
+		FlushInterval:     time.Second,
+		MaxPendingBatches: 16,
+		Retries:           3,
+		RetryInterval:     time.Second,
+		Timeout:           10 * time.Second,
+		ReporterConfig:    aggregator.DefaultReporterConfig(),
+	}
+}
+
+// NewAggregator returns aggregator, that asynchronously batch inserts samples.
+// By default, Report never blocks: samples are dropped on queue overflow, and batches are dropped,
+// when ClickHouse can't keep up with inserts.
+func NewAggregator(conf Config) (*Aggregator, error) {
+	if conf.ReporterConfig.OnOverflow == aggregator.OverflowSpill {
+		return nil, fmt.Errorf("on-overflow: %s is not supported by clickhouse aggregator", aggregator.OverflowSpill)
+	}
+	if conf.RunID == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return nil, fmt.Errorf("run id generate failed: %w", err)
+		}
+		conf.RunID = id.String()
+	}
+	if conf.Instance == "" {
+		conf.Instance, _ = os.Hostname()
+	}
+	if _, err := url.Parse(conf.URL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	return &Aggregator{
+		Reporter: *aggregator.NewReporter(conf.ReporterConfig),
+		conf:     conf,
+		client:   &http.Client{Timeout: conf.Timeout},
+		batches:  make(chan *bytes.Buffer, conf.MaxPendingBatches),
+		bufPool:  sync.Pool{New: func() any { return &bytes.Buffer{} }},
+	}, nil
+}
+
+type Aggregator struct {
+	aggregator.Reporter
+	core.AggregatorDeps
+
+	conf     Config
+	client   *http.Client
+	batches  chan *bytes.Buffer
+	bufPool  sync.Pool
+	rowsLost atomic.Int64
+}
+
+var _ core.Aggregator = (*Aggregator)(nil)
+
+func (a *Aggregator) Run(ctx context.Context, deps core.AggregatorDeps) (err error) {
+	a.AggregatorDeps = deps
+	a.Log = deps.Log.With(zap.String("run_id", a.conf.RunID))
+	a.Log.Info("ClickHouse aggregator started", zap.String("table", a.table()))
+	if a.conf.CreateTable {
+		// Use fresh context: table should be created, even if shooting is already finished.
+		err = a.withRetries(context.Background(), func(ctx context.Context) error {
+			return a.query(ctx, a.createTableQuery(), nil)
+		})
+		if err != nil {
+			return fmt.Errorf("table create failed: %w", err)
+		}
+	}
+
+	senderDone := make(chan struct{})
+	go func() {
+		defer close(senderDone)
+		a.sendBatches()
+	}()
+	defer func() {
+		close(a.batches)
+		<-senderDone
+		err = errutil.Join(err, a.DroppedErr())
+		a.LogOverflow(a.Log)
+		if lost := a.rowsLost.Load(); lost > 0 {
+			err = errutil.Join(err, fmt.Errorf("%v rows were not inserted", lost))
+		}
+	}()
+
+	flushTicker := time.NewTicker(a.conf.FlushInterval)
+	defer flushTicker.Stop()
+	buf := a.bufPool.Get().(*bytes.Buffer)
+	rows := 0
+	flush := func() {
+		if rows == 0 {
+			return
+		}
+		select {
+		case a.batches <- buf:
+		default:
+			a.Log.Warn("Too many pending batches. Dropping batch", zap.Int("rows", rows))
+			a.rowsLost.Add(int64(rows))
+			buf.Reset()
+			a.bufPool.Put(buf)
+		}
+		buf = a.bufPool.Get().(*bytes.Buffer)
+		rows = 0
+	}
+	handle := func(s core.Sample) {
+		a.appendRow(buf, s.(*netsample.Sample))
+		coreutil.ReturnSampleIfBorrowed(s)
+		rows++
+		if rows >= a.conf.BatchSize {
+			flush()
+		}
+	}
+	for {
+		select {
+		case s := <-a.Incomming:
+			handle(s)
+		case <-flushTicker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case s := <-a.Incomming:
+					handle(s)
+				default:
+					flush()
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (a *Aggregator) sendBatches() {
+	for buf := range a.batches {
+		rows := bytes.Count(buf.Bytes(), []byte{'\n'})
+		body := buf.Bytes()
+		err := a.withRetries(context.Background(), func(ctx context.Context) error {
+			return a.query(ctx, a.insertQuery(), body)
+		})
+		if err != nil {
+			a.Log.Error("Batch insert failed", zap.Int("rows", rows), zap.Error(err))
+			a.rowsLost.Add(int64(rows))
+		}
+		buf.Reset()
+		a.bufPool.Put(buf)
+	}
+}
+
+func (a *Aggregator) withRetries(ctx context.Context, do func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt <= a.conf.Retries; attempt++ {
+		if attempt > 0 {
+			a.Log.Warn("ClickHouse request failed. Retrying", zap.Int("attempt", attempt), zap.Error(err))
+			time.Sleep(a.conf.RetryInterval)
+		}
+		err = do(ctx)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (a *Aggregator) query(ctx context.Context, query string, body []byte) error {
+	u, _ := url.Parse(a.conf.URL)
+	q := u.Query()
+	q.Set("query", query)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if a.conf.User != "" {
+		req.Header.Set("X-ClickHouse-User", a.conf.User)
+		req.Header.Set("X-ClickHouse-Key", a.conf.Password)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (a *Aggregator) table() string {
+	return quoteIdentifier(a.conf.Database) + "." + quoteIdentifier(a.conf.Table)
+}
+
+func (a *Aggregator) createTableQuery() string {
+	return "CREATE TABLE IF NOT EXISTS " + a.table() + ` (
+	ts DateTime64(6, 'UTC'),
+	tag String,
+	rtt_us Int64,
+	response_us Int64,
+	connect_us Int64,
+	send_us Int64,
+	latency_us Int64,
+	receive_us Int64,
+	interval_event_us Int64,
+	request_bytes Int64,
+	response_bytes Int64,
+	net_code Int32,
+	proto_code Int32,
+	error_kind LowCardinality(String),
+	pool LowCardinality(String),
+	instance LowCardinality(String),
+	run_id LowCardinality(String),
+	labels Map(String, String),
+	metrics Map(String, Float64)
+) ENGINE = MergeTree() ORDER BY (run_id, ts)`
+}
+
+func (a *Aggregator) insertQuery() string {
+	return "INSERT INTO " + a.table() + " (ts, tag, rtt_us, response_us, connect_us, send_us, latency_us, receive_us, " +
+		"interval_event_us, request_bytes, response_bytes, net_code, proto_code, error_kind, pool, instance, run_id, labels, metrics) FORMAT TabSeparated"
+}
+
+const tsvTimeLayout = "2006-01-02 15:04:05.000000"
+
+func (a *Aggregator) appendRow(buf *bytes.Buffer, s *netsample.Sample) {
+	b := buf.AvailableBuffer()
+	b = s.Timestamp().UTC().AppendFormat(b, tsvTimeLayout)
+	b = append(b, '\t')
+	b = appendEscaped(b, s.Tags())
+	for _, d := range []time.Duration{s.RTT(), s.ResponseTime(), s.ConnectTime(), s.SendTime(), s.Latency(), s.ReceiveTime(), s.IntervalEvent()} {
+		b = append(b, '\t')
+		b = strconv.AppendInt(b, d.Microseconds(), 10)
+	}
+	for _, v := range []int{s.RequestBytes(), s.ResponseBytes(), s.NetCode(), s.ProtoCode()} {
+		b = append(b, '\t')
+		b = strconv.AppendInt(b, int64(v), 10)
+	}
+	for _, v := range []string{s.ErrKind(), a.PoolID, a.conf.Instance, a.conf.RunID} {
+		b = append(b, '\t')
+		b = appendEscaped(b, v)
+	}
+	b = append(b, "\t{"...)
+	for i, l := range s.Labels() {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendQuoted(b, l.Key)
+		b = append(b, ':')
+		b = appendQuoted(b, l.Value)
+	}
+	b = append(b, "}\t{"...)
+	for i, m := range s.Metrics() {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendQuoted(b, m.Name)
+		b = append(b, ':')
+		b = appendFloat(b, m.Value)
+	}
+	b = append(b, "}\n"...)
+	buf.Write(b)
+}
+
+// appendFloat appends float in ClickHouse text format, that has nan, inf and -inf
+// instead of Go NaN, +Inf and -Inf.
+func appendFloat(dst []byte, v float64) []byte {
+	switch {
+	case math.IsNaN(v):
+		return append(dst, "nan"...)
+	case math.IsInf(v, 1):
+		return append(dst, "inf"...)
+	case math.IsInf(v, -1):
+		return append(dst, "-inf"...)
+	}
+	return strconv.AppendFloat(dst, v, 'g', -1, 64)
+}
+
+// appendEscaped appends string escaped due to TabSeparated format rules.
+func appendEscaped(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			dst = append(dst, '\\', '\\')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// appendQuoted appends string literal of Map key or value. TabSeparated writes them with the same escaping, as strings.
+func appendQuoted(dst []byte, s string) []byte {
+	dst = append(dst, '\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'':
+			dst = append(dst, '\\', '\'')
+		case '\\':
+			dst = append(dst, '\\', '\\')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '\'')
+}
+
+func quoteIdentifier(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "\\`") + "`"
+}
@@ -0,0 +1,130 @@
+package clickhouse
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+	"go.uber.org/zap"
+)
+
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	queries  []string
+	bodies   []string
+	failures int
+}
+
+func newTestServer(failures int) *testServer {
+	s := &testServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.failures > 0 {
+			s.failures--
+			http.Error(w, "Code: 999. Temporary failure", http.StatusServiceUnavailable)
+			return
+		}
+		s.queries = append(s.queries, r.URL.Query().Get("query"))
+		s.bodies = append(s.bodies, string(body))
+	}))
+	return s
+}
+
+func testConfig(url string) Config {
+	conf := DefaultConfig()
+	conf.URL = url
+	conf.RunID = "run"
+	conf.Instance = "host"
+	conf.RetryInterval = time.Millisecond
+	conf.FlushInterval = time.Hour
+	return conf
+}
+
+func testSample(tag string) *netsample.Sample {
+	s := netsample.Acquire(tag)
+	s.SetScheduledTime(s.Timestamp().Add(-time.Millisecond))
+	s.SetUserDuration(1500 * time.Microsecond)
+	s.SetRequestBytes(10)
+	s.SetResponseBytes(20)
+	s.SetUserProto(200)
+	return s
+}
+
+func TestAggregator(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+	}{
+		{"no failures", 0},
+		{"retried", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(tt.failures)
+			defer server.Close()
+			conf := testConfig(server.URL)
+			conf.BatchSize = 2
+			a, err := NewAggregator(conf)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			a.Report(testSample("a"))
+			labeled := testSample("b\tc")
+			labeled.SetLabel("shard", "it's 1")
+			labeled.SetLabel("dc", "vla")
+			labeled.SetMetric("cache_hit", 1)
+			labeled.SetMetric("queue_ms", 2.5)
+			labeled.SetMetric("ratio", math.NaN())
+			labeled.SetMetric("limit", math.Inf(1))
+			a.Report(labeled)
+			a.Report(testSample("d"))
+			cancel()
+			err = a.Run(ctx, core.AggregatorDeps{Log: zap.L(), PoolID: "pool"})
+			require.NoError(t, err)
+
+			require.Len(t, server.queries, 3)
+			assert.True(t, strings.HasPrefix(server.queries[0], "CREATE TABLE IF NOT EXISTS `default`.`pandora_samples`"))
+			assert.True(t, strings.HasPrefix(server.queries[1], "INSERT INTO `default`.`pandora_samples`"))
+			rows := strings.Split(strings.TrimSuffix(server.bodies[1], "\n"), "\n")
+			require.Len(t, rows, 2)
+			assert.True(t, strings.HasSuffix(rows[0], "\t{}\t{}"), rows[0])
+			fields := strings.Split(rows[1], "\t")
+			require.Len(t, fields, 19)
+			assert.Equal(t, `b\tc`, fields[1])
+			assert.Equal(t, []string{"1500", "2500", "0", "0", "0", "0", "0", "10", "20", "0", "200", "", "pool", "host", "run",
+				`{'shard':'it\'s 1','dc':'vla'}`, `{'cache_hit':1,'queue_ms':2.5,'ratio':nan,'limit':inf}`}, fields[2:])
+			assert.Equal(t, 1, strings.Count(server.bodies[2], "\n"))
+		})
+	}
+}
+
+func TestAggregatorInsertFailed(t *testing.T) {
+	server := newTestServer(0)
+	defer server.Close()
+	conf := testConfig(server.URL)
+	conf.CreateTable = false
+	conf.Retries = 1
+	a, err := NewAggregator(conf)
+	require.NoError(t, err)
+
+	server.failures = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	a.Report(testSample("a"))
+	cancel()
+	err = a.Run(ctx, core.AggregatorDeps{Log: zap.L()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 rows were not inserted")
+}
@@ -0,0 +1,300 @@
+// Package errsummary contains aggregator, that groups failed samples by tag and normalized
+// error message, and reports most frequent errors at the end of shooting.
+package errsummary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+	"github.com/yandex/pandora/core/coreutil"
+	"github.com/yandex/pandora/lib/errutil"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	// Top is number of most frequent errors in summary.
+	Top int `config:"top" validate:"min=1"`
+	// MaxGroups limits number of distinct errors kept in memory.
+	// Errors, that don't fit, are counted, but not grouped.
+	MaxGroups int `config:"max-groups" validate:"min=1"`
+	// Sink is optional. If set, summary is written to it in JSON Lines format. Otherwise, it is logged.
+	Sink core.DataSink `config:"sink"`
+	// Next is optional aggregator, that receives all samples. For example, phout.
+	Next core.Aggregator `config:"next"`
+
+	ReporterConfig aggregator.ReporterConfig `config:",squash"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Top:            20,
+		MaxGroups:      10000,
+		ReporterConfig: aggregator.DefaultReporterConfig(),
+	}
+}
+
+func NewAggregator(conf Config) (*Aggregator, error) {
+	if conf.ReporterConfig.OnOverflow == aggregator.OverflowSpill {
+		return nil, fmt.Errorf("on-overflow: %s is not supported by error-summary aggregator", aggregator.OverflowSpill)
+	}
+	return &Aggregator{
+		Reporter: *aggregator.NewReporter(conf.ReporterConfig),
+		conf:     conf,
+		groups:   map[groupKey]*Group{},
+	}, nil
+}
+
+type Aggregator struct {
+	aggregator.Reporter
+	core.AggregatorDeps
+
+	conf   Config
+	groups map[groupKey]*Group
+	total  int64
+	// ungrouped is number of errors, that didn't fit into MaxGroups.
+	ungrouped int64
+}
+
+var _ core.Aggregator = (*Aggregator)(nil)
+
+// Group is summary of errors with same tag and normalized message.
+type Group struct {
+	Tag  string `json:"tag"`
+	Kind string `json:"kind,omitempty"`
+	// Message is normalized error message: numbers and addresses are replaced with placeholders.
+	Message string `json:"message"`
+	// Example is original message of first error.
+	Example   string    `json:"example"`
+	ExampleID uint64    `json:"example_id,omitempty"`
+	Count     int64     `json:"count"`
+	First     time.Time `json:"first"`
+	Last      time.Time `json:"last"`
+}
+
+type groupKey struct {
+	tag     string
+	message string
+}
+
+// failure is extracted from sample in Report, so sample can be passed to next aggregator.
+type failure struct {
+	tag     string
+	kind    string
+	message string
+	id      uint64
+	ts      time.Time
+}
+
+func (a *Aggregator) Report(s core.Sample) {
+	if sample, ok := s.(*netsample.Sample); ok {
+		if message := failureMessage(sample); message != "" {
+			a.Reporter.Report(&failure{
+				tag:     sample.Tags(),
+				kind:    sample.ErrKind(),
+				message: message,
+				id:      sample.ID(),
+				ts:      sample.Timestamp(),
+			})
+		}
+	}
+	if a.conf.Next != nil {
+		a.conf.Next.Report(s)
+		return
+	}
+	coreutil.ReturnSampleIfBorrowed(s)
+}
+
+// failureMessage returns error message of failed sample, or empty string, if sample is successful.
+func failureMessage(s *netsample.Sample) string {
+	switch {
+	case s.Err() != nil:
+		return s.Err().Error()
+	case s.NetCode() != 0:
+		return "net code " + strconv.Itoa(s.NetCode())
+	case s.ProtoCode() >= 400:
+		return "proto code " + strconv.Itoa(s.ProtoCode())
+	}
+	return ""
+}
+
+func (a *Aggregator) Run(ctx context.Context, deps core.AggregatorDeps) (err error) {
+	a.AggregatorDeps = deps
+	nextErr := make(chan error, 1)
+	if a.conf.Next != nil {
+		go func() {
+			nextErr <- a.conf.Next.Run(ctx, deps)
+		}()
+	} else {
+		nextErr <- nil
+	}
+	defer func() {
+		err = errutil.Join(err, a.writeSummary())
+		err = errutil.Join(err, a.DroppedErr())
+		a.LogOverflow(a.Log)
+		err = errutil.Join(err, <-nextErr)
+	}()
+	for {
+		select {
+		case s := <-a.Incomming:
+			a.handle(s.(*failure))
+		case <-ctx.Done():
+			for {
+				select {
+				case s := <-a.Incomming:
+					a.handle(s.(*failure))
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (a *Aggregator) handle(f *failure) {
+	a.total++
+	key := groupKey{tag: f.tag, message: Normalize(f.message)}
+	group, ok := a.groups[key]
+	if !ok {
+		if len(a.groups) >= a.conf.MaxGroups {
+			a.ungrouped++
+			return
+		}
+		group = &Group{
+			Tag:       f.tag,
+			Kind:      f.kind,
+			Message:   key.message,
+			Example:   f.message,
+			ExampleID: f.id,
+			First:     f.ts,
+			Last:      f.ts,
+		}
+		a.groups[key] = group
+	}
+	group.Count++
+	if f.ts.Before(group.First) {
+		group.First = f.ts
+	}
+	if f.ts.After(group.Last) {
+		group.Last = f.ts
+	}
+}
+
+// Top returns at most n most frequent error groups.
+func (a *Aggregator) Top(n int) []*Group {
+	groups := make([]*Group, 0, len(a.groups))
+	for _, g := range a.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		if groups[i].Tag != groups[j].Tag {
+			return groups[i].Tag < groups[j].Tag
+		}
+		return groups[i].Message < groups[j].Message
+	})
+	if len(groups) > n {
+		groups = groups[:n]
+	}
+	return groups
+}
+
+func (a *Aggregator) writeSummary() error {
+	top := a.Top(a.conf.Top)
+	if a.conf.Sink != nil {
+		return a.writeSink(top)
+	}
+	if a.total == 0 {
+		a.Log.Info("Error summary: no errors")
+		return nil
+	}
+	a.Log.Info("Error summary",
+		zap.Int64("errors", a.total), zap.Int("groups", len(a.groups)), zap.Int64("ungrouped", a.ungrouped))
+	for i, g := range top {
+		a.Log.Info(fmt.Sprintf("Error #%d", i+1),
+			zap.Int64("count", g.Count),
+			zap.String("tag", g.Tag),
+			zap.String("kind", g.Kind),
+			zap.String("message", g.Message),
+			zap.String("example", g.Example),
+			zap.Uint64("example_id", g.ExampleID),
+			zap.Time("first", g.First),
+			zap.Time("last", g.Last),
+		)
+	}
+	return nil
+}
+
+func (a *Aggregator) writeSink(top []*Group) (err error) {
+	sink, err := a.conf.Sink.OpenSink()
+	if err != nil {
+		return fmt.Errorf("error summary sink open failed: %w", err)
+	}
+	defer func() {
+		err = errutil.Join(err, sink.Close())
+	}()
+	enc := json.NewEncoder(sink)
+	for _, g := range top {
+		if err := enc.Encode(g); err != nil {
+			return fmt.Errorf("error summary write failed: %w", err)
+		}
+	}
+	return nil
+}
+
+var (
+	uuidRegexp     = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	ipv4Regexp     = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`)
+	ipv6Regexp     = regexp.MustCompile(`\[[0-9a-fA-F:.]*:[0-9a-fA-F:.]*\](:\d+)?`)
+	hostPortRegexp = regexp.MustCompile(`\b(localhost|([\w-]+\.)+[a-zA-Z][\w-]*):\d+\b`)
+	hexRegexp      = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`)
+)
+
+// Normalize replaces parts of error message, that differ from one error occurrence to another,
+// with placeholders. Example:
+// "dial tcp 10.0.0.1:8080: i/o timeout after 1.5s" -> "dial tcp <addr>: i/o timeout after <n>s".
+func Normalize(message string) string {
+	message = uuidRegexp.ReplaceAllString(message, "<uuid>")
+	message = ipv4Regexp.ReplaceAllString(message, "<addr>")
+	message = ipv6Regexp.ReplaceAllString(message, "<addr>")
+	message = hostPortRegexp.ReplaceAllString(message, "<addr>")
+	message = hexRegexp.ReplaceAllString(message, "<n>")
+	return replaceNumbers(message)
+}
+
+// replaceNumbers replaces integer and decimal numbers with <n>. Digits, that follow letter,
+// are part of word, like http2, and are kept.
+func replaceNumbers(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		if !isDigit(s[i]) || i > 0 && isLetter(s[i-1]) {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+		if i+1 < len(s) && s[i] == '.' && isDigit(s[i+1]) {
+			for i++; i < len(s) && isDigit(s[i]); i++ {
+			}
+		}
+		b.WriteString("<n>")
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' }
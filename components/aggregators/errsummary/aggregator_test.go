@@ -0,0 +1,108 @@
+package errsummary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+	"github.com/yandex/pandora/core/datasink"
+	"go.uber.org/zap"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"dial tcp 10.0.0.1:8080: i/o timeout after 1.5s", "dial tcp <addr>: i/o timeout after <n>s"},
+		{"read tcp 127.0.0.1:53412->127.0.0.1:80: read: connection reset by peer", "read tcp <addr>-><addr>: read: connection reset by peer"},
+		{"dial tcp [::1]:443: connect: connection refused", "dial tcp <addr>: connect: connection refused"},
+		{`Get "http://example.com:8080/users/42": EOF`, `Get "http://<addr>/users/<n>": EOF`},
+		{"user 3f2504e0-4f89-11d3-9a0c-0305e82c3301 not found", "user <uuid> not found"},
+		{"http2: stream 0x1f closed", "http2: stream <n> closed"},
+		{"proto code 503", "proto code <n>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.message))
+		})
+	}
+}
+
+func testSample(tag string, id uint64, err error, protoCode int) *netsample.Sample {
+	s := netsample.Acquire(tag)
+	s.SetID(id)
+	s.SetUserProto(protoCode)
+	if err != nil {
+		s.SetErr(err)
+	}
+	s.SetUserDuration(time.Millisecond)
+	return s
+}
+
+func TestAggregator(t *testing.T) {
+	sink := datasink.NewBuffer()
+	next := aggregator.NewTest()
+	conf := DefaultConfig()
+	conf.Top = 2
+	conf.Sink = sink
+	conf.Next = next
+	a, err := NewAggregator(conf)
+	require.NoError(t, err)
+
+	a.Report(testSample("get", 1, errors.New("dial tcp 10.0.0.1:80: i/o timeout"), 0))
+	a.Report(testSample("get", 2, nil, 200))
+	a.Report(testSample("get", 3, errors.New("dial tcp 10.0.0.2:80: i/o timeout"), 0))
+	a.Report(testSample("post", 4, nil, 503))
+	a.Report(testSample("post", 5, errors.New("unexpected EOF"), 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = a.Run(ctx, core.AggregatorDeps{Log: zap.L()})
+	require.NoError(t, err)
+	assert.Len(t, next.GetSamples(), 5)
+
+	lines := strings.Split(strings.TrimSuffix(sink.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var first Group
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "get", first.Tag)
+	assert.Equal(t, "dial tcp <addr>: i/o timeout", first.Message)
+	assert.Equal(t, "dial tcp 10.0.0.1:80: i/o timeout", first.Example)
+	assert.Equal(t, netsample.ErrKindUnknown, first.Kind)
+	assert.EqualValues(t, 2, first.Count)
+	assert.EqualValues(t, 1, first.ExampleID)
+	assert.False(t, first.First.After(first.Last))
+
+	var second Group
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "post", second.Tag)
+	assert.Equal(t, "proto code <n>", second.Message)
+	assert.EqualValues(t, 1, second.Count)
+}
+
+func TestAggregatorMaxGroups(t *testing.T) {
+	conf := DefaultConfig()
+	conf.MaxGroups = 1
+	a, err := NewAggregator(conf)
+	require.NoError(t, err)
+	a.Report(testSample("a", 0, errors.New("first"), 0))
+	a.Report(testSample("a", 0, errors.New("second"), 0))
+	a.Report(testSample("a", 0, errors.New("first"), 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, a.Run(ctx, core.AggregatorDeps{Log: zap.L()}))
+
+	top := a.Top(10)
+	require.Len(t, top, 1)
+	assert.EqualValues(t, 2, top[0].Count)
+	assert.EqualValues(t, 1, a.ungrouped)
+}
@@ -0,0 +1,16 @@
+package aggregators
+
+import (
+	"github.com/spf13/afero"
+	"github.com/yandex/pandora/components/aggregators/clickhouse"
+	"github.com/yandex/pandora/components/aggregators/errsummary"
+	"github.com/yandex/pandora/components/aggregators/statsd"
+	"github.com/yandex/pandora/core/register"
+)
+
+func Import(fs afero.Fs) {
+	register.Aggregator("clickhouse", clickhouse.NewAggregator, clickhouse.DefaultConfig)
+	register.Aggregator("statsd", statsd.NewAggregator, statsd.DefaultStatsDConfig)
+	register.Aggregator("graphite", statsd.NewAggregator, statsd.DefaultGraphiteConfig)
+	register.Aggregator("error-summary", errsummary.NewAggregator, errsummary.DefaultConfig)
+}
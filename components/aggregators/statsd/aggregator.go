@@ -0,0 +1,497 @@
+// Package statsd contains aggregator, that periodically sends per tag counters and timers
+// to StatsD over UDP or to Graphite using plaintext protocol over TCP.
+package statsd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+	"github.com/yandex/pandora/core/coreutil"
+	"go.uber.org/zap"
+)
+
+const (
+	ProtocolStatsD   = "statsd"
+	ProtocolGraphite = "graphite"
+)
+
+type Config struct {
+	// Protocol is statsd (UDP) or graphite (plaintext TCP).
+	Protocol string `config:"protocol" validate:"oneof=statsd graphite"`
+	Address  string `config:"address" validate:"required"`
+	// Prefix is prepended to all metric names. Example: pandora.my-service
+	Prefix        string        `config:"prefix"`
+	FlushInterval time.Duration `config:"flush-interval" validate:"min-time=1ms"`
+	// Percentiles of RTT reported to Graphite. StatsD calculates percentiles by itself.
+	Percentiles []float64 `config:"percentiles" validate:"dive,gt=0,lte=100"`
+	// RTT is service or response. With response, rtt is measured since scheduled shot start.
+	RTT string `config:"rtt" validate:"oneof=service response"`
+	// MaxPacketSize limits size of StatsD UDP datagram. Several metrics are packed into one datagram.
+	MaxPacketSize int `config:"max-packet-size" validate:"min=64"`
+	// MaxTimers limits StatsD timer values of tag, that are sent per flush interval. If there are more values,
+	// evenly spaced ones are sent with |@rate sample rate, so StatsD scales counts back. Zero means no limit.
+	MaxTimers int           `config:"max-timers" validate:"min=0"`
+	Timeout   time.Duration `config:"timeout"`
+
+	ReporterConfig aggregator.ReporterConfig `config:",squash"`
+}
+
+func DefaultStatsDConfig() Config {
+	return Config{
+		Protocol:       ProtocolStatsD,
+		Address:        "localhost:8125",
+		Prefix:         "pandora",
+		FlushInterval:  time.Second,
+		Percentiles:    []float64{50, 90, 95, 99},
+		RTT:            netsample.RTTService,
+		MaxPacketSize:  1432,
+		MaxTimers:      100,
+		Timeout:        5 * time.Second,
+		ReporterConfig: aggregator.DefaultReporterConfig(),
+	}
+}
+
+func DefaultGraphiteConfig() Config {
+	conf := DefaultStatsDConfig()
+	conf.Protocol = ProtocolGraphite
+	conf.Address = "localhost:2003"
+	conf.FlushInterval = 10 * time.Second
+	return conf
+}
+
+func NewAggregator(conf Config) (*Aggregator, error) {
+	if conf.ReporterConfig.OnOverflow == aggregator.OverflowSpill {
+		return nil, fmt.Errorf("on-overflow: %s is not supported by %s aggregator", aggregator.OverflowSpill, conf.Protocol)
+	}
+	conf.Prefix = strings.Trim(conf.Prefix, ".")
+	return &Aggregator{
+		Reporter: *aggregator.NewReporter(conf.ReporterConfig),
+		conf:     conf,
+		tags:     map[string]*tagMetrics{},
+	}, nil
+}
+
+type Aggregator struct {
+	aggregator.Reporter
+	core.AggregatorDeps
+
+	conf Config
+	conn net.Conn
+	tags map[string]*tagMetrics
+	buf  bytes.Buffer
+}
+
+var _ core.Aggregator = (*Aggregator)(nil)
+
+// tagMetrics accumulates samples of one tag and label set between flushes.
+type tagMetrics struct {
+	name       string
+	labels     []netsample.Label
+	requests   int64
+	errors     int64
+	protoCodes map[int]int64
+	netCodes   map[int]int64
+	errKinds   map[string]int64
+	rtts       []time.Duration
+	metrics    map[string][]float64
+}
+
+func (a *Aggregator) Run(ctx context.Context, deps core.AggregatorDeps) (err error) {
+	a.AggregatorDeps = deps
+	a.Log.Info("Metrics aggregator started",
+		zap.String("protocol", a.conf.Protocol), zap.String("address", a.conf.Address))
+	defer func() {
+		if a.conn != nil {
+			_ = a.conn.Close()
+		}
+		err = a.DroppedErr()
+		a.LogOverflow(a.Log)
+	}()
+
+	flushTicker := time.NewTicker(a.conf.FlushInterval)
+	defer flushTicker.Stop()
+	for {
+		select {
+		case s := <-a.Incomming:
+			a.handle(s)
+		case now := <-flushTicker.C:
+			a.flush(now)
+		case <-ctx.Done():
+			for {
+				select {
+				case s := <-a.Incomming:
+					a.handle(s)
+				default:
+					a.flush(time.Now())
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (a *Aggregator) handle(s core.Sample) {
+	sample := s.(*netsample.Sample)
+	labels := metricLabels(sample.Labels())
+	key := sample.Tags()
+	for _, l := range labels {
+		key += "\x00" + l.Key + "=" + l.Value
+	}
+	tag, ok := a.tags[key]
+	if !ok {
+		tag = &tagMetrics{
+			name:       metricName(sample.Tags()),
+			labels:     labels,
+			protoCodes: map[int]int64{},
+			netCodes:   map[int]int64{},
+			errKinds:   map[string]int64{},
+			metrics:    map[string][]float64{},
+		}
+		a.tags[key] = tag
+	}
+	tag.requests++
+	netCode, protoCode := sample.NetCode(), sample.ProtoCode()
+	if netCode != 0 || protoCode >= 400 {
+		tag.errors++
+	}
+	if netCode != 0 {
+		tag.netCodes[netCode]++
+	} else {
+		tag.protoCodes[protoCode]++
+	}
+	if kind := sample.ErrKind(); kind != "" {
+		tag.errKinds[kind]++
+	}
+	rtt := sample.ServiceTime()
+	if a.conf.RTT == netsample.RTTResponse {
+		rtt = sample.ResponseTime()
+	}
+	tag.rtts = append(tag.rtts, rtt)
+	for _, m := range sample.Metrics() {
+		name := sanitize(m.Name)
+		tag.metrics[name] = append(tag.metrics[name], m.Value)
+	}
+	coreutil.ReturnSampleIfBorrowed(s)
+}
+
+// flush sends metrics accumulated since previous flush and resets them.
+// Send errors are logged, but not returned: metrics are best effort and should not stop shooting.
+func (a *Aggregator) flush(now time.Time) {
+	var err error
+	if a.conf.Protocol == ProtocolGraphite {
+		err = a.sendGraphite(now)
+	} else {
+		err = a.sendStatsD()
+	}
+	if err != nil {
+		a.Log.Warn("Metrics send failed", zap.Error(err))
+		if a.conn != nil {
+			_ = a.conn.Close()
+			a.conn = nil
+		}
+	}
+	for key, tag := range a.tags {
+		if tag.requests == 0 {
+			// Tag had no samples during whole interval.
+			delete(a.tags, key)
+			continue
+		}
+		tag.requests = 0
+		tag.errors = 0
+		clear(tag.protoCodes)
+		clear(tag.netCodes)
+		clear(tag.errKinds)
+		tag.rtts = tag.rtts[:0]
+		for name := range tag.metrics {
+			tag.metrics[name] = tag.metrics[name][:0]
+		}
+	}
+}
+
+func (a *Aggregator) sendStatsD() error {
+	var lines [][]byte
+	for _, key := range a.sortedTags() {
+		tag := a.tags[key]
+		suffix := statsdTags(tag.labels)
+		counter := func(name string, value int64) {
+			line := append([]byte(a.metric(tag.name+name)), ':')
+			line = strconv.AppendInt(line, value, 10)
+			lines = append(lines, append(append(line, "|c"...), suffix...))
+		}
+		timers := func(name string, values []float64) {
+			values, rate := sampleTimers(values, a.conf.MaxTimers)
+			for _, value := range values {
+				line := append([]byte(a.metric(tag.name+name)), ':')
+				line = strconv.AppendFloat(line, value, 'f', -1, 64)
+				line = append(line, "|ms"...)
+				if rate < 1 {
+					line = append(line, "|@"...)
+					line = strconv.AppendFloat(line, rate, 'f', -1, 64)
+				}
+				lines = append(lines, append(line, suffix...))
+			}
+		}
+		counter(".requests", tag.requests)
+		counter(".errors", tag.errors)
+		for _, code := range sortedCodes(tag.protoCodes) {
+			counter(".proto_code."+strconv.Itoa(code), tag.protoCodes[code])
+		}
+		for _, code := range sortedCodes(tag.netCodes) {
+			counter(".net_code."+strconv.Itoa(code), tag.netCodes[code])
+		}
+		for _, kind := range sortedKinds(tag.errKinds) {
+			counter(".error_kind."+kind, tag.errKinds[kind])
+		}
+		rtts := make([]float64, len(tag.rtts))
+		for i, rtt := range tag.rtts {
+			rtts[i] = float64(rtt) / float64(time.Millisecond)
+		}
+		timers(".rtt", rtts)
+		for _, name := range sortedMetrics(tag.metrics) {
+			timers(".metric."+name, tag.metrics[name])
+		}
+	}
+	// Pack lines into datagrams, not exceeding MaxPacketSize.
+	a.buf.Reset()
+	for _, line := range lines {
+		if a.buf.Len() > 0 && a.buf.Len()+1+len(line) > a.conf.MaxPacketSize {
+			if err := a.write(a.buf.Bytes()); err != nil {
+				return err
+			}
+			a.buf.Reset()
+		}
+		if a.buf.Len() > 0 {
+			a.buf.WriteByte('\n')
+		}
+		a.buf.Write(line)
+	}
+	if a.buf.Len() == 0 {
+		return nil
+	}
+	return a.write(a.buf.Bytes())
+}
+
+func (a *Aggregator) sendGraphite(now time.Time) error {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	a.buf.Reset()
+	for _, key := range a.sortedTags() {
+		tag := a.tags[key]
+		suffix := graphiteTags(tag.labels)
+		line := func(name string, value string) {
+			fmt.Fprintf(&a.buf, "%s%s %s %s\n", a.metric(name), suffix, value, ts)
+		}
+		line(tag.name+".requests", strconv.FormatInt(tag.requests, 10))
+		line(tag.name+".errors", strconv.FormatInt(tag.errors, 10))
+		for _, code := range sortedCodes(tag.protoCodes) {
+			line(tag.name+".proto_code."+strconv.Itoa(code), strconv.FormatInt(tag.protoCodes[code], 10))
+		}
+		for _, code := range sortedCodes(tag.netCodes) {
+			line(tag.name+".net_code."+strconv.Itoa(code), strconv.FormatInt(tag.netCodes[code], 10))
+		}
+		for _, kind := range sortedKinds(tag.errKinds) {
+			line(tag.name+".error_kind."+kind, strconv.FormatInt(tag.errKinds[kind], 10))
+		}
+		for _, name := range sortedMetrics(tag.metrics) {
+			values := tag.metrics[name]
+			minValue, maxValue, sum := values[0], values[0], 0.0
+			for _, v := range values {
+				minValue, maxValue, sum = math.Min(minValue, v), math.Max(maxValue, v), sum+v
+			}
+			line(tag.name+".metric."+name+".min", formatFloat(minValue))
+			line(tag.name+".metric."+name+".max", formatFloat(maxValue))
+			line(tag.name+".metric."+name+".mean", formatFloat(sum/float64(len(values))))
+		}
+		if len(tag.rtts) == 0 {
+			continue
+		}
+		sort.Slice(tag.rtts, func(i, j int) bool { return tag.rtts[i] < tag.rtts[j] })
+		var sum time.Duration
+		for _, rtt := range tag.rtts {
+			sum += rtt
+		}
+		line(tag.name+".rtt.min", formatMillis(tag.rtts[0]))
+		line(tag.name+".rtt.max", formatMillis(tag.rtts[len(tag.rtts)-1]))
+		line(tag.name+".rtt.mean", formatMillis(sum/time.Duration(len(tag.rtts))))
+		for _, p := range a.conf.Percentiles {
+			name := "p" + strings.ReplaceAll(strconv.FormatFloat(p, 'f', -1, 64), ".", "_")
+			line(tag.name+".rtt."+name, formatMillis(percentile(tag.rtts, p)))
+		}
+	}
+	if a.buf.Len() == 0 {
+		return nil
+	}
+	return a.write(a.buf.Bytes())
+}
+
+func (a *Aggregator) write(data []byte) error {
+	if a.conn == nil {
+		network := "udp"
+		if a.conf.Protocol == ProtocolGraphite {
+			network = "tcp"
+		}
+		conn, err := net.DialTimeout(network, a.conf.Address, a.conf.Timeout)
+		if err != nil {
+			return err
+		}
+		a.conn = conn
+	}
+	if a.conf.Timeout > 0 {
+		_ = a.conn.SetWriteDeadline(time.Now().Add(a.conf.Timeout))
+	}
+	_, err := a.conn.Write(data)
+	return err
+}
+
+func (a *Aggregator) metric(name string) string {
+	if a.conf.Prefix == "" {
+		return name
+	}
+	return a.conf.Prefix + "." + name
+}
+
+func (a *Aggregator) sortedTags() []string {
+	keys := make([]string, 0, len(a.tags))
+	for key, tag := range a.tags {
+		if tag.requests > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedCodes(codes map[int]int64) []int {
+	res := make([]int, 0, len(codes))
+	for code := range codes {
+		res = append(res, code)
+	}
+	sort.Ints(res)
+	return res
+}
+
+// sortedMetrics returns names of metrics, that have values in current interval.
+func sortedMetrics(metrics map[string][]float64) []string {
+	res := make([]string, 0, len(metrics))
+	for name, values := range metrics {
+		if len(values) > 0 {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sortedKinds(kinds map[string]int64) []string {
+	res := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		res = append(res, kind)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// sampleTimers returns at most limit values, that are evenly spaced in arrival order, and their sample rate.
+// All values are returned with rate 1, if limit is zero or not exceeded.
+func sampleTimers(values []float64, limit int) ([]float64, float64) {
+	if limit <= 0 || len(values) <= limit {
+		return values, 1
+	}
+	sampled := make([]float64, limit)
+	for i := range sampled {
+		sampled[i] = values[i*len(values)/limit]
+	}
+	return sampled, float64(limit) / float64(len(values))
+}
+
+// percentile returns p-th percentile of sorted rtts using nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func formatMillis(d time.Duration) string {
+	return formatFloat(float64(d) / float64(time.Millisecond))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// metricName makes metric name part from sample tag. Dots separate metric path
+// nodes, and spaces, colons and pipes are protocol delimiters, so all characters
+// except letters, digits, '-' and '_' are replaced with '_'.
+func metricName(tag string) string {
+	if tag == "" {
+		return "untagged"
+	}
+	return sanitize(tag)
+}
+
+// metricLabels returns sanitized sample labels sorted by key, so samples with
+// the same labels set in different order are reported together.
+// Labels with empty value are skipped: Graphite doesn't allow empty tag values.
+func metricLabels(labels []netsample.Label) []netsample.Label {
+	if len(labels) == 0 {
+		return nil
+	}
+	res := make([]netsample.Label, 0, len(labels))
+	for _, l := range labels {
+		if l.Key == "" || l.Value == "" {
+			continue
+		}
+		res = append(res, netsample.Label{Key: sanitize(l.Key), Value: sanitize(l.Value)})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+	return res
+}
+
+// statsdTags formats labels as DogStatsD tags: |#key:value,key:value
+func statsdTags(labels []netsample.Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("|#")
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.Key + ":" + l.Value)
+	}
+	return b.String()
+}
+
+// graphiteTags formats labels as Graphite tags: ;key=value;key=value
+func graphiteTags(labels []netsample.Label) string {
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString(";" + l.Key + "=" + l.Value)
+	}
+	return b.String()
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
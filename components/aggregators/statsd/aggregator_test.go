@@ -0,0 +1,250 @@
+package statsd
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+	"go.uber.org/zap"
+)
+
+func testSample(tag string, rtt time.Duration, protoCode int) *netsample.Sample {
+	s := netsample.Acquire(tag)
+	s.SetUserDuration(rtt)
+	s.SetUserProto(protoCode)
+	return s
+}
+
+func testSamples() []*netsample.Sample {
+	failed := testSample("get page", 4*time.Millisecond, 503)
+	failed.SetErrKind(netsample.ErrKindReadTimeout)
+	return []*netsample.Sample{
+		testSample("get page", 2*time.Millisecond, 200),
+		failed,
+		testSample("", 1500*time.Microsecond, 200),
+	}
+}
+
+// labeledSamples returns samples of one tag and two label sets. Labels of first two samples
+// are set in different order, so they should be reported together.
+func labeledSamples() []*netsample.Sample {
+	first := testSample("search", time.Millisecond, 200)
+	first.SetLabel("dc", "vla")
+	first.SetLabel("shard", "1 a")
+	first.SetMetric("items", 3)
+	second := testSample("search", 3*time.Millisecond, 200)
+	second.SetLabel("shard", "1 a")
+	second.SetLabel("dc", "vla")
+	second.SetLabel("empty", "")
+	second.SetMetric("items", 5)
+	other := testSample("search", 2*time.Millisecond, 200)
+	other.SetLabel("dc", "sas")
+	return []*netsample.Sample{first, second, other}
+}
+
+func runAggregator(t *testing.T, conf Config, samples []*netsample.Sample) {
+	a, err := NewAggregator(conf)
+	require.NoError(t, err)
+	for _, s := range samples {
+		a.Report(s)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = a.Run(ctx, core.AggregatorDeps{Log: zap.L()})
+	require.NoError(t, err)
+}
+
+func TestStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conf := DefaultStatsDConfig()
+	conf.Address = conn.LocalAddr().String()
+	conf.Prefix = "load.test."
+	conf.MaxPacketSize = 100
+	runAggregator(t, conf, testSamples())
+
+	lines := receiveStatsD(t, conn, conf.MaxPacketSize, 11)
+	assert.Equal(t, []string{
+		"load.test.untagged.requests:1|c",
+		"load.test.untagged.errors:0|c",
+		"load.test.untagged.proto_code.200:1|c",
+		"load.test.untagged.rtt:1.5|ms",
+		"load.test.get_page.requests:2|c",
+		"load.test.get_page.errors:1|c",
+		"load.test.get_page.proto_code.200:1|c",
+		"load.test.get_page.proto_code.503:1|c",
+		"load.test.get_page.error_kind.read_timeout:1|c",
+		"load.test.get_page.rtt:2|ms",
+		"load.test.get_page.rtt:4|ms",
+	}, lines)
+}
+
+func receiveStatsD(t *testing.T, conn net.PacketConn, maxPacketSize int, count int) []string {
+	var lines []string
+	buf := make([]byte, 2048)
+	for len(lines) < count {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, n, maxPacketSize)
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+	}
+	return lines
+}
+
+func TestStatsD_LabelsAndMetrics(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conf := DefaultStatsDConfig()
+	conf.Address = conn.LocalAddr().String()
+	runAggregator(t, conf, labeledSamples())
+
+	lines := receiveStatsD(t, conn, conf.MaxPacketSize, 11)
+	assert.Equal(t, []string{
+		"pandora.search.requests:1|c|#dc:sas",
+		"pandora.search.errors:0|c|#dc:sas",
+		"pandora.search.proto_code.200:1|c|#dc:sas",
+		"pandora.search.rtt:2|ms|#dc:sas",
+		"pandora.search.requests:2|c|#dc:vla,shard:1_a",
+		"pandora.search.errors:0|c|#dc:vla,shard:1_a",
+		"pandora.search.proto_code.200:2|c|#dc:vla,shard:1_a",
+		"pandora.search.rtt:1|ms|#dc:vla,shard:1_a",
+		"pandora.search.rtt:3|ms|#dc:vla,shard:1_a",
+		"pandora.search.metric.items:3|ms|#dc:vla,shard:1_a",
+		"pandora.search.metric.items:5|ms|#dc:vla,shard:1_a",
+	}, lines)
+}
+
+func TestStatsD_MaxTimers(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conf := DefaultStatsDConfig()
+	conf.Address = conn.LocalAddr().String()
+	conf.MaxTimers = 1
+	runAggregator(t, conf, labeledSamples())
+
+	lines := receiveStatsD(t, conn, conf.MaxPacketSize, 9)
+	assert.Equal(t, []string{
+		"pandora.search.requests:1|c|#dc:sas",
+		"pandora.search.errors:0|c|#dc:sas",
+		"pandora.search.proto_code.200:1|c|#dc:sas",
+		"pandora.search.rtt:2|ms|#dc:sas",
+		"pandora.search.requests:2|c|#dc:vla,shard:1_a",
+		"pandora.search.errors:0|c|#dc:vla,shard:1_a",
+		"pandora.search.proto_code.200:2|c|#dc:vla,shard:1_a",
+		"pandora.search.rtt:1|ms|@0.5|#dc:vla,shard:1_a",
+		"pandora.search.metric.items:3|ms|@0.5|#dc:vla,shard:1_a",
+	}, lines)
+}
+
+func TestSampleTimers(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	sampled, rate := sampleTimers(values, 4)
+	assert.Equal(t, []float64{1, 3, 6, 8}, sampled)
+	assert.Equal(t, 0.4, rate)
+	sampled, rate = sampleTimers(values, 0)
+	assert.Equal(t, values, sampled)
+	assert.Equal(t, 1.0, rate)
+	sampled, rate = sampleTimers(values, 10)
+	assert.Equal(t, values, sampled)
+	assert.Equal(t, 1.0, rate)
+}
+
+// receiveGraphite accepts one connection and returns received metric names and values.
+func receiveGraphite(t *testing.T, conf *Config, run func()) []string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	conf.Address = listener.Addr().String()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var lines []string
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		received <- lines
+	}()
+	run()
+
+	var lines []string
+	select {
+	case lines = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("metrics were not received")
+	}
+	var metrics []string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		require.Len(t, fields, 3, line)
+		metrics = append(metrics, fields[0]+" "+fields[1])
+	}
+	return metrics
+}
+
+func TestGraphite(t *testing.T) {
+	conf := DefaultGraphiteConfig()
+	conf.Percentiles = []float64{50, 99.9}
+	metrics := receiveGraphite(t, &conf, func() { runAggregator(t, conf, testSamples()) })
+	assert.Equal(t, []string{
+		"pandora.untagged.requests 1",
+		"pandora.untagged.errors 0",
+		"pandora.untagged.proto_code.200 1",
+		"pandora.untagged.rtt.min 1.5",
+		"pandora.untagged.rtt.max 1.5",
+		"pandora.untagged.rtt.mean 1.5",
+		"pandora.untagged.rtt.p50 1.5",
+		"pandora.untagged.rtt.p99_9 1.5",
+		"pandora.get_page.requests 2",
+		"pandora.get_page.errors 1",
+		"pandora.get_page.proto_code.200 1",
+		"pandora.get_page.proto_code.503 1",
+		"pandora.get_page.error_kind.read_timeout 1",
+		"pandora.get_page.rtt.min 2",
+		"pandora.get_page.rtt.max 4",
+		"pandora.get_page.rtt.mean 3",
+		"pandora.get_page.rtt.p50 2",
+		"pandora.get_page.rtt.p99_9 4",
+	}, metrics)
+}
+
+func TestGraphite_LabelsAndMetrics(t *testing.T) {
+	conf := DefaultGraphiteConfig()
+	conf.Percentiles = nil
+	metrics := receiveGraphite(t, &conf, func() { runAggregator(t, conf, labeledSamples()) })
+	assert.Equal(t, []string{
+		"pandora.search.requests;dc=sas 1",
+		"pandora.search.errors;dc=sas 0",
+		"pandora.search.proto_code.200;dc=sas 1",
+		"pandora.search.rtt.min;dc=sas 2",
+		"pandora.search.rtt.max;dc=sas 2",
+		"pandora.search.rtt.mean;dc=sas 2",
+		"pandora.search.requests;dc=vla;shard=1_a 2",
+		"pandora.search.errors;dc=vla;shard=1_a 0",
+		"pandora.search.proto_code.200;dc=vla;shard=1_a 2",
+		"pandora.search.metric.items.min;dc=vla;shard=1_a 3",
+		"pandora.search.metric.items.max;dc=vla;shard=1_a 5",
+		"pandora.search.metric.items.mean;dc=vla;shard=1_a 4",
+		"pandora.search.rtt.min;dc=vla;shard=1_a 1",
+		"pandora.search.rtt.max;dc=vla;shard=1_a 3",
+		"pandora.search.rtt.mean;dc=vla;shard=1_a 2",
+	}, metrics)
+}
@@ -11,7 +11,7 @@ import (
 
 func Import(fs afero.Fs) {
 
-	register.Provider("grpc/json", func(conf grpcjson.Config) core.Provider {
+	register.Provider("grpc/json", func(conf grpcjson.Config) (core.Provider, error) {
 		return grpcjson.NewProvider(fs, conf)
 	})
 
@@ -44,6 +44,9 @@ func (g *Gun) Shoot(_ core.Ammo) {
 func (g *Gun) shoot() {
 	code := 0
 	sample := netsample.Acquire("")
+	if g.ScheduledTime != nil {
+		sample.SetScheduledTime(g.ScheduledTime())
+	}
 	defer func() {
 		sample.SetProtoCode(code)
 		g.Aggr.Report(sample)
@@ -197,6 +197,9 @@ func (g *Gun) Shoot(am core.Ammo) {
 func (g *Gun) shoot(ammo *ammo.Ammo) {
 	code := 0
 	sample := netsample.Acquire(ammo.Tag)
+	if g.ScheduledTime != nil {
+		sample.SetScheduledTime(g.ScheduledTime())
+	}
 	defer func() {
 		sample.SetProtoCode(code)
 		g.Aggr.Report(sample)
@@ -233,6 +236,7 @@ func (g *Gun) shoot(ammo *ammo.Ammo) {
 	ctx = metadata.NewOutgoingContext(ctx, metadata.New(ammo.Metadata))
 	out, grpcErr := g.Stub.InvokeRpc(ctx, &method, message)
 	code = ConvertGrpcStatus(grpcErr)
+	sample.SetErrKind(ConvertGrpcErrorKind(grpcErr))
 
 	if grpcErr != nil {
 		g.GunDeps.Log.Error("response error", zap.Error(err))
@@ -337,6 +341,23 @@ func ConvertGrpcStatus(err error) int {
 	}
 }
 
+// ConvertGrpcErrorKind returns netsample error kind of transport level gRPC errors.
+// Application level statuses, like NotFound, have no kind, same as HTTP error codes.
+func ConvertGrpcErrorKind(err error) string {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded:
+		return netsample.ErrKindReadTimeout
+	case codes.Canceled:
+		return netsample.ErrKindCanceled
+	case codes.Unavailable:
+		return netsample.ErrKindConnectError
+	case codes.Internal:
+		return netsample.ErrKindProtoError
+	default:
+		return ""
+	}
+}
+
 func replacePort(host string, port int64) string {
 	if port == 0 {
 		return host
@@ -123,9 +123,13 @@ func (g *Gun) shoot(ammo *Scenario, templateVars map[string]any) error {
 	}
 
 	startAt := time.Now()
-	for _, call := range ammo.Calls {
+	for i, call := range ammo.Calls {
 		tag := ammo.Name + "." + call.Tag
 		sample := netsample.Acquire(tag)
+		if i == 0 && g.gun.ScheduledTime != nil {
+			// Only first call start may be delayed by schedule.
+			sample.SetScheduledTime(g.gun.ScheduledTime())
+		}
 
 		err := g.shootStep(&call, sample, ammo.Name, templateVars, requestVars)
 		if err != nil {
@@ -139,10 +143,13 @@ func (g *Gun) shoot(ammo *Scenario, templateVars map[string]any) error {
 	return nil
 }
 
-func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, templateVars map[string]any, requestVars map[string]any) error {
+func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, templateVars map[string]any, requestVars map[string]any) (err error) {
 	const op = "base_gun.shootStep"
 	code := 0
 	defer func() {
+		if err != nil && sample.ErrKind() == "" {
+			sample.SetErrKind(netsample.ClassifyError(err))
+		}
 		sample.SetProtoCode(code)
 		g.gun.Aggr.Report(sample)
 	}()
@@ -155,7 +162,7 @@ func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, t
 	for _, preProcessor := range step.Preprocessors {
 		pp, err := preProcessor.Process(step, templateVars)
 		if err != nil {
-			return fmt.Errorf("%s preProcessor %w", op, err)
+			return fmt.Errorf("%s preProcessor %w", op, netsample.WithErrorKind(err, netsample.ErrKindPreprocessor))
 		}
 		preprocVars = mergeMaps(preprocVars, pp)
 		if g.gun.DebugLog {
@@ -167,7 +174,7 @@ func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, t
 	// Template
 	payloadJSON, err := g.templ.Apply(step.Payload, step.Metadata, templateVars, ammoName, step.Name)
 	if err != nil {
-		return fmt.Errorf("%s templater.Apply %w", op, err)
+		return fmt.Errorf("%s templater.Apply %w", op, netsample.WithErrorKind(err, netsample.ErrKindTemplateError))
 	}
 
 	// Method
@@ -184,7 +191,7 @@ func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, t
 	if err != nil {
 		code = 400
 		g.gun.GunDeps.Log.Error("invalid payload. Cant unmarshal gRPC", zap.Error(err))
-		return fmt.Errorf("%s invalid payload. Cant unmarshal gRPC", op)
+		return netsample.WithErrorKind(fmt.Errorf("%s invalid payload. Cant unmarshal gRPC", op), netsample.ErrKindTemplateError)
 	}
 
 	timeout := defaultTimeout
@@ -198,6 +205,7 @@ func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, t
 	out, grpcErr := g.gun.Stub.InvokeRpc(ctx, &method, message)
 	code = grpcgun.ConvertGrpcStatus(grpcErr)
 	sample.SetProtoCode(code) // for setRTT inside
+	sample.SetErrKind(grpcgun.ConvertGrpcErrorKind(grpcErr))
 
 	if grpcErr != nil {
 		g.gun.GunDeps.Log.Error("response error", zap.Error(err))
@@ -208,7 +216,7 @@ func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, t
 	for _, postProcessor := range step.Postprocessors {
 		pp, err := postProcessor.Process(out, code)
 		if err != nil {
-			return fmt.Errorf("%s postProcessor %w", op, err)
+			return fmt.Errorf("%s postProcessor %w", op, netsample.WithErrorKind(err, netsample.ErrKindPostprocessor))
 		}
 		stepVars = mergeMaps(stepVars, pp)
 		if g.gun.DebugLog {
@@ -40,7 +40,8 @@ type AnswLogConfig struct {
 }
 
 type HTTPTraceConfig struct {
-	DumpEnabled  bool `config:"dump"`
+	DumpEnabled bool `config:"dump"`
+	// Deprecated: timings are always recorded. Option is kept for config compatibility.
 	TraceEnabled bool `config:"trace"`
 }
 
@@ -147,6 +148,9 @@ func (b *BaseGun) Shoot(ammo Ammo) {
 	}
 
 	req, sample := ammo.Request()
+	if b.ScheduledTime != nil {
+		sample.SetScheduledTime(b.ScheduledTime())
+	}
 	if ammo.IsInvalid() {
 		sample.AddTag(EmptyTag)
 		sample.SetProtoCode(0)
@@ -187,12 +191,8 @@ func (b *BaseGun) Shoot(ammo Ammo) {
 		err = errors.WithStack(err)
 	}()
 
-	var timings *TraceTimings
-	if b.Config.HTTPTrace.TraceEnabled {
-		var clientTracer *httptrace.ClientTrace
-		clientTracer, timings = CreateHTTPTrace()
-		req = req.WithContext(httptrace.WithClientTrace(req.Context(), clientTracer))
-	}
+	clientTracer, timings := CreateHTTPTrace()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), clientTracer))
 	if b.Config.HTTPTrace.DumpEnabled {
 		requestDump, err := httputil.DumpRequest(req, true)
 		if err != nil {
@@ -203,9 +203,7 @@ func (b *BaseGun) Shoot(ammo Ammo) {
 	}
 	var res *http.Response
 	res, err = b.Client.Do(req)
-	if b.Config.HTTPTrace.TraceEnabled && timings != nil {
-		sample.SetReceiveTime(timings.GetReceiveTime())
-	}
+	timings.SetTimings(sample)
 	if b.Config.HTTPTrace.DumpEnabled && res != nil {
 		responseDump, err := httputil.DumpResponse(res, true)
 		if err != nil {
@@ -214,11 +212,6 @@ func (b *BaseGun) Shoot(ammo Ammo) {
 			sample.SetResponseBytes(len(responseDump))
 		}
 	}
-	if b.Config.HTTPTrace.TraceEnabled && timings != nil {
-		sample.SetConnectTime(timings.GetConnectTime())
-		sample.SetSendTime(timings.GetSendTime())
-		sample.SetLatency(timings.GetLatency())
-	}
 
 	if err != nil {
 		b.Log.Warn("Request fail", zap.Error(err))
@@ -247,8 +240,8 @@ func (b *BaseGun) Shoot(ammo Ammo) {
 
 	sample.SetProtoCode(res.StatusCode)
 	defer res.Body.Close()
-	// TODO: measure body read time
 	_, err = io.Copy(ioutil.Discard, res.Body) // Buffers are pooled for ioutil.Discard
+	sample.SetReceiveTime(timings.GetReceiveTime())
 	if err != nil {
 		b.Log.Warn("Body read fail", zap.Error(err))
 		return
@@ -173,7 +173,7 @@ func (s *BaseGunSuite) Test_Shoot() {
 			s.base.AnswLog = zap.NewNop()
 			s.base.Client = &testDecoratedClient{
 				before: func(doReq *http.Request) {
-					s.Require().Equal(req, doReq)
+					s.Require().Equal(req.WithContext(doReq.Context()), doReq)
 				},
 				returnRes: &http.Response{
 					StatusCode: http.StatusNotFound,
@@ -1,35 +1,80 @@
 package phttp
 
 import (
+	"crypto/tls"
 	"net/http/httptrace"
 	"time"
+
+	"github.com/yandex/pandora/core/aggregator/netsample"
 )
 
 type TraceTimings struct {
-	GotConnTime          time.Time
-	GetConnTime          time.Time
-	DNSStartTime         time.Time
-	DNSDoneTime          time.Time
-	ConnectDoneTime      time.Time
-	ConnectStartTime     time.Time
-	WroteRequestTime     time.Time
-	GotFirstResponseByte time.Time
+	GotConnTime           time.Time
+	GetConnTime           time.Time
+	DNSStartTime          time.Time
+	DNSDoneTime           time.Time
+	ConnectDoneTime       time.Time
+	ConnectStartTime      time.Time
+	TLSHandshakeStartTime time.Time
+	TLSHandshakeDoneTime  time.Time
+	WroteRequestTime      time.Time
+	GotFirstResponseByte  time.Time
 }
 
+// GetReceiveTime returns time since first response byte. Should be called right after body is read.
 func (t *TraceTimings) GetReceiveTime() time.Duration {
-	return time.Since(t.GotFirstResponseByte)
+	return between(t.GotFirstResponseByte, time.Now())
 }
 
+// GetConnectTime returns time of getting connection, including DNS, TCP connect and TLS handshake.
+// Zero for reused connection.
 func (t *TraceTimings) GetConnectTime() time.Duration {
-	return t.GotConnTime.Sub(t.GetConnTime)
+	return between(t.GetConnTime, t.GotConnTime)
 }
 
 func (t *TraceTimings) GetSendTime() time.Duration {
-	return t.WroteRequestTime.Sub(t.GotConnTime)
+	return between(t.GotConnTime, t.WroteRequestTime)
 }
 
 func (t *TraceTimings) GetLatency() time.Duration {
-	return t.GotFirstResponseByte.Sub(t.WroteRequestTime)
+	return between(t.WroteRequestTime, t.GotFirstResponseByte)
+}
+
+func (t *TraceTimings) GetDNSTime() time.Duration {
+	return between(t.DNSStartTime, t.DNSDoneTime)
+}
+
+func (t *TraceTimings) GetTCPConnectTime() time.Duration {
+	return between(t.ConnectStartTime, t.ConnectDoneTime)
+}
+
+func (t *TraceTimings) GetTLSHandshakeTime() time.Duration {
+	return between(t.TLSHandshakeStartTime, t.TLSHandshakeDoneTime)
+}
+
+// GetTTFB returns time since start of getting connection till first response byte.
+func (t *TraceTimings) GetTTFB() time.Duration {
+	return between(t.GetConnTime, t.GotFirstResponseByte)
+}
+
+// SetTimings sets all timings known after response headers are received.
+// Receive time should be set separately, when response body is read.
+func (t *TraceTimings) SetTimings(sample *netsample.Sample) {
+	sample.SetConnectTime(t.GetConnectTime())
+	sample.SetSendTime(t.GetSendTime())
+	sample.SetLatency(t.GetLatency())
+	sample.SetDNSTime(t.GetDNSTime())
+	sample.SetTCPConnectTime(t.GetTCPConnectTime())
+	sample.SetTLSHandshakeTime(t.GetTLSHandshakeTime())
+	sample.SetTTFB(t.GetTTFB())
+}
+
+// between returns zero, if any of events didn't happen.
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
 }
 
 func CreateHTTPTrace() (*httptrace.ClientTrace, *TraceTimings) {
@@ -53,6 +98,12 @@ func CreateHTTPTrace() (*httptrace.ClientTrace, *TraceTimings) {
 		ConnectDone: func(network, addr string, err error) {
 			timings.ConnectDoneTime = time.Now()
 		},
+		TLSHandshakeStart: func() {
+			timings.TLSHandshakeStartTime = time.Now()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, _ error) {
+			timings.TLSHandshakeDoneTime = time.Now()
+		},
 		WroteRequest: func(wr httptrace.WroteRequestInfo) {
 			timings.WroteRequestTime = time.Now()
 		},
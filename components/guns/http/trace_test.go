@@ -0,0 +1,52 @@
+package phttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+)
+
+func TestTraceTimings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+	client := server.Client()
+
+	tracer, timings := CreateHTTPTrace()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer))
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	_, err = io.Copy(io.Discard, res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	sample := netsample.Acquire("")
+	timings.SetTimings(sample)
+	sample.SetReceiveTime(timings.GetReceiveTime())
+	assert.NotZero(t, sample.ConnectTime())
+	assert.NotZero(t, sample.TCPConnectTime())
+	assert.NotZero(t, sample.TLSHandshakeTime())
+	assert.Zero(t, sample.DNSTime(), "no DNS for IP address")
+	assert.GreaterOrEqual(t, sample.Latency(), 10*time.Millisecond)
+	assert.GreaterOrEqual(t, sample.TTFB(), sample.Latency()+sample.ConnectTime())
+	assert.LessOrEqual(t, sample.TCPConnectTime()+sample.TLSHandshakeTime(), sample.ConnectTime())
+}
+
+func TestTraceTimingsNoEvents(t *testing.T) {
+	timings := &TraceTimings{GetConnTime: time.Now()}
+	assert.Zero(t, timings.GetConnectTime())
+	assert.Zero(t, timings.GetLatency())
+	assert.Zero(t, timings.GetTTFB())
+	assert.Zero(t, timings.GetReceiveTime())
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/yandex/pandora/components/providers/scenario"
+	"github.com/yandex/pandora/core/aggregator/netsample"
 )
 
 type SourceStorage interface {
@@ -72,6 +73,12 @@ type Postprocessor interface {
 	Process(resp *http.Response, body io.Reader) (map[string]any, error)
 }
 
+// SamplePostprocessor is optional Postprocessor extension, that attaches custom labels and metrics
+// to request sample. ProcessSample is called after Process.
+type SamplePostprocessor interface {
+	ProcessSample(sample *netsample.Sample, resp *http.Response) error
+}
+
 type RequestParts struct {
 	URL     string
 	Method  string
@@ -91,10 +91,14 @@ func (g *ScenarioGun) shoot(ammo *Scenario, templateVars map[string]any) error {
 	startAt := time.Now()
 	var idBuilder strings.Builder
 	rnd := strconv.Itoa(rand.Int())
-	for _, req := range ammo.Requests {
+	for i, req := range ammo.Requests {
 		tag := ammo.Name + "." + req.Name
 		g.buildLogID(&idBuilder, tag, ammo.ID, rnd)
 		sample := netsample.Acquire(tag)
+		if i == 0 && g.base.ScheduledTime != nil {
+			// Only first step start may be delayed by schedule.
+			sample.SetScheduledTime(g.base.ScheduledTime())
+		}
 
 		err := g.shootStep(req, sample, ammo.Name, templateVars, requestVars, idBuilder.String())
 		if err != nil {
@@ -119,7 +123,7 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 	if step.Preprocessor != nil {
 		preProcVars, err := step.Preprocessor.Process(templateVars)
 		if err != nil {
-			return fmt.Errorf("%s preProcessor %w", op, err)
+			return fmt.Errorf("%s preProcessor %w", op, netsample.WithErrorKind(err, netsample.ErrKindPreprocessor))
 		}
 		stepVars["preprocessor"] = preProcVars
 		if g.base.DebugLog {
@@ -137,13 +141,13 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 
 	// Template
 	if err := step.Templater.Apply(&reqParts, templateVars, ammoName, step.Name); err != nil {
-		return fmt.Errorf("%s templater.Apply %w", op, err)
+		return fmt.Errorf("%s templater.Apply %w", op, netsample.WithErrorKind(err, netsample.ErrKindTemplateError))
 	}
 
 	// Prepare request
 	req, err := g.prepareRequest(reqParts)
 	if err != nil {
-		return fmt.Errorf("%s prepareRequest %w", op, err)
+		return fmt.Errorf("%s prepareRequest %w", op, netsample.WithErrorKind(err, netsample.ErrKindTemplateError))
 	}
 
 	var reqBytes []byte
@@ -177,6 +181,7 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 	} else {
 		_, err = io.Copy(io.Discard, resp.Body)
 	}
+	sample.SetReceiveTime(timings.GetReceiveTime())
 	if err != nil {
 		return fmt.Errorf("%s io.Copy %w", op, err)
 	}
@@ -200,11 +205,17 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 	for _, postprocessor := range processors {
 		vars, err = postprocessor.Process(resp, respBody)
 		if err != nil {
-			return fmt.Errorf("%s postprocessor.Postprocess %w", op, err)
+			return fmt.Errorf("%s postprocessor.Postprocess %w", op, netsample.WithErrorKind(err, netsample.ErrKindPostprocessor))
 		}
 		for k, v := range vars {
 			postprocessorVars[k] = v
 		}
+		if sp, ok := postprocessor.(SamplePostprocessor); ok {
+			err = sp.ProcessSample(sample, resp)
+			if err != nil {
+				return fmt.Errorf("%s postprocessor.ProcessSample %w", op, netsample.WithErrorKind(err, netsample.ErrKindPostprocessor))
+			}
+		}
 		_, err = respBody.Seek(0, io.SeekStart)
 		if err != nil {
 			return fmt.Errorf("%s postprocessor.Postprocess %w", op, err)
@@ -264,12 +275,8 @@ func (g *ScenarioGun) prepareRequest(reqParts RequestParts) (*http.Request, erro
 }
 
 func (g *ScenarioGun) initTracing(req *http.Request, sample *netsample.Sample) (*phttp.TraceTimings, *http.Request) {
-	var timings *phttp.TraceTimings
-	if g.base.Config.HTTPTrace.TraceEnabled {
-		var clientTracer *httptrace.ClientTrace
-		clientTracer, timings = phttp.CreateHTTPTrace()
-		req = req.WithContext(httptrace.WithClientTrace(req.Context(), clientTracer))
-	}
+	clientTracer, timings := phttp.CreateHTTPTrace()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), clientTracer))
 	if g.base.Config.HTTPTrace.DumpEnabled {
 		requestDump, err := httputil.DumpRequest(req, true)
 		if err != nil {
@@ -282,9 +289,7 @@ func (g *ScenarioGun) initTracing(req *http.Request, sample *netsample.Sample) (
 }
 
 func (g *ScenarioGun) saveTrace(timings *phttp.TraceTimings, sample *netsample.Sample, resp *http.Response) {
-	if g.base.Config.HTTPTrace.TraceEnabled && timings != nil {
-		sample.SetReceiveTime(timings.GetReceiveTime())
-	}
+	timings.SetTimings(sample)
 	if g.base.Config.HTTPTrace.DumpEnabled && resp != nil {
 		responseDump, e := httputil.DumpResponse(resp, true)
 		if e != nil {
@@ -293,11 +298,6 @@ func (g *ScenarioGun) saveTrace(timings *phttp.TraceTimings, sample *netsample.S
 			sample.SetResponseBytes(len(responseDump))
 		}
 	}
-	if g.base.Config.HTTPTrace.TraceEnabled && timings != nil {
-		sample.SetConnectTime(timings.GetConnectTime())
-		sample.SetSendTime(timings.GetSendTime())
-		sample.SetLatency(timings.GetLatency())
-	}
 }
 
 func (g *ScenarioGun) verboseLogging(resp *http.Response, reqBody, respBody []byte) {
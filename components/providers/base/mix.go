@@ -0,0 +1,57 @@
+package base
+
+import (
+	"context"
+	"math/rand"
+)
+
+// MixSource is ammo source of weighted mixture.
+type MixSource[T any] struct {
+	// Weight is relative share of source ammo. Source with zero weight is not used.
+	Weight int
+	Ammo   <-chan T
+	// Done is called, when Ammo is closed. Mix returns its error, if it is not nil.
+	Done func() error
+}
+
+// Mix receives ammo from sources, that are chosen at random in proportion to weights, and passes it to send.
+// Closed source is dropped, so the rest are mixed in proportion to their weights.
+// Mix returns, when all sources are closed, send returns false or ctx is done.
+func Mix[T any](ctx context.Context, rnd *rand.Rand, sources []MixSource[T], send func(source int, ammo T) bool) error {
+	active := make([]int, 0, len(sources))
+	total := 0
+	for i, s := range sources {
+		if s.Weight > 0 {
+			active = append(active, i)
+			total += s.Weight
+		}
+	}
+	for len(active) > 0 {
+		n := rnd.Intn(total)
+		pick := 0
+		for n >= sources[active[pick]].Weight {
+			n -= sources[active[pick]].Weight
+			pick++
+		}
+		source := active[pick]
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ammo, ok := <-sources[source].Ammo:
+			if ok {
+				if !send(source, ammo) {
+					return ctx.Err()
+				}
+				continue
+			}
+		}
+		active = append(active[:pick], active[pick+1:]...)
+		total -= sources[source].Weight
+		if done := sources[source].Done; done != nil {
+			if err := done(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mixTestSource(weight int, n int, value string) MixSource[string] {
+	ammo := make(chan string, n)
+	for i := 0; i < n; i++ {
+		ammo <- value
+	}
+	close(ammo)
+	return MixSource[string]{Weight: weight, Ammo: ammo}
+}
+
+func TestMix(t *testing.T) {
+	sources := []MixSource[string]{
+		mixTestSource(3, 1000, "a"),
+		mixTestSource(1, 1000, "b"),
+		mixTestSource(0, 10, "disabled"),
+	}
+	var first []string
+	counts := map[string]int{}
+	err := Mix(context.Background(), rand.New(rand.NewSource(1)), sources, func(source int, ammo string) bool {
+		if len(first) < 1000 {
+			first = append(first, ammo)
+		}
+		counts[ammo]++
+		return true
+	})
+	assert.NoError(t, err)
+	// The rest of ammo is sent after the first source is closed.
+	assert.Equal(t, map[string]int{"a": 1000, "b": 1000}, counts)
+	var a int
+	for _, ammo := range first {
+		if ammo == "a" {
+			a++
+		}
+	}
+	assert.InDelta(t, 750, a, 50)
+}
+
+func TestMix_Stop(t *testing.T) {
+	failed := mixTestSource(1, 0, "")
+	failed.Done = func() error { return errors.New("source failed") }
+	err := Mix(context.Background(), rand.New(rand.NewSource(1)), []MixSource[string]{failed}, func(int, string) bool { return true })
+	assert.EqualError(t, err, "source failed")
+
+	var sent int
+	err = Mix(context.Background(), rand.New(rand.NewSource(1)), []MixSource[string]{mixTestSource(1, 10, "a")}, func(int, string) bool {
+		sent++
+		return sent < 5
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, sent)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Mix(ctx, rand.New(rand.NewSource(1)), []MixSource[string]{{Weight: 1, Ammo: make(chan string)}}, func(int, string) bool { return true })
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,97 @@
+package grpcjson
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/components/providers/base"
+	ammo "github.com/yandex/pandora/components/providers/grpc"
+	"github.com/yandex/pandora/core"
+)
+
+func (p *Provider) Run(ctx context.Context, deps core.ProviderDeps) error {
+	if len(p.Files) == 0 {
+		return p.Provider.Run(ctx, deps)
+	}
+	defer p.Close()
+	p.ProviderDeps = deps
+	defer close(p.Sink)
+	return p.runMixed(ctx)
+}
+
+// runMixed reads every file in its own goroutine and sends ammo, choosing file by weight for every ammo.
+// File is done after its passes, so the rest are mixed in proportion to their weights.
+// Names of files are set and checked for uniqueness by NewProvider.
+func (p *Provider) runMixed(ctx context.Context) error {
+	mix := make([]base.MixSource[*ammo.Ammo], len(p.Files))
+	errs := make([]chan error, len(p.Files))
+	for i, f := range p.Files {
+		weight := f.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		mix[i].Weight = weight
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	for i, f := range p.Files {
+		sink := make(chan *ammo.Ammo, cap(p.Sink))
+		errs[i] = make(chan error, 1)
+		go func(file string, sink chan<- *ammo.Ammo, res chan<- error) {
+			defer close(sink)
+			ammoFile, err := p.fs.Open(file)
+			if err != nil {
+				res <- errors.Wrap(err, "failed to open ammo file")
+				return
+			}
+			defer ammoFile.Close()
+			res <- p.read(ctx, ammoFile, sink, 0)
+		}(f.File, sink, errs[i])
+		mix[i].Ammo = sink
+		mix[i].Done = func() error {
+			err := <-errs[i]
+			errs[i] = nil
+			return errors.Wrapf(err, "file %s", p.Files[i].File)
+		}
+	}
+	defer func() {
+		// Files are closed before return.
+		cancel()
+		for i := range mix {
+			if errs[i] != nil {
+				for range mix[i].Ammo {
+				}
+				<-errs[i]
+			}
+		}
+	}()
+
+	seed := p.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	var ammoNum int
+	err := base.Mix(ctx, rand.New(rand.NewSource(seed)), mix, func(source int, a *ammo.Ammo) bool {
+		if p.Limit != 0 && ammoNum >= p.Limit {
+			return false
+		}
+		ammoNum++
+		if a.Tag == "" {
+			a.Tag = p.Files[source].Name
+		} else {
+			a.Tag = p.Files[source].Name + "." + a.Tag
+		}
+		select {
+		case p.Sink <- a:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
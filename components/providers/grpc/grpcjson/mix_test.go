@@ -0,0 +1,82 @@
+package grpcjson
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ammo "github.com/yandex/pandora/components/providers/grpc"
+	"github.com/yandex/pandora/core"
+	"go.uber.org/zap"
+)
+
+func runProvider(t *testing.T, fs afero.Fs, conf Config) (map[string]int, error) {
+	p, err := NewProvider(fs, conf)
+	require.NoError(t, err)
+	tags := map[string]int{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			a, ok := p.Acquire()
+			if !ok {
+				return
+			}
+			tags[a.(*ammo.Ammo).Tag]++
+		}
+	}()
+	err = p.Run(context.Background(), core.ProviderDeps{Log: zap.NewNop()})
+	<-done
+	return tags, err
+}
+
+func TestProvider_Files(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "main.jsonline", []byte(`{"tag": "item", "call": "Svc.Get"}
+{"call": "Svc.List"}
+`), 0644))
+	require.NoError(t, afero.WriteFile(fs, "rare.jsonline", []byte(`{"call": "Svc.Put"}`+"\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "broken.jsonline", []byte(`{"call": "Svc.Get"}
+{"call":
+`), 0644))
+
+	conf := Config{
+		Passes: 3,
+		Files: []SourceFile{
+			{File: "main.jsonline", Weight: 3},
+			{File: "rare.jsonline", Name: "other"},
+		},
+	}
+	tags, err := runProvider(t, fs, conf)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"main.item": 3, "main": 3, "other": 3}, tags, "every file has own passes")
+
+	conf.Limit = 4
+	tags, err = runProvider(t, fs, conf)
+	require.NoError(t, err)
+	total := 0
+	for _, n := range tags {
+		total += n
+	}
+	assert.Equal(t, 4, total)
+
+	conf.Limit = 0
+	conf.Files = append(conf.Files, SourceFile{File: "broken.jsonline"})
+	_, err = runProvider(t, fs, conf)
+	assert.ErrorContains(t, err, "file broken.jsonline: failed to decode ammo at line: 2")
+
+	conf.Files[2] = SourceFile{File: "missing.jsonline"}
+	_, err = runProvider(t, fs, conf)
+	assert.ErrorContains(t, err, "file missing.jsonline: failed to open ammo file")
+
+	conf.Files[2] = SourceFile{File: "other/main.jsonline"}
+	_, err = NewProvider(fs, conf)
+	assert.ErrorContains(t, err, `source name "main" of file other/main.jsonline is not unique`)
+
+	conf.Files = conf.Files[:2]
+	conf.File = "main.jsonline"
+	_, err = NewProvider(fs, conf)
+	assert.ErrorContains(t, err, "either 'files' or 'file'")
+}
@@ -3,6 +3,9 @@ package grpcjson
 import (
 	"bufio"
 	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
@@ -12,22 +15,40 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewProvider(fs afero.Fs, conf Config) *Provider {
+func NewProvider(fs afero.Fs, conf Config) (*Provider, error) {
 	var p Provider
 	if conf.Source.Path != "" {
 		conf.File = conf.Source.Path
 	}
+	if len(conf.Files) > 0 {
+		if conf.File != "" {
+			return nil, errors.New("one should specify either 'files' or 'file', but not both of them")
+		}
+		conf.Files = append([]SourceFile(nil), conf.Files...)
+		names := map[string]bool{}
+		for i, f := range conf.Files {
+			if f.Name == "" {
+				conf.Files[i].Name = strings.TrimSuffix(filepath.Base(f.File), filepath.Ext(f.File))
+			}
+			if names[conf.Files[i].Name] {
+				return nil, fmt.Errorf("source name %q of file %s is not unique, set name", conf.Files[i].Name, f.File)
+			}
+			names[conf.Files[i].Name] = true
+		}
+	}
 	p = Provider{
 		Provider: ammo.NewProvider(fs, conf.File, p.start),
 		Config:   conf,
+		fs:       fs,
 	}
-	return &p
+	return &p, nil
 }
 
 type Provider struct {
 	ammo.Provider
 	Config
 	log *zap.Logger
+	fs  afero.Fs
 }
 
 type Source struct {
@@ -46,9 +67,25 @@ type Config struct {
 	MaxAmmoSize int
 	Source      Source `config:"source"`
 	ChosenCases []string
+	// Files are ammo files, that are mixed by weight. They are set instead of File.
+	Files []SourceFile `config:"files" validate:"dive"`
+	// Seed of files mixture. Random, if zero.
+	Seed int64
+}
+
+// SourceFile is one of mixed Files. File is read with own passes, and its ammo tags get "<Name>." prefix.
+type SourceFile struct {
+	File   string `config:"file" validate:"required"`
+	Weight int    `config:"weight" validate:"min=0"`
+	Name   string `config:"name"`
 }
 
 func (p *Provider) start(ctx context.Context, ammoFile afero.File) error {
+	return p.read(ctx, ammoFile, p.Sink, p.Limit)
+}
+
+// read sends ammo of file to sink for passes of config, till limit, if it is not zero.
+func (p *Provider) read(ctx context.Context, ammoFile afero.File, sink chan<- *ammo.Ammo, limit int) error {
 	var ammoNum, passNum int
 	for {
 		passNum++
@@ -57,7 +94,7 @@ func (p *Provider) start(ctx context.Context, ammoFile afero.File) error {
 			var buffer []byte
 			scanner.Buffer(buffer, p.Config.MaxAmmoSize)
 		}
-		for line := 1; scanner.Scan() && (p.Limit == 0 || ammoNum < p.Limit); line++ {
+		for line := 1; scanner.Scan() && (limit == 0 || ammoNum < limit); line++ {
 			data := scanner.Bytes()
 			a, err := decodeAmmo(data, p.Pool.Get().(*ammo.Ammo))
 			if err != nil {
@@ -72,7 +109,7 @@ func (p *Provider) start(ctx context.Context, ammoFile afero.File) error {
 			}
 			ammoNum++
 			select {
-			case p.Sink <- a:
+			case sink <- a:
 			case <-ctx.Done():
 				return nil
 			}
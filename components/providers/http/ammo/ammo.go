@@ -2,6 +2,7 @@ package ammo
 
 import (
 	"net/http"
+	"time"
 
 	phttp "github.com/yandex/pandora/components/guns/http"
 	"github.com/yandex/pandora/core/aggregator/netsample"
@@ -17,6 +18,7 @@ type GunAmmo struct {
 	req       *http.Request
 	id        uint64
 	tag       string
+	timestamp time.Time
 	isInvalid bool
 }
 
@@ -30,6 +32,12 @@ func (g GunAmmo) ID() uint64 {
 	return g.id
 }
 
+// Timestamp returns time of original request, if decoder knows it. For example, time of access log record.
+// Zero time is returned otherwise.
+func (g GunAmmo) Timestamp() time.Time {
+	return g.timestamp
+}
+
 func (g GunAmmo) IsInvalid() bool {
 	return g.isInvalid
 }
@@ -41,3 +49,9 @@ func NewGunAmmo(req *http.Request, tag string, id uint64) GunAmmo {
 		tag: tag,
 	}
 }
+
+func NewTimestampedGunAmmo(req *http.Request, tag string, id uint64, timestamp time.Time) GunAmmo {
+	a := NewGunAmmo(req, tag, id)
+	a.timestamp = timestamp
+	return a
+}
@@ -7,6 +7,8 @@ import (
 type Config struct {
 	Decoder DecoderType
 	File    string
+	// Files are ammo files, that are mixed by weight. They are set instead of File.
+	Files []SourceFile `validate:"dive"`
 	// Limit limits total num of ammo. Unlimited if zero.
 	Limit uint
 	// Default HTTP headers
@@ -21,4 +23,86 @@ type Config struct {
 	ChosenCases []string
 	Middlewares []middleware.Middleware
 	Preload     bool
+	// Order is order of ammo: sequential (default), shuffle or random.
+	// Shuffle and random orders need index of file, if ammo is not preloaded.
+	Order string `validate:"omitempty,oneof=sequential shuffle random"`
+	// Seed of shuffle and random orders. Random, if zero.
+	Seed int64
+	// HAR configures `har` decoder.
+	HAR HARConfig
+	// AccessLog configures `accesslog` decoder.
+	AccessLog AccessLogConfig
+	// PCAP configures `pcap` decoder.
+	PCAP PCAPConfig
+}
+
+// SourceFile is ammo file of weighted mixture. Its ammo has own passes and tags, that are prefixed by Name: name.tag.
+type SourceFile struct {
+	File string `config:"file" validate:"required"`
+	// Weight is relative share of file ammo. Default is 1.
+	Weight int `config:"weight" validate:"min=0"`
+	// Name is prefix of file ammo tags. Default is file name without extension.
+	Name string `config:"name"`
+}
+
+const (
+	// OrderSequential sends ammo in file order.
+	OrderSequential = "sequential"
+	// OrderShuffle sends every ammo once per pass; order is shuffled every pass.
+	OrderShuffle = "shuffle"
+	// OrderRandom samples ammo with replacement. Pass is counted every number of ammo in file.
+	OrderRandom = "random"
+)
+
+type HARConfig struct {
+	// Hosts rewrite hosts of HAR requests. First matching rewrite is applied.
+	Hosts []HARHostRewrite `config:"hosts"`
+	// IncludeHeaders, if not empty, is list of HAR headers to keep. Other headers are dropped.
+	IncludeHeaders []string `config:"include-headers"`
+	// ExcludeHeaders is list of HAR headers to drop. For example, Cookie.
+	ExcludeHeaders []string `config:"exclude-headers"`
+	// Tags set ammo tag by first matching URL pattern.
+	Tags []HARTag `config:"tags"`
+}
+
+type HARHostRewrite struct {
+	// From is host (with port, if it is in URL) to rewrite. Empty From matches any host.
+	From string `config:"from"`
+	To   string `config:"to" validate:"required"`
+}
+
+type HARTag struct {
+	// Pattern is regexp, that is matched against full request URL.
+	Pattern string `config:"pattern" validate:"required"`
+	// Tag can refer to pattern submatches, like $1 or ${name}.
+	Tag string `config:"tag" validate:"required"`
+}
+
+const (
+	AccessLogFormatCombined = "combined"
+	AccessLogFormatCommon   = "common"
+)
+
+type AccessLogConfig struct {
+	// Format is `combined` (default), `common` or nginx log_format pattern,
+	// like `$remote_addr [$time_local] "$request" $status`.
+	Format string `config:"format"`
+	// Methods are request methods to keep. Default is GET and HEAD: logs have no request bodies.
+	Methods []string `config:"methods"`
+	// Statuses are response statuses to keep: codes, like 200, or classes, like 2xx. All, if empty.
+	Statuses []string `config:"statuses"`
+	// Tags set ammo tag by longest matching path prefix.
+	Tags []AccessLogTag `config:"tags"`
+	// Timestamps makes ammo Timestamp return time of log record.
+	Timestamps bool `config:"timestamps"`
+}
+
+type AccessLogTag struct {
+	Prefix string `config:"prefix" validate:"required"`
+	Tag    string `config:"tag" validate:"required"`
+}
+
+type PCAPConfig struct {
+	// Ports are server TCP ports of HTTP traffic. Requests to any port are taken, if empty.
+	Ports []int `config:"ports"`
 }
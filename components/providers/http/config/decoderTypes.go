@@ -3,15 +3,19 @@ package config
 type DecoderType string
 
 const (
-	DecoderURI      DecoderType = "uri"
-	DecoderURIPost  DecoderType = "uripost"
-	DecoderRaw      DecoderType = "raw"
-	DecoderJSONLine DecoderType = "jsonline"
+	DecoderURI       DecoderType = "uri"
+	DecoderURIPost   DecoderType = "uripost"
+	DecoderRaw       DecoderType = "raw"
+	DecoderJSONLine  DecoderType = "jsonline"
+	DecoderHAR       DecoderType = "har"
+	DecoderAccessLog DecoderType = "accesslog"
+	DecoderCurl      DecoderType = "curl"
+	DecoderPCAP      DecoderType = "pcap"
 )
 
 func (d DecoderType) IsValid() bool {
 	switch d {
-	case DecoderURI, DecoderURIPost, DecoderRaw, DecoderJSONLine:
+	case DecoderURI, DecoderURIPost, DecoderRaw, DecoderJSONLine, DecoderHAR, DecoderAccessLog, DecoderCurl, DecoderPCAP:
 		return true
 	}
 	return false
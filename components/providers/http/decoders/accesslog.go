@@ -0,0 +1,350 @@
+package decoders
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yandex/pandora/components/providers/http/config"
+	"github.com/yandex/pandora/components/providers/http/decoders/ammo"
+	"github.com/yandex/pandora/core"
+)
+
+// Apache combined and common formats are the same, as nginx ones.
+var accessLogFormats = map[string]string{
+	config.AccessLogFormatCombined: `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`,
+	config.AccessLogFormatCommon:   `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent`,
+}
+
+const accessLogTimeLocalLayout = "02/Jan/2006:15:04:05 -0700"
+
+var (
+	accessLogVariable = regexp.MustCompile(`\$(?:\{(\w+)\}|(\w+))`)
+	accessLogStatus   = regexp.MustCompile(`^[1-5](xx|\d\d)$`)
+)
+
+// errAccessLogMismatch is returned for line, that doesn't match log format. Such lines are skipped:
+// logs often have lines of other format, like error messages, written to the same file.
+var errAccessLogMismatch = errors.New("line doesn't match access log format")
+
+// accessLogPattern is compiled log_format.
+type accessLogPattern struct {
+	re *regexp.Regexp
+	// Indexes of variable submatches. Zero, if variable is not in format.
+	request, method, uri, status int
+	timeLocal, timeISO8601, msec int
+}
+
+// compileAccessLogFormat makes regexp from log_format. Variable value lasts till next literal character of format,
+// so "$request" matches quoted request line, and [$time_local] matches time in brackets.
+// Line can have more fields, than format has: they are ignored.
+func compileAccessLogFormat(format string) (*accessLogPattern, error) {
+	if f, ok := accessLogFormats[format]; ok {
+		format = f
+	}
+	p := &accessLogPattern{}
+	var expr strings.Builder
+	expr.WriteString("^")
+	matches := accessLogVariable.FindAllStringSubmatchIndex(format, -1)
+	literalStart := 0
+	for i, m := range matches {
+		expr.WriteString(regexp.QuoteMeta(format[literalStart:m[0]]))
+		literalStart = m[1]
+		var name string
+		if m[2] >= 0 {
+			name = format[m[2]:m[3]]
+		} else {
+			name = format[m[4]:m[5]]
+		}
+		switch {
+		case m[1] == len(format):
+			expr.WriteString(`(.*)`)
+		case format[m[1]] == '"':
+			// nginx escapes quotes as \x22, apache as \".
+			expr.WriteString(`((?:[^"\\]|\\.)*)`)
+		default:
+			expr.WriteString(`([^` + regexp.QuoteMeta(format[m[1]:m[1]+1]) + `]*)`)
+		}
+		index := i + 1
+		switch name {
+		case "request":
+			p.request = index
+		case "request_method":
+			p.method = index
+		case "request_uri":
+			p.uri = index
+		case "status":
+			p.status = index
+		case "time_local":
+			p.timeLocal = index
+		case "time_iso8601":
+			p.timeISO8601 = index
+		case "msec":
+			p.msec = index
+		}
+	}
+	expr.WriteString(regexp.QuoteMeta(format[literalStart:]))
+	if p.request == 0 && (p.method == 0 || p.uri == 0) {
+		return nil, fmt.Errorf("access log format should have $request or both $request_method and $request_uri: %q", format)
+	}
+	var err error
+	p.re, err = regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("access log format %q compile failed: %w", format, err)
+	}
+	return p, nil
+}
+
+// accessLogRecord is part of log line, needed to make ammo.
+type accessLogRecord struct {
+	method, uri, status string
+	time                time.Time
+}
+
+// parse returns false, if line has no valid request. For example, nginx logs "-" or TLS handshake bytes
+// as request of connection, that was closed before request was read.
+func (p *accessLogPattern) parse(line string, withTime bool) (r accessLogRecord, ok bool, err error) {
+	m := p.re.FindStringSubmatch(line)
+	if m == nil {
+		return r, false, errAccessLogMismatch
+	}
+	if p.request != 0 {
+		fields := strings.Fields(m[p.request])
+		if len(fields) < 2 || len(fields) > 3 {
+			return r, false, nil
+		}
+		r.method, r.uri = fields[0], fields[1]
+	} else {
+		r.method, r.uri = m[p.method], m[p.uri]
+	}
+	if !strings.HasPrefix(r.uri, "/") {
+		return r, false, nil
+	}
+	if p.status != 0 {
+		r.status = m[p.status]
+	}
+	if withTime {
+		switch {
+		case p.timeLocal != 0:
+			r.time, err = time.Parse(accessLogTimeLocalLayout, m[p.timeLocal])
+		case p.timeISO8601 != 0:
+			r.time, err = time.Parse(time.RFC3339, m[p.timeISO8601])
+		case p.msec != 0:
+			var msec float64
+			msec, err = strconv.ParseFloat(m[p.msec], 64)
+			r.time = time.UnixMilli(int64(msec * 1000))
+		}
+		if err != nil {
+			return r, false, fmt.Errorf("invalid time: %w", err)
+		}
+	}
+	return r, true, nil
+}
+
+func newAccessLogDecoder(file io.ReadSeeker, cfg config.Config, decodedConfigHeaders http.Header) (*accessLogDecoder, error) {
+	conf := cfg.AccessLog
+	if conf.Format == "" {
+		conf.Format = config.AccessLogFormatCombined
+	}
+	pattern, err := compileAccessLogFormat(conf.Format)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Timestamps && pattern.timeLocal == 0 && pattern.timeISO8601 == 0 && pattern.msec == 0 {
+		return nil, fmt.Errorf("access log timestamps need $time_local, $time_iso8601 or $msec in format")
+	}
+	if len(conf.Statuses) > 0 && pattern.status == 0 {
+		return nil, fmt.Errorf("access log statuses filter needs $status in format")
+	}
+	methods := conf.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead}
+	}
+	d := &accessLogDecoder{
+		protoDecoder: protoDecoder{
+			file:                 file,
+			config:               cfg,
+			decodedConfigHeaders: decodedConfigHeaders,
+		},
+		scanner:    bufio.NewScanner(file),
+		pattern:    pattern,
+		methods:    map[string]bool{},
+		statuses:   map[string]bool{},
+		tags:       conf.Tags,
+		timestamps: conf.Timestamps,
+		pool:       &sync.Pool{New: func() any { return &ammo.Ammo{} }},
+	}
+	for _, m := range methods {
+		d.methods[strings.ToUpper(m)] = true
+	}
+	for _, s := range conf.Statuses {
+		s = strings.ToLower(s)
+		if !accessLogStatus.MatchString(s) {
+			return nil, fmt.Errorf("invalid access log status %q: should be code, like 200, or class, like 2xx", s)
+		}
+		d.statuses[s] = true
+	}
+	return d, nil
+}
+
+type accessLogDecoder struct {
+	protoDecoder
+	scanner    *bufio.Scanner
+	pattern    *accessLogPattern
+	methods    map[string]bool
+	statuses   map[string]bool
+	tags       []config.AccessLogTag
+	timestamps bool
+	line       uint
+	skipped    int
+	pool       *sync.Pool
+	index      fileIndex
+}
+
+func (d *accessLogDecoder) readLine(data string) (DecodedAmmo, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+	r, ok, err := d.pattern.parse(data, d.timestamps)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if !d.methods[r.method] || !d.statusMatches(r.status) {
+		return nil, nil
+	}
+	a := d.pool.Get().(*ammo.Ammo)
+	if err := a.Setup(r.method, r.uri, nil, d.decodedConfigHeaders.Clone(), d.tag(r.uri)); err != nil {
+		return nil, err
+	}
+	a.SetTimestamp(r.time)
+	return a, nil
+}
+
+func (d *accessLogDecoder) statusMatches(status string) bool {
+	if len(d.statuses) == 0 {
+		return true
+	}
+	if len(status) != 3 {
+		return false
+	}
+	return d.statuses[status] || d.statuses[status[:1]+"xx"]
+}
+
+// tag returns tag of longest matching path prefix.
+func (d *accessLogDecoder) tag(uri string) string {
+	path, _, _ := strings.Cut(uri, "?")
+	var tag string
+	longest := -1
+	for _, t := range d.tags {
+		if len(t.Prefix) > longest && strings.HasPrefix(path, t.Prefix) {
+			tag = t.Tag
+			longest = len(t.Prefix)
+		}
+	}
+	return tag
+}
+
+func (d *accessLogDecoder) Release(a core.Ammo) {
+	if am, ok := a.(*ammo.Ammo); ok {
+		am.Reset()
+		d.pool.Put(am)
+	}
+}
+
+func (d *accessLogDecoder) LoadAmmo(ctx context.Context) ([]DecodedAmmo, error) {
+	return d.protoDecoder.LoadAmmo(ctx, d.Scan)
+}
+
+func (d *accessLogDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
+	if d.config.Limit != 0 && d.ammoNum >= d.config.Limit {
+		return nil, ErrAmmoLimit
+	}
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !d.scanner.Scan() {
+			if d.scanner.Err() != nil {
+				return nil, d.scanner.Err()
+			}
+			d.line = 0
+			d.passNum++
+			if d.config.Passes != 0 && d.passNum >= d.config.Passes {
+				return nil, ErrPassLimit
+			}
+			if d.ammoNum == 0 {
+				return nil, ErrNoAmmo
+			}
+			_, err := d.file.Seek(0, io.SeekStart)
+			if err != nil {
+				return nil, err
+			}
+			d.scanner = bufio.NewScanner(d.file)
+			continue
+		}
+		d.line++
+		data := d.scanner.Text()
+		a, err := d.readLine(data)
+		if errors.Is(err, errAccessLogMismatch) {
+			if d.passNum == 0 {
+				d.skipped++
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode at line %d `%s` error: %w", d.line, data, err)
+		}
+		if a != nil {
+			d.ammoNum++
+			return a, nil
+		}
+	}
+}
+
+// Skipped returns number of lines, that don't match log format.
+func (d *accessLogDecoder) Skipped() int {
+	return d.skipped
+}
+
+func (d *accessLogDecoder) Index(ctx context.Context) (int, error) {
+	err := d.index.build(ctx, d.file, func(line string) (bool, int, error) {
+		// Line is parsed to skip records, that are filtered out.
+		a, err := d.readLine(strings.TrimRight(line, "\r\n"))
+		if a != nil {
+			d.Release(a)
+		}
+		if errors.Is(err, errAccessLogMismatch) {
+			d.skipped++
+			return false, 0, nil
+		}
+		return a != nil, 0, err
+	})
+	return len(d.index.offsets), err
+}
+
+func (d *accessLogDecoder) ReadAmmo(i int) (DecodedAmmo, error) {
+	r, err := d.index.seek(d.file, i)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readRecordLine(r)
+	if err != nil {
+		return nil, err
+	}
+	a, err := d.readLine(data)
+	if err == nil && a == nil {
+		err = errors.New("record is filtered out")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode ammo %d `%s` error: %w", i, data, err)
+	}
+	return a, nil
+}
@@ -0,0 +1,135 @@
+package decoders
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/http/config"
+)
+
+const accessLogDecoderInput = `127.0.0.1 - - [19/Oct/2026:12:00:00 +0300] "GET /api/items/42?full=1 HTTP/1.1" 200 512 "-" "curl/8.0"
+127.0.0.1 - admin [19/Oct/2026:12:00:01 +0300] "POST /api/cart HTTP/1.1" 201 0 "-" "curl/8.0"
+127.0.0.1 - - [19/Oct/2026:12:00:02 +0300] "-" 400 0 "-" "-"
+
+127.0.0.1 - - [19/Oct/2026:12:00:03 +0300] "HEAD /static/app.js HTTP/1.1" 304 0 "https://example.com/" "Mozilla/5.0 \"quoted\""
+127.0.0.1 - - [19/Oct/2026:12:00:04 +0300] "GET /api/missing HTTP/1.1" 404 0 "-" "curl/8.0"
+127.0.0.1 - - [19/Oct/2026:12:00:05 +0300] "GET /api/admin/users HTTP/2.0" 500 0 "-" "curl/8.0" rt=0.002
+`
+
+func TestAccessLogDecoder(t *testing.T) {
+	conf := config.Config{
+		Passes: 1,
+		AccessLog: config.AccessLogConfig{
+			Statuses: []string{"2xx", "3xx", "500"},
+			Tags: []config.AccessLogTag{
+				{Prefix: "/api", Tag: "api"},
+				{Prefix: "/api/admin", Tag: "admin"},
+			},
+			Timestamps: true,
+		},
+	}
+	headers := http.Header{"User-Agent": []string{"Pandora"}}
+	d, err := newAccessLogDecoder(strings.NewReader(accessLogDecoderInput), conf, headers)
+	require.NoError(t, err)
+
+	type request struct {
+		method, uri, tag string
+		time             time.Time
+	}
+	want := []request{
+		{method: "GET", uri: "/api/items/42?full=1", tag: "api", time: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{method: "HEAD", uri: "/static/app.js", time: time.Date(2026, 10, 19, 9, 0, 3, 0, time.UTC)},
+		{method: "GET", uri: "/api/admin/users", tag: "admin", time: time.Date(2026, 10, 19, 9, 0, 5, 0, time.UTC)},
+	}
+	ctx := context.Background()
+	for _, w := range want {
+		a, err := d.Scan(ctx)
+		require.NoError(t, err)
+		req, err := a.BuildRequest()
+		require.NoError(t, err)
+		assert.Equal(t, headers, req.Header)
+		ts := a.(TimestampedAmmo).Timestamp()
+		assert.Equal(t, w, request{method: req.Method, uri: req.URL.String(), tag: a.Tag(), time: ts.UTC()})
+	}
+	_, err = d.Scan(ctx)
+	assert.ErrorIs(t, err, ErrPassLimit)
+
+	conf.AccessLog = config.AccessLogConfig{Methods: []string{"post"}}
+	d, err = newAccessLogDecoder(strings.NewReader(accessLogDecoderInput), conf, http.Header{})
+	require.NoError(t, err)
+	ammos, err := d.LoadAmmo(ctx)
+	require.NoError(t, err)
+	require.Len(t, ammos, 1)
+	req, err := ammos[0].BuildRequest()
+	require.NoError(t, err)
+	assert.Equal(t, "POST", req.Method)
+	assert.True(t, ammos[0].(TimestampedAmmo).Timestamp().IsZero())
+}
+
+func TestAccessLogDecoder_Format(t *testing.T) {
+	conf := config.Config{
+		Passes: 1,
+		AccessLog: config.AccessLogConfig{
+			Format:     `$msec $request_method ${request_uri} $status`,
+			Timestamps: true,
+		},
+	}
+	d, err := newAccessLogDecoder(strings.NewReader("1792400400.250 GET /ping 200\n"), conf, http.Header{})
+	require.NoError(t, err)
+	a, err := d.Scan(context.Background())
+	require.NoError(t, err)
+	req, err := a.BuildRequest()
+	require.NoError(t, err)
+	assert.Equal(t, "/ping", req.URL.String())
+	assert.Equal(t, time.UnixMilli(1792400400250), a.(TimestampedAmmo).Timestamp())
+
+	for _, c := range []config.AccessLogConfig{
+		{Format: `$remote_addr $status`},
+		{Format: `$request`, Timestamps: true},
+		{Format: `$request`, Statuses: []string{"200"}},
+		{Statuses: []string{"20x"}},
+	} {
+		_, err = newAccessLogDecoder(strings.NewReader(""), config.Config{AccessLog: c}, http.Header{})
+		assert.Error(t, err, c)
+	}
+}
+
+func TestAccessLogDecoder_SkipUnmatched(t *testing.T) {
+	const input = "1792400400.250 GET /1 200\ngarbage\n1792400400.250 GET /2 200\nnot a record\n"
+	conf := config.Config{
+		Passes:    2,
+		AccessLog: config.AccessLogConfig{Format: `$msec $request_method ${request_uri} $status`},
+	}
+	d, err := newAccessLogDecoder(strings.NewReader(input), conf, http.Header{})
+	require.NoError(t, err)
+	var uris []string
+	for {
+		a, err := d.Scan(context.Background())
+		if err == ErrPassLimit {
+			break
+		}
+		require.NoError(t, err)
+		req, err := a.BuildRequest()
+		require.NoError(t, err)
+		uris = append(uris, req.URL.String())
+	}
+	assert.Equal(t, []string{"/1", "/2", "/1", "/2"}, uris)
+	assert.Equal(t, 2, d.Skipped(), "lines are counted once for all passes")
+
+	d, err = newAccessLogDecoder(strings.NewReader(input), conf, http.Header{})
+	require.NoError(t, err)
+	n, err := d.Index(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, d.Skipped())
+	a, err := d.ReadAmmo(1)
+	require.NoError(t, err)
+	req, err := a.BuildRequest()
+	require.NoError(t, err)
+	assert.Equal(t, "/2", req.URL.String())
+}
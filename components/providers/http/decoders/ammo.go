@@ -1,8 +1,17 @@
 package decoders
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type DecodedAmmo interface {
 	BuildRequest() (*http.Request, error)
 	Tag() string
 }
+
+// TimestampedAmmo is ammo, that knows time of original request. For example, ammo of accesslog decoder.
+type TimestampedAmmo interface {
+	DecodedAmmo
+	Timestamp() time.Time
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	url2 "net/url"
+	"time"
 
 	"github.com/yandex/pandora/components/providers/http/util"
 	"github.com/yandex/pandora/lib/netutil"
@@ -18,6 +19,8 @@ type Ammo struct {
 	url    string
 	tag    string
 	header http.Header
+	// timestamp is time of original request. Zero, if unknown.
+	timestamp time.Time
 }
 
 func (a *Ammo) BuildRequest() (*http.Request, error) {
@@ -37,6 +40,15 @@ func (a *Ammo) Tag() string {
 	return a.tag
 }
 
+// Timestamp returns time of original request, for example, from access log. Zero, if unknown.
+func (a *Ammo) Timestamp() time.Time {
+	return a.timestamp
+}
+
+func (a *Ammo) SetTimestamp(t time.Time) {
+	a.timestamp = t
+}
+
 func (a *Ammo) Setup(method string, url string, body []byte, header http.Header, tag string) error {
 	if ok := netutil.ValidHTTPMethod(method); !ok {
 		return errors.New("invalid HTTP method " + method)
@@ -59,4 +71,5 @@ func (a *Ammo) Reset() {
 	a.url = ""
 	a.tag = ""
 	a.header = nil
+	a.timestamp = time.Time{}
 }
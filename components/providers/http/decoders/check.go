@@ -0,0 +1,113 @@
+package decoders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+
+	"github.com/yandex/pandora/components/providers/http/decoders/ammo"
+)
+
+// BodySizeBuckets are upper bounds of body size histogram of CheckReport, in bytes.
+// The last bucket of histogram counts bodies, that are bigger than the last bound.
+var BodySizeBuckets = []int{0, 1 << 10, 10 << 10, 100 << 10, 1 << 20}
+
+// CheckReport is result of full ammo file check.
+type CheckReport struct {
+	// Ammo is number of valid ammo.
+	Ammo int
+	// Errors are decode errors of malformed entries and request build errors.
+	Errors []error
+	// Skipped is number of malformed entries, that decoder skips by itself. See SkipCounter.
+	Skipped int
+	// Tags, Methods and Hosts are numbers of ammo per value. Empty value means unset.
+	Tags    map[string]int
+	Methods map[string]int
+	Hosts   map[string]int
+	// BodySizes is histogram of BodySizeBuckets.
+	BodySizes []int
+	// PreloadMemory is rough estimation of memory in bytes, that ammo takes with preload option.
+	PreloadMemory int64
+}
+
+// Check scans decoder till the end of pass and collects ammo statistics.
+// Decoder should be made with single pass, otherwise ammo is counted for every pass.
+// Malformed entries are skipped, if decoder can continue after them; check stops after maxErrors errors, if it is positive.
+func Check(ctx context.Context, d Decoder, maxErrors int) (r CheckReport) {
+	defer func() {
+		if sc, ok := d.(SkipCounter); ok {
+			r.Skipped = sc.Skipped()
+		}
+	}()
+	r = CheckReport{
+		Tags:      map[string]int{},
+		Methods:   map[string]int{},
+		Hosts:     map[string]int{},
+		BodySizes: make([]int, len(BodySizeBuckets)+1),
+	}
+	addError := func(err error) bool {
+		r.Errors = append(r.Errors, err)
+		return maxErrors <= 0 || len(r.Errors) < maxErrors
+	}
+	var lastErr string
+	for n := 1; ; n++ {
+		a, err := d.Scan(ctx)
+		if errors.Is(err, ErrPassLimit) || errors.Is(err, ErrAmmoLimit) || errors.Is(err, ErrNoAmmo) {
+			return r
+		}
+		if err != nil {
+			// Some decoders can't skip malformed entry and return the same error again.
+			if err.Error() == lastErr || ctx.Err() != nil || !addError(err) {
+				return r
+			}
+			lastErr = err.Error()
+			continue
+		}
+		lastErr = ""
+		req, err := a.BuildRequest()
+		if err != nil {
+			if !addError(fmt.Errorf("ammo #%d: %w", n, err)) {
+				return r
+			}
+			continue
+		}
+		var body []byte
+		if req.Body != nil {
+			body, err = io.ReadAll(req.Body)
+			if err != nil {
+				if !addError(fmt.Errorf("ammo #%d: %w", n, err)) {
+					return r
+				}
+				continue
+			}
+		}
+		host := req.Host
+		if host == "" {
+			host = req.URL.Host
+		}
+		r.Ammo++
+		r.Tags[a.Tag()]++
+		r.Methods[req.Method]++
+		r.Hosts[host]++
+		bucket := len(BodySizeBuckets)
+		for i, bound := range BodySizeBuckets {
+			if len(body) <= bound {
+				bucket = i
+				break
+			}
+		}
+		r.BodySizes[bucket]++
+
+		size := int64(unsafe.Sizeof(ammo.Ammo{})) + int64(len(req.Method)+len(req.URL.String())+len(a.Tag())+len(body))
+		for k, vv := range req.Header {
+			// Map entry and slice headers.
+			size += int64(len(k)) + 64
+			for _, v := range vv {
+				size += int64(len(v)) + int64(unsafe.Sizeof(v))
+			}
+		}
+		r.PreloadMemory += size
+	}
+}
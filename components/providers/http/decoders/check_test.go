@@ -0,0 +1,121 @@
+package decoders
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/http/config"
+)
+
+func TestCheck(t *testing.T) {
+	const input = `[Host: shop.example.com]
+/items items
+/%zz
+/cart
+[Broken header
+/cart cart
+`
+	d := newURIDecoder(strings.NewReader(input), config.Config{Passes: 1}, http.Header{})
+	r := Check(context.Background(), d, 0)
+	assert.Equal(t, 3, r.Ammo)
+	require.Len(t, r.Errors, 2)
+	assert.ErrorContains(t, r.Errors[0], "decode at line 3 `/%zz`")
+	assert.ErrorContains(t, r.Errors[1], "decode at line 5 `[Broken header`")
+	assert.Equal(t, map[string]int{"items": 1, "": 1, "cart": 1}, r.Tags)
+	assert.Equal(t, map[string]int{"GET": 3}, r.Methods)
+	assert.Equal(t, map[string]int{"shop.example.com": 3}, r.Hosts)
+	assert.Equal(t, []int{3, 0, 0, 0, 0, 0}, r.BodySizes)
+	assert.Positive(t, r.PreloadMemory)
+
+	d = newURIDecoder(strings.NewReader(input), config.Config{Passes: 1}, http.Header{})
+	r = Check(context.Background(), d, 1)
+	assert.Len(t, r.Errors, 1)
+	assert.Equal(t, 1, r.Ammo)
+}
+
+func TestCheck_UnrecoverableDecoder(t *testing.T) {
+	const input = `{"host": "a.example.com", "method": "POST", "uri": "/1", "body": "` + "12345678901" + `"}
+{"host": "a.example.com", "method": "GET", "uri": "/2"
+{"host": "a.example.com", "method": "GET", "uri": "/3"}
+`
+	d, err := newJsonlineDecoder(strings.NewReader(input), config.Config{Passes: 1}, http.Header{})
+	require.NoError(t, err)
+	r := Check(context.Background(), d, 100)
+	assert.Equal(t, 1, r.Ammo)
+	require.Len(t, r.Errors, 1)
+	assert.ErrorContains(t, r.Errors[0], "failed to decode ammo at line: 2")
+	assert.Equal(t, []int{0, 1, 0, 0, 0, 0}, r.BodySizes)
+	assert.Equal(t, map[string]int{"POST": 1}, r.Methods)
+}
+
+func TestCheck_LineNumbers(t *testing.T) {
+	const rawRequest = "GET /1 HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	tests := []struct {
+		name        string
+		decoder     func(r io.ReadSeeker) (Decoder, error)
+		input       string
+		wantAmmo    int
+		wantErrors  []string
+		wantSkipped int
+	}{
+		{
+			name: "uripost",
+			decoder: func(r io.ReadSeeker) (Decoder, error) {
+				return newURIPostDecoder(r, config.Config{Passes: 1}, http.Header{}), nil
+			},
+			input:      "[Host: example.com]\n5 /1\nline\n\n[Broken\n2 /2\n{}\nxx /3\n",
+			wantAmmo:   2,
+			wantErrors: []string{"decode at line 5 `[Broken`", "decode at line 8 `xx /3`"},
+		},
+		{
+			name: "raw",
+			decoder: func(r io.ReadSeeker) (Decoder, error) {
+				return newRawDecoder(r, config.Config{Passes: 1}, http.Header{}), nil
+			},
+			input:      fmt.Sprintf("%d\n%s\nbroken\n%d\n%s", len(rawRequest), rawRequest, len(rawRequest), rawRequest),
+			wantAmmo:   2,
+			wantErrors: []string{"header decoding error at line 6: invalid payload size line `broken`"},
+		},
+		{
+			name: "jsonline",
+			decoder: func(r io.ReadSeeker) (Decoder, error) {
+				return newJsonlineDecoder(r, config.Config{Passes: 1}, http.Header{})
+			},
+			input:      "{\"uri\": \"/1\"}\n\n{\"uri\": \"/2\"}\n{\"uri\": 3}\n",
+			wantAmmo:   2,
+			wantErrors: []string{"failed to decode ammo at line: 4"},
+		},
+		{
+			name: "accesslog",
+			decoder: func(r io.ReadSeeker) (Decoder, error) {
+				return newAccessLogDecoder(r, config.Config{Passes: 1}, http.Header{})
+			},
+			input: `127.0.0.1 - - [19/Oct/2026:12:00:00 +0300] "GET /1 HTTP/1.1" 200 0 "-" "-"
+
+garbage
+127.0.0.1 - - [19/Oct/2026:12:00:01 +0300] "GET /2 HTTP/1.1" 200 0 "-" "-"
+`,
+			wantAmmo:    2,
+			wantSkipped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := tt.decoder(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			r := Check(context.Background(), d, 0)
+			assert.Equal(t, tt.wantAmmo, r.Ammo)
+			assert.Equal(t, tt.wantSkipped, r.Skipped)
+			require.Len(t, r.Errors, len(tt.wantErrors), "%v", r.Errors)
+			for i, want := range tt.wantErrors {
+				assert.ErrorContains(t, r.Errors[i], want)
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package decoders
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/yandex/pandora/components/providers/http/config"
+	"github.com/yandex/pandora/components/providers/http/decoders/ammo"
+	"github.com/yandex/pandora/core"
+)
+
+// curlIgnoredFlags don't change request, or are about client behaviour, that is configured in gun.
+var curlIgnoredFlags = map[string]bool{
+	"-s": true, "--silent": true,
+	"-S": true, "--show-error": true,
+	"-k": true, "--insecure": true,
+	"-L": true, "--location": true,
+	"-i": true, "--include": true,
+	"-v": true, "--verbose": true,
+	"-g": true, "--globoff": true,
+	"--http1.1": true, "--http2": true,
+}
+
+// newCurlDecoder reads whole file, because curl command can take several lines.
+// File is list of bash curl commands, like "Copy as cURL (bash)" of browser developer tools makes.
+// Lines, that start with #, are comments.
+func newCurlDecoder(file io.ReadSeeker, cfg config.Config, decodedConfigHeaders http.Header) (*curlDecoder, error) {
+	d := &curlDecoder{
+		protoDecoder: protoDecoder{
+			file:                 file,
+			config:               cfg,
+			decodedConfigHeaders: decodedConfigHeaders,
+		},
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	commands, err := splitShellCommands(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("curl commands parse failed: %w", err)
+	}
+	for _, cmd := range commands {
+		a, err := d.makeAmmo(cmd.args)
+		if err != nil {
+			return nil, fmt.Errorf("curl command at line %d: %w", cmd.line, err)
+		}
+		d.ammos = append(d.ammos, a)
+	}
+	return d, nil
+}
+
+type curlDecoder struct {
+	protoDecoder
+	ammos []DecodedAmmo
+}
+
+func (d *curlDecoder) makeAmmo(args []string) (DecodedAmmo, error) {
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New("command should start with curl")
+	}
+	var (
+		method, rawURL string
+		data           []string
+		get            bool
+		compressed     bool
+	)
+	header := d.decodedConfigHeaders.Clone()
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			if rawURL != "" {
+				return nil, fmt.Errorf("several urls are not supported: %q and %q", rawURL, arg)
+			}
+			rawURL = arg
+			continue
+		}
+		if curlIgnoredFlags[arg] || isCurlIgnoredShortFlags(arg) {
+			continue
+		}
+		switch arg {
+		case "--compressed":
+			compressed = true
+			continue
+		case "-G", "--get":
+			get = true
+			continue
+		case "-I", "--head":
+			method = http.MethodHead
+			continue
+		}
+		// Short options can be glued with value, like -XPOST.
+		name, value := arg, ""
+		if len(arg) > 2 && arg[1] != '-' {
+			name, value = arg[:2], arg[2:]
+		} else {
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("option %s needs value", arg)
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "-X", "--request":
+			method = value
+		case "--url":
+			rawURL = value
+		case "-H", "--header":
+			key, val, ok := strings.Cut(value, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid header %q", value)
+			}
+			header.Set(strings.TrimSpace(key), strings.TrimSpace(val))
+		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii":
+			if strings.HasPrefix(value, "@") && name != "--data-raw" {
+				return nil, fmt.Errorf("data from file %s is not supported", value)
+			}
+			data = append(data, value)
+		case "--data-urlencode":
+			data = append(data, curlURLEncode(value))
+		case "-u", "--user":
+			header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(value)))
+		case "-b", "--cookie":
+			if !strings.Contains(value, "=") {
+				return nil, fmt.Errorf("cookie file %s is not supported", value)
+			}
+			header.Set("Cookie", value)
+		case "-A", "--user-agent":
+			header.Set("User-Agent", value)
+		case "-e", "--referer":
+			header.Set("Referer", value)
+		default:
+			return nil, fmt.Errorf("unsupported option %s", arg)
+		}
+	}
+	if rawURL == "" {
+		return nil, errors.New("no url")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	var body []byte
+	if data != nil {
+		joined := strings.Join(data, "&")
+		if get {
+			separator := "?"
+			if strings.Contains(rawURL, "?") {
+				separator = "&"
+			}
+			rawURL += separator + joined
+		} else {
+			body = []byte(joined)
+			if header.Get("Content-Type") == "" {
+				header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+		}
+	}
+	if method == "" {
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+	if compressed && header.Get("Accept-Encoding") == "" {
+		header.Set("Accept-Encoding", "deflate, gzip")
+	}
+	a := &ammo.Ammo{}
+	err := a.Setup(method, rawURL, body, header, "")
+	return a, err
+}
+
+// isCurlIgnoredShortFlags checks, that arg is combination of ignored short flags, like -sSL.
+func isCurlIgnoredShortFlags(arg string) bool {
+	if len(arg) < 2 || arg[1] == '-' {
+		return false
+	}
+	for _, c := range arg[1:] {
+		if !curlIgnoredFlags["-"+string(c)] {
+			return false
+		}
+	}
+	return true
+}
+
+// curlURLEncode encodes --data-urlencode value: content, =content or name=content.
+func curlURLEncode(value string) string {
+	name, content, ok := strings.Cut(value, "=")
+	if !ok {
+		return url.QueryEscape(value)
+	}
+	if name == "" {
+		return url.QueryEscape(content)
+	}
+	return name + "=" + url.QueryEscape(content)
+}
+
+func (d *curlDecoder) Release(core.Ammo) {}
+
+func (d *curlDecoder) LoadAmmo(context.Context) ([]DecodedAmmo, error) {
+	if len(d.ammos) == 0 {
+		return nil, ErrNoAmmo
+	}
+	return d.ammos, nil
+}
+
+func (d *curlDecoder) Scan(context.Context) (DecodedAmmo, error) {
+	if d.config.Limit != 0 && d.ammoNum >= d.config.Limit {
+		return nil, ErrAmmoLimit
+	}
+	return d.scanAmmos(d.ammos)
+}
+
+type shellCommand struct {
+	line int // Line, where command starts.
+	args []string
+}
+
+// splitShellCommands splits text to commands, and commands to arguments, like bash does.
+// Single and double quotes, ANSI-C $'...' quotes, backslash escapes and line continuations are supported.
+// Variables, substitutions and pipes are not.
+func splitShellCommands(text string) ([]shellCommand, error) {
+	var (
+		commands []shellCommand
+		cmd      shellCommand
+		arg      strings.Builder
+		inArg    bool
+	)
+	line := 1
+	endArg := func() {
+		if inArg {
+			cmd.args = append(cmd.args, arg.String())
+			arg.Reset()
+			inArg = false
+		}
+	}
+	endCommand := func() {
+		endArg()
+		if len(cmd.args) > 0 {
+			commands = append(commands, cmd)
+		}
+		cmd = shellCommand{}
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if len(cmd.args) == 0 && !inArg {
+			cmd.line = line
+		}
+		switch {
+		case c == '\n':
+			line++
+			endCommand()
+		case c == ' ' || c == '\t' || c == '\r':
+			endArg()
+		case c == '#' && !inArg:
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '\\':
+			if i+1 == len(text) {
+				return nil, fmt.Errorf("line %d: unexpected end after backslash", line)
+			}
+			i++
+			if text[i] == '\n' {
+				line++
+				continue
+			}
+			if text[i] == '\r' && i+1 < len(text) && text[i+1] == '\n' {
+				i++
+				line++
+				continue
+			}
+			arg.WriteByte(text[i])
+			inArg = true
+		case c == '\'':
+			end := strings.IndexByte(text[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single quote", line)
+			}
+			quoted := text[i+1 : i+1+end]
+			line += strings.Count(quoted, "\n")
+			arg.WriteString(quoted)
+			inArg = true
+			i += end + 1
+		case c == '$' && i+1 < len(text) && text[i+1] == '\'':
+			n, err := readANSICQuoted(text[i+2:], &arg)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			line += strings.Count(text[i+2:i+2+n], "\n")
+			inArg = true
+			i += n + 1
+		case c == '"':
+			n, err := readDoubleQuoted(text[i+1:], &arg)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			line += strings.Count(text[i+1:i+1+n], "\n")
+			inArg = true
+			i += n
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	endCommand()
+	return commands, nil
+}
+
+// readDoubleQuoted reads "..." content till closing quote. It returns number of bytes read, including closing quote.
+// In double quotes backslash escapes only $, `, ", \ and newline.
+func readDoubleQuoted(s string, arg *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return i + 1, nil
+		case '\\':
+			if i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+				i++
+				if s[i] != '\n' {
+					arg.WriteByte(s[i])
+				}
+				continue
+			}
+			arg.WriteByte(c)
+		default:
+			arg.WriteByte(c)
+		}
+	}
+	return 0, errors.New("unterminated double quote")
+}
+
+// readANSICQuoted reads $'...' content till closing quote. It returns number of bytes read, including closing quote.
+func readANSICQuoted(s string, arg *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			return i + 1, nil
+		}
+		if c != '\\' {
+			arg.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			break
+		}
+		switch e := s[i]; e {
+		case 'n':
+			arg.WriteByte('\n')
+		case 't':
+			arg.WriteByte('\t')
+		case 'r':
+			arg.WriteByte('\r')
+		case 'e', 'E':
+			arg.WriteByte(0x1b)
+		case '\\', '\'', '"', '?':
+			arg.WriteByte(e)
+		case 'x', 'u', 'U':
+			size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+			j := i + 1
+			for j < len(s) && j < i+1+size && isHexDigit(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				return 0, fmt.Errorf("invalid escape \\%c", e)
+			}
+			code, _ := strconv.ParseUint(s[i+1:j], 16, 32)
+			if e == 'x' {
+				arg.WriteByte(byte(code))
+			} else {
+				arg.WriteString(string(rune(code)))
+			}
+			i = j - 1
+		default:
+			// Unknown escape is kept as is.
+			arg.WriteByte('\\')
+			arg.WriteByte(e)
+		}
+	}
+	return 0, errors.New("unterminated $' quote")
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func (d *curlDecoder) Index(context.Context) (int, error) {
+	return len(d.ammos), nil
+}
+
+func (d *curlDecoder) ReadAmmo(i int) (DecodedAmmo, error) {
+	return d.ammos[i], nil
+}
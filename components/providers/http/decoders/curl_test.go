@@ -0,0 +1,99 @@
+package decoders
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/http/config"
+)
+
+const curlDecoderInput = `# Copied from browser.
+curl 'https://shop.example.com/api/items?full=1' \
+  -H 'accept: application/json' \
+  -H 'user-agent: Mozilla/5.0' \
+  -b 'session=secret' \
+  --compressed
+
+curl -X PUT "https://shop.example.com/api/cart" -H "Content-Type: application/json" \
+  --data-raw $'{"name":"it\'s \\"tea\\""}'
+curl -sS -u admin:pass -d user=admin --data-urlencode 'q=a b' shop.example.com/login
+curl -G https://shop.example.com/search?page=2 -d q=tea -XHEAD
+`
+
+func TestCurlDecoder(t *testing.T) {
+	conf := config.Config{Passes: 1}
+	headers := http.Header{"User-Agent": []string{"Pandora"}}
+	d, err := newCurlDecoder(strings.NewReader(curlDecoderInput), conf, headers)
+	require.NoError(t, err)
+
+	type request struct {
+		method, url, body string
+		header            http.Header
+	}
+	want := []request{
+		{
+			method: "GET",
+			url:    "https://shop.example.com/api/items?full=1",
+			header: http.Header{
+				"Accept":          []string{"application/json"},
+				"User-Agent":      []string{"Mozilla/5.0"},
+				"Cookie":          []string{"session=secret"},
+				"Accept-Encoding": []string{"deflate, gzip"},
+			},
+		},
+		{
+			method: "PUT",
+			url:    "https://shop.example.com/api/cart",
+			body:   `{"name":"it's \"tea\""}`,
+			header: http.Header{"Content-Type": []string{"application/json"}, "User-Agent": []string{"Pandora"}},
+		},
+		{
+			method: "POST",
+			url:    "http://shop.example.com/login",
+			body:   "user=admin&q=a+b",
+			header: http.Header{
+				"Authorization": []string{"Basic YWRtaW46cGFzcw=="},
+				"Content-Type":  []string{"application/x-www-form-urlencoded"},
+				"User-Agent":    []string{"Pandora"},
+			},
+		},
+		{
+			method: "HEAD",
+			url:    "https://shop.example.com/search?page=2&q=tea",
+			header: http.Header{"User-Agent": []string{"Pandora"}},
+		},
+	}
+	ctx := context.Background()
+	for _, w := range want {
+		a, err := d.Scan(ctx)
+		require.NoError(t, err)
+		req, err := a.BuildRequest()
+		require.NoError(t, err)
+		var body []byte
+		if req.Body != nil {
+			body, err = io.ReadAll(req.Body)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, w, request{method: req.Method, url: req.URL.String(), body: string(body), header: req.Header})
+	}
+	_, err = d.Scan(ctx)
+	assert.ErrorIs(t, err, ErrPassLimit)
+}
+
+func TestCurlDecoder_Errors(t *testing.T) {
+	for input, wantErr := range map[string]string{
+		"curl https://example.com --connect-timeout 5": "line 1: unsupported option --connect-timeout",
+		"\ncurl -d @body.json https://example.com":     "line 2: data from file @body.json is not supported",
+		"wget https://example.com":                     "should start with curl",
+		"curl -H 'Accept: */*":                         "unterminated single quote",
+		"curl -X POST":                                 "no url",
+	} {
+		_, err := newCurlDecoder(strings.NewReader(input), config.Config{}, http.Header{})
+		assert.ErrorContains(t, err, wantErr, input)
+	}
+}
@@ -32,6 +32,23 @@ type Decoder interface {
 	LoadAmmo(context.Context) ([]DecodedAmmo, error)
 }
 
+// Indexer reads ammo by number, so ammo can be sent in any order without keeping all ammo in memory.
+// Streaming decoders keep offsets of records in file, decoders, that read whole file on start, keep ammo.
+// Index and ReadAmmo move file position, so decoder can't Scan after them.
+type Indexer interface {
+	// Index reads whole file and returns number of ammo in it.
+	Index(context.Context) (int, error)
+	// ReadAmmo returns ammo with number i, that is less than Index result.
+	ReadAmmo(i int) (DecodedAmmo, error)
+}
+
+// SkipCounter is decoder, that skips malformed records of file instead of failing, like access log lines
+// of other format.
+type SkipCounter interface {
+	// Skipped returns number of records, that were skipped in the first pass.
+	Skipped() int
+}
+
 type protoDecoder struct {
 	file                 io.ReadSeeker
 	config               config.Config
@@ -63,6 +80,24 @@ func (d *protoDecoder) LoadAmmo(ctx context.Context, scan func(ctx context.Conte
 	return result, err
 }
 
+// scanAmmos returns preloaded ammo one by one, pass by pass.
+func (d *protoDecoder) scanAmmos(ammos []DecodedAmmo) (DecodedAmmo, error) {
+	length := len(ammos)
+	if length == 0 {
+		return nil, ErrNoAmmo
+	}
+	if d.config.Passes != 0 && d.passNum >= d.config.Passes {
+		return nil, ErrPassLimit
+	}
+	i := int(d.ammoNum) % length
+	a := ammos[i]
+	if i == length-1 {
+		d.passNum++
+	}
+	d.ammoNum++
+	return a, nil
+}
+
 func NewDecoder(conf config.Config, file io.ReadSeeker) (d Decoder, err error) {
 	decodedConfigHeaders, err := util.DecodeHTTPConfigHeaders(conf.Headers)
 	if err != nil {
@@ -78,6 +113,14 @@ func NewDecoder(conf config.Config, file io.ReadSeeker) (d Decoder, err error) {
 		d = newURIDecoder(file, conf, decodedConfigHeaders)
 	case config.DecoderURIPost:
 		d = newURIPostDecoder(file, conf, decodedConfigHeaders)
+	case config.DecoderHAR:
+		d, err = newHARDecoder(file, conf, decodedConfigHeaders)
+	case config.DecoderAccessLog:
+		d, err = newAccessLogDecoder(file, conf, decodedConfigHeaders)
+	case config.DecoderCurl:
+		d, err = newCurlDecoder(file, conf, decodedConfigHeaders)
+	case config.DecoderPCAP:
+		d, err = newPCAPDecoder(file, conf, decodedConfigHeaders)
 	default:
 		err = ErrUnknown
 	}
@@ -0,0 +1,78 @@
+package decoders
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/http/config"
+)
+
+func TestProtoDecoder_scanAmmos(t *testing.T) {
+	const singleHAR = `{"log": {"entries": [{"request": {"method": "GET", "url": "http://example.com/", "headers": []}}]}}`
+	tests := []struct {
+		name      string
+		decoder   func(conf config.Config) (Decoder, error)
+		passes    uint
+		wantAmmos int
+	}{
+		{
+			name: "single har entry",
+			decoder: func(conf config.Config) (Decoder, error) {
+				return newHARDecoder(strings.NewReader(singleHAR), conf, http.Header{})
+			},
+			passes:    2,
+			wantAmmos: 2,
+		},
+		{
+			name: "single preloaded jsonline entry",
+			decoder: func(conf config.Config) (Decoder, error) {
+				return newJsonlineDecoder(strings.NewReader(`[{"host": "example.com", "method": "GET", "uri": "/"}]`), conf, http.Header{})
+			},
+			passes:    2,
+			wantAmmos: 2,
+		},
+		{
+			name: "single preloaded jsonline entry one pass",
+			decoder: func(conf config.Config) (Decoder, error) {
+				return newJsonlineDecoder(strings.NewReader(`[{"host": "example.com", "method": "GET", "uri": "/"}]`), conf, http.Header{})
+			},
+			passes:    1,
+			wantAmmos: 1,
+		},
+		{
+			name: "preloaded jsonline entries one pass",
+			decoder: func(conf config.Config) (Decoder, error) {
+				return newJsonlineDecoder(strings.NewReader(`[{"host": "example.com", "method": "GET", "uri": "/1"},
+{"host": "example.com", "method": "GET", "uri": "/2"}]`), conf, http.Header{})
+			},
+			passes:    1,
+			wantAmmos: 2,
+		},
+		{
+			name: "preloaded jsonline entries",
+			decoder: func(conf config.Config) (Decoder, error) {
+				return newJsonlineDecoder(strings.NewReader(`[{"host": "example.com", "method": "GET", "uri": "/1"},
+{"host": "example.com", "method": "GET", "uri": "/2"}, {"host": "example.com", "method": "GET", "uri": "/3"}]`), conf, http.Header{})
+			},
+			passes:    2,
+			wantAmmos: 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := tt.decoder(config.Config{Passes: tt.passes})
+			require.NoError(t, err)
+			ctx := context.Background()
+			for i := 0; i < tt.wantAmmos; i++ {
+				_, err = d.Scan(ctx)
+				require.NoError(t, err, i)
+			}
+			_, err = d.Scan(ctx)
+			assert.ErrorIs(t, err, ErrPassLimit)
+		})
+	}
+}
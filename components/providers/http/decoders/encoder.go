@@ -0,0 +1,346 @@
+package decoders
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yandex/pandora/components/providers/http/config"
+)
+
+// Encoder writes ammo in format of decoder, so it can be read back.
+// Close should be called after last ammo: buffered data is written on close.
+type Encoder interface {
+	Encode(a DecodedAmmo) error
+	Close() error
+}
+
+// NewEncoder returns encoder of decoder format. Formats, that are made by other tools,
+// like access logs or captures, have no encoder.
+func NewEncoder(format config.DecoderType, w io.Writer) (Encoder, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case config.DecoderURI:
+		return &uriEncoder{w: bw, header: map[string]string{}}, nil
+	case config.DecoderURIPost:
+		return &uriEncoder{w: bw, header: map[string]string{}, post: true}, nil
+	case config.DecoderRaw:
+		return &rawEncoder{w: bw}, nil
+	case config.DecoderJSONLine:
+		enc := json.NewEncoder(bw)
+		enc.SetEscapeHTML(false)
+		return &jsonlineEncoder{w: bw, enc: enc}, nil
+	case config.DecoderHAR:
+		return &harEncoder{w: bw}, nil
+	}
+	return nil, fmt.Errorf("encoding to %s is not supported", format)
+}
+
+// encodedRequest is ammo request, that is split to parts, that ammo formats have.
+type encodedRequest struct {
+	method string
+	scheme string
+	host   string
+	// uri is path with query.
+	uri    string
+	header http.Header
+	body   []byte
+	tag    string
+	time   time.Time
+}
+
+func newEncodedRequest(a DecodedAmmo) (encodedRequest, error) {
+	req, err := a.BuildRequest()
+	if err != nil {
+		return encodedRequest{}, err
+	}
+	r := encodedRequest{
+		method: req.Method,
+		scheme: req.URL.Scheme,
+		host:   req.Host,
+		uri:    req.URL.RequestURI(),
+		header: req.Header.Clone(),
+		tag:    a.Tag(),
+	}
+	if r.host == "" {
+		r.host = req.URL.Host
+	}
+	if r.scheme == "" {
+		r.scheme = "http"
+	}
+	// Encoders set Content-Length themselves, if format needs it.
+	r.header.Del("Content-Length")
+	r.header.Del("Host")
+	if req.Body != nil {
+		r.body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return encodedRequest{}, err
+		}
+		if len(r.body) == 0 {
+			r.body = nil
+		}
+	}
+	if ta, ok := a.(TimestampedAmmo); ok {
+		r.time = ta.Timestamp()
+	}
+	return r, nil
+}
+
+// headerValues returns header values, that are joined by comma, including Host. Header names are sorted.
+func (r encodedRequest) headerValues() ([]string, map[string]string) {
+	values := make(map[string]string, len(r.header)+1)
+	for k, vv := range r.header {
+		values[k] = strings.Join(vv, ", ")
+	}
+	if r.host != "" {
+		values["Host"] = r.host
+	}
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names, values
+}
+
+// uriEncoder writes uri or uripost ammo. Headers of these formats are common for all next requests,
+// so header is written only when its value changes. Header can't be unset.
+type uriEncoder struct {
+	w      *bufio.Writer
+	post   bool
+	header map[string]string
+}
+
+func (e *uriEncoder) Encode(a DecodedAmmo) error {
+	r, err := newEncodedRequest(a)
+	if err != nil {
+		return err
+	}
+	switch {
+	case e.post && r.method != http.MethodPost:
+		return fmt.Errorf("uripost format supports only POST requests, got %s %s", r.method, r.uri)
+	case !e.post && (r.method != http.MethodGet || r.body != nil):
+		return fmt.Errorf("uri format supports only GET requests without body, got %s %s", r.method, r.uri)
+	}
+	names, values := r.headerValues()
+	for name := range e.header {
+		if _, ok := values[name]; !ok {
+			return fmt.Errorf("request %s has no header %s of previous requests: headers can't be unset in uri formats", r.uri, name)
+		}
+	}
+	for _, name := range names {
+		if e.header[name] != values[name] {
+			e.header[name] = values[name]
+			fmt.Fprintf(e.w, "[%s: %s]\n", name, values[name])
+		}
+	}
+	line := r.uri
+	if e.post {
+		line = fmt.Sprintf("%d %s", len(r.body), r.uri)
+	}
+	if r.tag != "" {
+		line += " " + r.tag
+	}
+	e.w.WriteString(line + "\n")
+	if e.post {
+		e.w.Write(r.body)
+		e.w.WriteString("\n")
+	}
+	return nil
+}
+
+func (e *uriEncoder) Close() error {
+	return e.w.Flush()
+}
+
+type rawEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *rawEncoder) Encode(a DecodedAmmo) error {
+	r, err := newEncodedRequest(a)
+	if err != nil {
+		return err
+	}
+	var req bytes.Buffer
+	fmt.Fprintf(&req, "%s %s HTTP/1.1\r\n", r.method, r.uri)
+	names, values := r.headerValues()
+	for _, name := range names {
+		fmt.Fprintf(&req, "%s: %s\r\n", name, values[name])
+	}
+	if r.body != nil {
+		fmt.Fprintf(&req, "Content-Length: %d\r\n", len(r.body))
+	}
+	req.WriteString("\r\n")
+	req.Write(r.body)
+
+	fmt.Fprintf(e.w, "%d", req.Len())
+	if r.tag != "" {
+		e.w.WriteString(" " + r.tag)
+	}
+	e.w.WriteString("\n")
+	e.w.Write(req.Bytes())
+	// Decoder reads size line till line end, so the last request should end with it too.
+	e.w.WriteString("\n")
+	return nil
+}
+
+func (e *rawEncoder) Close() error {
+	return e.w.Flush()
+}
+
+type jsonlineEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (e *jsonlineEncoder) Encode(a DecodedAmmo) error {
+	r, err := newEncodedRequest(a)
+	if err != nil {
+		return err
+	}
+	// JSON string can't keep arbitrary bytes, and jsonline body has no encoding field.
+	if !utf8.Valid(r.body) {
+		return fmt.Errorf("request %s body is not valid UTF-8: jsonline format supports only text bodies", r.uri)
+	}
+	headers := make(map[string]string, len(r.header))
+	for k, vv := range r.header {
+		headers[k] = strings.Join(vv, ", ")
+	}
+	return e.enc.Encode(entity{
+		Host:    r.host,
+		Method:  r.method,
+		URI:     r.uri,
+		Headers: headers,
+		Tag:     r.tag,
+		Body:    string(r.body),
+	})
+}
+
+func (e *jsonlineEncoder) Close() error {
+	return e.w.Flush()
+}
+
+// harOutput is HTTP Archive 1.2 document with fields, that spec requires.
+type harOutput struct {
+	Log struct {
+		Version string `json:"version"`
+		Creator struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"creator"`
+		Entries []harOutputEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harOutputEntry struct {
+	StartedDateTime string           `json:"startedDateTime,omitempty"`
+	Comment         string           `json:"comment,omitempty"`
+	Request         harOutputRequest `json:"request"`
+}
+
+type harOutputRequest struct {
+	Method      string             `json:"method"`
+	URL         string             `json:"url"`
+	HTTPVersion string             `json:"httpVersion"`
+	Cookies     []harNameValue     `json:"cookies"`
+	Headers     []harNameValue     `json:"headers"`
+	QueryString []harNameValue     `json:"queryString"`
+	PostData    *harOutputPostData `json:"postData,omitempty"`
+	HeadersSize int                `json:"headersSize"`
+	BodySize    int                `json:"bodySize"`
+}
+
+type harOutputPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// harEncoder keeps entries till Close, because HAR is single JSON document.
+// Tag is written to entry comment, that HAR decoder reads back.
+type harEncoder struct {
+	w       *bufio.Writer
+	entries []harOutputEntry
+}
+
+func (e *harEncoder) Encode(a DecodedAmmo) error {
+	r, err := newEncodedRequest(a)
+	if err != nil {
+		return err
+	}
+	if r.host == "" {
+		return fmt.Errorf("request %s has no host: HAR needs absolute URL, set Host header", r.uri)
+	}
+	req, err := http.NewRequest(r.method, r.scheme+"://"+r.host+r.uri, nil)
+	if err != nil {
+		return err
+	}
+	out := harOutputRequest{
+		Method:      r.method,
+		URL:         req.URL.String(),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     []harNameValue{},
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(r.body),
+	}
+	names, values := r.headerValues()
+	for _, name := range names {
+		out.Headers = append(out.Headers, harNameValue{Name: name, Value: values[name]})
+	}
+	query := req.URL.Query()
+	for _, name := range sortedKeys(query) {
+		for _, value := range query[name] {
+			out.QueryString = append(out.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if r.body != nil {
+		out.PostData = &harOutputPostData{MimeType: r.header.Get("Content-Type"), Text: string(r.body)}
+		if !utf8.Valid(r.body) {
+			out.PostData.Text = base64.StdEncoding.EncodeToString(r.body)
+			out.PostData.Encoding = "base64"
+		}
+	}
+	entry := harOutputEntry{Comment: r.tag, Request: out}
+	if !r.time.IsZero() {
+		entry.StartedDateTime = r.time.Format(time.RFC3339Nano)
+	}
+	e.entries = append(e.entries, entry)
+	return nil
+}
+
+func (e *harEncoder) Close() error {
+	var out harOutput
+	out.Log.Version = "1.2"
+	out.Log.Creator.Name = "pandora"
+	out.Log.Entries = e.entries
+	if out.Log.Entries == nil {
+		out.Log.Entries = []harOutputEntry{}
+	}
+	enc := json.NewEncoder(e.w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
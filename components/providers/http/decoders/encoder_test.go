@@ -0,0 +1,136 @@
+package decoders
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/http/config"
+	"github.com/yandex/pandora/components/providers/http/decoders/ammo"
+)
+
+type encodedTestRequest struct {
+	method, url, body, tag string
+	header                 http.Header
+}
+
+func makeEncoderTestAmmo(t *testing.T, r encodedTestRequest) DecodedAmmo {
+	a := &ammo.Ammo{}
+	var body []byte
+	if r.body != "" {
+		body = []byte(r.body)
+	}
+	require.NoError(t, a.Setup(r.method, r.url, body, r.header.Clone(), r.tag))
+	return a
+}
+
+// readEncoderTestRequest makes request comparable, whether ammo URL is absolute, or host is in Host header.
+func readEncoderTestRequest(t *testing.T, a DecodedAmmo) encodedTestRequest {
+	req, err := a.BuildRequest()
+	require.NoError(t, err)
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		require.NoError(t, err)
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	header := req.Header.Clone()
+	header.Del("Content-Length")
+	return encodedTestRequest{
+		method: req.Method,
+		url:    "http://" + host + req.URL.RequestURI(),
+		body:   string(body),
+		tag:    a.Tag(),
+		header: header,
+	}
+}
+
+func TestEncoder_RoundTrip(t *testing.T) {
+	header := http.Header{"Accept": []string{"*/*"}, "User-Agent": []string{"Pandora"}}
+	postHeader := http.Header{"Content-Type": []string{"application/json"}, "User-Agent": []string{"Pandora"}}
+	get := []encodedTestRequest{
+		{method: "GET", url: "http://shop.example.com/items?page=1&sort=name", tag: "items", header: header},
+		{method: "GET", url: "http://shop.example.com/cart", header: header},
+	}
+	post := []encodedTestRequest{
+		{method: "POST", url: "http://shop.example.com/cart", body: `{"id": 1}`, tag: "add", header: postHeader},
+		{method: "POST", url: "http://shop.example.com/cart", body: "line\nbreak", tag: "add", header: postHeader},
+	}
+	all := append(append([]encodedTestRequest{}, get...), post...)
+	all = append(all, encodedTestRequest{method: "DELETE", url: "http://shop.example.com/cart/1", header: header})
+
+	for format, requests := range map[config.DecoderType][]encodedTestRequest{
+		config.DecoderURI:      get,
+		config.DecoderURIPost:  post,
+		config.DecoderRaw:      all,
+		config.DecoderJSONLine: all,
+		config.DecoderHAR:      all,
+	} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder(format, &buf)
+			require.NoError(t, err)
+			for _, r := range requests {
+				require.NoError(t, enc.Encode(makeEncoderTestAmmo(t, r)))
+			}
+			require.NoError(t, enc.Close())
+
+			d, err := NewDecoder(config.Config{Decoder: format, Passes: 1}, bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			var got []encodedTestRequest
+			for {
+				a, err := d.Scan(context.Background())
+				if err == ErrPassLimit {
+					break
+				}
+				require.NoError(t, err)
+				got = append(got, readEncoderTestRequest(t, a))
+			}
+			assert.Equal(t, requests, got, buf.String())
+		})
+	}
+}
+
+func TestEncoder_HARScheme(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(config.DecoderHAR, &buf)
+	require.NoError(t, err)
+	header := http.Header{"Accept": []string{"*/*"}}
+	require.NoError(t, enc.Encode(makeEncoderTestAmmo(t, encodedTestRequest{method: "GET", url: "https://a.example.com/1?q=1", header: header})))
+	require.NoError(t, enc.Encode(makeEncoderTestAmmo(t, encodedTestRequest{method: "GET", url: "http://a.example.com/2", header: header})))
+	require.NoError(t, enc.Close())
+	assert.Contains(t, buf.String(), `"url": "https://a.example.com/1?q=1"`)
+	assert.Contains(t, buf.String(), `"url": "http://a.example.com/2"`)
+}
+
+func TestEncoder_URI(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(config.DecoderURI, &buf)
+	require.NoError(t, err)
+	header := http.Header{"Accept": []string{"*/*"}}
+	require.NoError(t, enc.Encode(makeEncoderTestAmmo(t, encodedTestRequest{method: "GET", url: "http://a.example.com/1", tag: "one", header: header})))
+	header = http.Header{"Accept": []string{"text/html"}}
+	require.NoError(t, enc.Encode(makeEncoderTestAmmo(t, encodedTestRequest{method: "GET", url: "http://a.example.com/2", header: header})))
+	require.NoError(t, enc.Close())
+	assert.Equal(t, "[Accept: */*]\n[Host: a.example.com]\n/1 one\n[Accept: text/html]\n/2\n", buf.String())
+
+	err = enc.Encode(makeEncoderTestAmmo(t, encodedTestRequest{method: "GET", url: "http://a.example.com/3", header: http.Header{}}))
+	assert.ErrorContains(t, err, "has no header Accept of previous requests")
+	err = enc.Encode(makeEncoderTestAmmo(t, encodedTestRequest{method: "POST", url: "http://a.example.com/4", header: header}))
+	assert.ErrorContains(t, err, "uri format supports only GET requests")
+
+	enc, err = NewEncoder(config.DecoderJSONLine, &buf)
+	require.NoError(t, err)
+	err = enc.Encode(makeEncoderTestAmmo(t, encodedTestRequest{method: "POST", url: "http://a.example.com/5", body: "\xff\xfe", header: header}))
+	assert.ErrorContains(t, err, "body is not valid UTF-8")
+
+	_, err = NewEncoder(config.DecoderPCAP, &buf)
+	assert.ErrorContains(t, err, "encoding to pcap is not supported")
+}
@@ -0,0 +1,216 @@
+package decoders
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/yandex/pandora/components/providers/http/config"
+	"github.com/yandex/pandora/components/providers/http/decoders/ammo"
+	"github.com/yandex/pandora/core"
+)
+
+// har is subset of HTTP Archive 1.2 format, that is needed to make requests.
+// See http://www.softwareishard.com/blog/har-12-spec/
+type har struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request harRequest `json:"request"`
+	// Comment is ammo tag, if no tag pattern matches. Encoder writes tag to it.
+	Comment string `json:"comment"`
+}
+
+type harRequest struct {
+	Method   string         `json:"method"`
+	URL      string         `json:"url"`
+	Headers  []harNameValue `json:"headers"`
+	PostData *harPostData   `json:"postData"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []harNameValue `json:"params"`
+	// Encoding is not in HAR 1.2 spec, but some tools set it to base64 for binary bodies.
+	Encoding string `json:"encoding"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harSkipHeaders are set by client, or by Go HTTP transport.
+var harSkipHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Transfer-Encoding": true,
+	"Keep-Alive":        true,
+	"Upgrade":           true,
+	"Te":                true,
+}
+
+type harTag struct {
+	pattern *regexp.Regexp
+	tag     string
+}
+
+// newHARDecoder reads whole HAR file, because it is single JSON document.
+// Requests with other than http and https schemes, like data: or ws:, are skipped.
+func newHARDecoder(file io.ReadSeeker, cfg config.Config, decodedConfigHeaders http.Header) (*harDecoder, error) {
+	d := &harDecoder{
+		protoDecoder: protoDecoder{
+			file:                 file,
+			config:               cfg,
+			decodedConfigHeaders: decodedConfigHeaders,
+		},
+		include: headerSet(cfg.HAR.IncludeHeaders),
+		exclude: headerSet(cfg.HAR.ExcludeHeaders),
+	}
+	for _, t := range cfg.HAR.Tags {
+		pattern, err := regexp.Compile(t.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid har tag pattern %q: %w", t.Pattern, err)
+		}
+		d.tags = append(d.tags, harTag{pattern: pattern, tag: t.Tag})
+	}
+	var data har
+	if err := json.NewDecoder(file).Decode(&data); err != nil {
+		return nil, fmt.Errorf("har decode failed: %w", err)
+	}
+	for i, entry := range data.Log.Entries {
+		a, err := d.makeAmmo(entry.Request, entry.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("har entry #%d: %w", i+1, err)
+		}
+		if a != nil {
+			d.ammos = append(d.ammos, a)
+		}
+	}
+	return d, nil
+}
+
+type harDecoder struct {
+	protoDecoder
+	include map[string]bool
+	exclude map[string]bool
+	tags    []harTag
+	ammos   []DecodedAmmo
+}
+
+func headerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
+
+func (d *harDecoder) makeAmmo(req harRequest, comment string) (DecodedAmmo, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", req.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, nil
+	}
+	tag := d.tag(req.URL)
+	if tag == "" {
+		tag = comment
+	}
+	for _, rewrite := range d.config.HAR.Hosts {
+		if rewrite.From == "" || rewrite.From == u.Host {
+			u.Host = rewrite.To
+			break
+		}
+	}
+
+	header := d.decodedConfigHeaders.Clone()
+	for _, h := range req.Headers {
+		name := http.CanonicalHeaderKey(h.Name)
+		// HTTP/2 pseudo headers, like :authority.
+		if strings.HasPrefix(name, ":") || harSkipHeaders[name] || d.exclude[name] ||
+			len(d.include) > 0 && !d.include[name] {
+			continue
+		}
+		header.Set(name, h.Value)
+	}
+
+	body, err := harBody(req.PostData)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil && header.Get("Content-Type") == "" && req.PostData.MimeType != "" {
+		header.Set("Content-Type", req.PostData.MimeType)
+	}
+	a := &ammo.Ammo{}
+	err = a.Setup(req.Method, u.String(), body, header, tag)
+	return a, err
+}
+
+func (d *harDecoder) tag(rawURL string) string {
+	for _, t := range d.tags {
+		match := t.pattern.FindStringSubmatchIndex(rawURL)
+		if match != nil {
+			return string(t.pattern.ExpandString(nil, t.tag, rawURL, match))
+		}
+	}
+	return ""
+}
+
+func harBody(postData *harPostData) ([]byte, error) {
+	switch {
+	case postData == nil:
+		return nil, nil
+	case postData.Text != "" && postData.Encoding == "base64":
+		body, err := base64.StdEncoding.DecodeString(postData.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %w", err)
+		}
+		return body, nil
+	case postData.Text != "":
+		return []byte(postData.Text), nil
+	case len(postData.Params) > 0:
+		form := url.Values{}
+		for _, p := range postData.Params {
+			form.Add(p.Name, p.Value)
+		}
+		return []byte(form.Encode()), nil
+	}
+	return nil, nil
+}
+
+func (d *harDecoder) Release(core.Ammo) {}
+
+func (d *harDecoder) LoadAmmo(context.Context) ([]DecodedAmmo, error) {
+	if len(d.ammos) == 0 {
+		return nil, ErrNoAmmo
+	}
+	return d.ammos, nil
+}
+
+func (d *harDecoder) Scan(context.Context) (DecodedAmmo, error) {
+	if d.config.Limit != 0 && d.ammoNum >= d.config.Limit {
+		return nil, ErrAmmoLimit
+	}
+	return d.scanAmmos(d.ammos)
+}
+
+func (d *harDecoder) Index(context.Context) (int, error) {
+	return len(d.ammos), nil
+}
+
+func (d *harDecoder) ReadAmmo(i int) (DecodedAmmo, error) {
+	return d.ammos[i], nil
+}
@@ -0,0 +1,143 @@
+package decoders
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/http/config"
+)
+
+const harDecoderInput = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "WebInspector", "version": "537.36"},
+    "entries": [
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://shop.example.com/api/items/42?full=1",
+          "httpVersion": "http/2.0",
+          "headers": [
+            {"name": ":authority", "value": "shop.example.com"},
+            {"name": "accept", "value": "application/json"},
+            {"name": "cookie", "value": "session=secret"},
+            {"name": "host", "value": "shop.example.com"}
+          ]
+        },
+        "response": {"status": 200}
+      },
+      {
+        "request": {
+          "method": "GET",
+          "url": "data:image/png;base64,iVBORw0KGgo=",
+          "headers": []
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://auth.example.com/login",
+          "headers": [
+            {"name": "Content-Length", "value": "21"}
+          ],
+          "postData": {
+            "mimeType": "application/x-www-form-urlencoded",
+            "params": [{"name": "user", "value": "admin"}, {"name": "pass", "value": "1"}]
+          }
+        }
+      },
+      {
+        "request": {
+          "method": "PUT",
+          "url": "http://shop.example.com/api/cart",
+          "headers": [{"name": "Content-Type", "value": "application/json"}],
+          "postData": {"mimeType": "text/plain", "text": "{\"id\":42}"}
+        }
+      }
+    ]
+  }
+}`
+
+func TestHARDecoder(t *testing.T) {
+	conf := config.Config{
+		Passes: 1,
+		HAR: config.HARConfig{
+			Hosts: []config.HARHostRewrite{
+				{From: "auth.example.com", To: "localhost:8081"},
+				{To: "localhost:8080"},
+			},
+			ExcludeHeaders: []string{"Cookie"},
+			Tags: []config.HARTag{
+				{Pattern: `/api/(\w+)`, Tag: "api_$1"},
+				{Pattern: `/login`, Tag: "login"},
+			},
+		},
+	}
+	headers := http.Header{"User-Agent": []string{"Pandora"}}
+	d, err := newHARDecoder(strings.NewReader(harDecoderInput), conf, headers)
+	require.NoError(t, err)
+
+	type request struct {
+		method, url, tag, body string
+		header                 http.Header
+	}
+	want := []request{
+		{
+			method: "GET",
+			url:    "https://localhost:8080/api/items/42?full=1",
+			tag:    "api_items",
+			header: http.Header{"Accept": []string{"application/json"}, "User-Agent": []string{"Pandora"}},
+		},
+		{
+			method: "POST",
+			url:    "https://localhost:8081/login",
+			tag:    "login",
+			body:   "pass=1&user=admin",
+			header: http.Header{"Content-Type": []string{"application/x-www-form-urlencoded"}, "User-Agent": []string{"Pandora"}},
+		},
+		{
+			method: "PUT",
+			url:    "http://localhost:8080/api/cart",
+			tag:    "api_cart",
+			body:   `{"id":42}`,
+			header: http.Header{"Content-Type": []string{"application/json"}, "User-Agent": []string{"Pandora"}},
+		},
+	}
+	ctx := context.Background()
+	for _, w := range want {
+		a, err := d.Scan(ctx)
+		require.NoError(t, err)
+		req, err := a.BuildRequest()
+		require.NoError(t, err)
+		var body []byte
+		if req.Body != nil {
+			body, err = io.ReadAll(req.Body)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, w, request{
+			method: req.Method,
+			url:    req.URL.String(),
+			tag:    a.Tag(),
+			body:   string(body),
+			header: req.Header,
+		})
+	}
+	_, err = d.Scan(ctx)
+	assert.ErrorIs(t, err, ErrPassLimit)
+
+	conf.HAR = config.HARConfig{IncludeHeaders: []string{"cookie"}}
+	d, err = newHARDecoder(strings.NewReader(harDecoderInput), conf, http.Header{})
+	require.NoError(t, err)
+	ammos, err := d.LoadAmmo(ctx)
+	require.NoError(t, err)
+	require.Len(t, ammos, 3)
+	req, err := ammos[0].BuildRequest()
+	require.NoError(t, err)
+	assert.Equal(t, http.Header{"Cookie": []string{"session=secret"}}, req.Header)
+	assert.Equal(t, "shop.example.com", req.Host)
+}
@@ -0,0 +1,89 @@
+package decoders
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// fileIndex keeps offsets of ammo records in file of streaming decoder.
+type fileIndex struct {
+	offsets []int64
+	reader  *bufio.Reader
+}
+
+// build reads file by lines. Record reports, whether line starts ammo record,
+// and number of bytes after line, that belong to the record, like request body.
+func (ix *fileIndex) build(ctx context.Context, file io.ReadSeeker, record func(line string) (ok bool, skip int, err error)) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(file)
+	ix.offsets = ix.offsets[:0]
+	var offset int64
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("index read failed at position %d: %w", offset, err)
+		}
+		ok, skip, err := record(line)
+		if err != nil {
+			return fmt.Errorf("index at position %d `%s` error: %w", offset, strings.TrimSpace(line), err)
+		}
+		if ok {
+			ix.offsets = append(ix.offsets, offset)
+		}
+		offset += int64(len(line))
+		if skip > 0 {
+			n, err := r.Discard(skip)
+			if err != nil {
+				return fmt.Errorf("index at position %d: tried to skip %d bytes of record, have skipped %d: %w", offset, skip, n, err)
+			}
+			offset += int64(n)
+		}
+	}
+}
+
+// seek returns reader, that is positioned at the record i.
+func (ix *fileIndex) seek(file io.ReadSeeker, i int) (*bufio.Reader, error) {
+	if i < 0 || i >= len(ix.offsets) {
+		return nil, fmt.Errorf("ammo %d is out of index of %d ammo", i, len(ix.offsets))
+	}
+	if _, err := file.Seek(ix.offsets[i], io.SeekStart); err != nil {
+		return nil, err
+	}
+	if ix.reader == nil {
+		ix.reader = bufio.NewReader(file)
+	} else {
+		ix.reader.Reset(file)
+	}
+	return ix.reader, nil
+}
+
+// readRecordLine reads line of record without line end.
+func readRecordLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+var (
+	_ Indexer = (*uriDecoder)(nil)
+	_ Indexer = (*uripostDecoder)(nil)
+	_ Indexer = (*rawDecoder)(nil)
+	_ Indexer = (*jsonlineDecoder)(nil)
+	_ Indexer = (*accessLogDecoder)(nil)
+	_ Indexer = (*harDecoder)(nil)
+	_ Indexer = (*curlDecoder)(nil)
+	_ Indexer = (*pcapDecoder)(nil)
+)
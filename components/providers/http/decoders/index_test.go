@@ -0,0 +1,97 @@
+package decoders
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/http/config"
+)
+
+func TestIndexer_ReadAmmo(t *testing.T) {
+	header := http.Header{"Accept": []string{"*/*"}}
+	postHeader := http.Header{"Content-Type": []string{"text/plain"}}
+	get := []encodedTestRequest{
+		{method: "GET", url: "http://a.example.com/1", tag: "one", header: header},
+		{method: "GET", url: "http://a.example.com/2", header: http.Header{"Accept": []string{"text/html"}}},
+		{method: "GET", url: "http://b.example.com/3", tag: "three", header: http.Header{"Accept": []string{"text/html"}}},
+	}
+	post := []encodedTestRequest{
+		{method: "POST", url: "http://a.example.com/1", body: "first\nbody", tag: "one", header: postHeader},
+		{method: "POST", url: "http://b.example.com/2", body: "second", header: postHeader},
+	}
+	files := map[config.DecoderType][]byte{}
+	for format, requests := range map[config.DecoderType][]encodedTestRequest{
+		config.DecoderURI:      get,
+		config.DecoderURIPost:  post,
+		config.DecoderRaw:      append(append([]encodedTestRequest{}, get...), post...),
+		config.DecoderJSONLine: append(append([]encodedTestRequest{}, post...), get...),
+		config.DecoderHAR:      get,
+	} {
+		var buf bytes.Buffer
+		enc, err := NewEncoder(format, &buf)
+		require.NoError(t, err)
+		for _, r := range requests {
+			require.NoError(t, enc.Encode(makeEncoderTestAmmo(t, r)))
+		}
+		require.NoError(t, enc.Close())
+		files[format] = buf.Bytes()
+	}
+	files[config.DecoderAccessLog] = []byte(`10.0.0.1 - - [19/Oct/2026:12:00:00 +0300] "GET /1 HTTP/1.1" 200 10 "-" "curl"
+10.0.0.1 - - [19/Oct/2026:12:00:01 +0300] "POST /form HTTP/1.1" 200 10 "-" "curl"
+
+10.0.0.1 - - [19/Oct/2026:12:00:02 +0300] "GET /2 HTTP/1.1" 404 10 "-" "curl"`)
+
+	for format, file := range files {
+		t.Run(string(format), func(t *testing.T) {
+			conf := config.Config{Decoder: format, Passes: 1}
+			d, err := NewDecoder(conf, bytes.NewReader(file))
+			require.NoError(t, err)
+			var want []encodedTestRequest
+			for {
+				a, err := d.Scan(context.Background())
+				if err == ErrPassLimit {
+					break
+				}
+				require.NoError(t, err)
+				want = append(want, readEncoderTestRequest(t, a))
+			}
+
+			d, err = NewDecoder(conf, bytes.NewReader(file))
+			require.NoError(t, err)
+			indexer := d.(Indexer)
+			n, err := indexer.Index(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, len(want), n)
+			for i := n - 1; i >= 0; i-- {
+				a, err := indexer.ReadAmmo(i)
+				require.NoError(t, err)
+				assert.Equal(t, want[i], readEncoderTestRequest(t, a), i)
+			}
+		})
+	}
+}
+
+func TestIndexer_Errors(t *testing.T) {
+	for format, input := range map[config.DecoderType]string{
+		config.DecoderURI:      "/1\n[Broken header\n/2\n",
+		config.DecoderURIPost:  "5 /1\nfirst\n10 /2\nshort\n",
+		config.DecoderRaw:      "wrong size\nGET / HTTP/1.1\r\n\r\n",
+		config.DecoderJSONLine: "{\"uri\": \"/1\"}\n{\"uri\": \n\"/2\"}\n",
+	} {
+		d, err := NewDecoder(config.Config{Decoder: format}, strings.NewReader(input))
+		require.NoError(t, err, format)
+		_, err = d.(Indexer).Index(context.Background())
+		assert.Error(t, err, format)
+	}
+
+	d := newURIDecoder(strings.NewReader("/1\n[Broken header\n"), config.Config{}, http.Header{})
+	_, err := d.Index(context.Background())
+	assert.ErrorContains(t, err, "index at position 3 `[Broken header` error")
+	_, err = d.ReadAmmo(1)
+	assert.ErrorContains(t, err, "ammo 1 is out of index of 1 ammo")
+}
@@ -2,12 +2,14 @@ package decoders
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/yandex/pandora/components/providers/http/config"
@@ -36,8 +38,9 @@ func newJsonlineDecoder(file io.ReadSeeker, cfg config.Config, decodedConfigHead
 		},
 		scanner: scanner,
 		pool:    &sync.Pool{New: func() any { return &ammo.Ammo{} }},
-		decoder: json.NewDecoder(file),
+		lines:   &lineCountingReader{r: file},
 	}
+	decoder.decoder = json.NewDecoder(decoder.lines)
 	if isArray {
 		ammos, err := decoder.readArray()
 		if err != nil {
@@ -65,10 +68,11 @@ func isArray(r io.ReadSeeker) (bool, error) {
 type jsonlineDecoder struct {
 	protoDecoder
 	scanner *bufio.Scanner
-	line    uint
+	lines   *lineCountingReader
 	pool    *sync.Pool
 	decoder *json.Decoder
 	ammos   []DecodedAmmo
+	index   fileIndex
 }
 
 func (d *jsonlineDecoder) Release(a core.Ammo) {
@@ -96,12 +100,27 @@ type entity struct {
 	Body string `json:"body"`
 }
 
+func (d *jsonlineDecoder) makeAmmo(da entity) (DecodedAmmo, error) {
+	header := d.decodedConfigHeaders.Clone()
+	for k, v := range da.Headers {
+		header.Set(k, v)
+	}
+	url := "http://" + da.Host + da.URI // schema will be rewrite in gun
+	var body []byte
+	if da.Body != "" {
+		body = []byte(da.Body)
+	}
+	a := d.pool.Get().(*ammo.Ammo)
+	err := a.Setup(da.Method, url, body, header, da.Tag)
+	return a, err
+}
+
 func (d *jsonlineDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
 	if d.config.Limit != 0 && d.ammoNum >= d.config.Limit {
 		return nil, ErrAmmoLimit
 	}
 	if d.ammos != nil {
-		return d.scanAmmos()
+		return d.scanAmmos(d.ammos)
 	}
 	for {
 		if d.config.Passes != 0 && d.passNum >= d.config.Passes {
@@ -111,25 +130,12 @@ func (d *jsonlineDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
 		err := d.decoder.Decode(&da)
 		if err != nil {
 			if err != io.EOF {
-				return nil, xerrors.Errorf("failed to decode ammo at line: %v; with err: %w", d.line+1, err)
+				return nil, xerrors.Errorf("failed to decode ammo at line: %v; with err: %w", d.errorLine(err), err)
 			}
 			// go to next pass
 		} else {
-			d.line++
 			d.ammoNum++
-
-			header := d.decodedConfigHeaders.Clone()
-			for k, v := range da.Headers {
-				header.Set(k, v)
-			}
-			url := "http://" + da.Host + da.URI // schema will be rewrite in gun
-			var body []byte
-			if da.Body != "" {
-				body = []byte(da.Body)
-			}
-			a := d.pool.Get().(*ammo.Ammo)
-			err = a.Setup(da.Method, url, body, header, da.Tag)
-			return a, err
+			return d.makeAmmo(da)
 		}
 
 		err = d.scanner.Err()
@@ -139,17 +145,41 @@ func (d *jsonlineDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
 		if d.ammoNum == 0 {
 			return nil, ErrNoAmmo
 		}
-		d.line = 0
 		d.passNum++
 
 		_, err = d.file.Seek(0, io.SeekStart)
 		if err != nil {
 			return nil, err
 		}
-		d.decoder = json.NewDecoder(d.file)
+		d.lines.lines = 0
+		d.decoder = json.NewDecoder(d.lines)
 	}
 }
 
+// errorLine returns line of value, that decoder failed to decode. Decoder reads ahead, so lines of
+// buffered, but not decoded data are subtracted. After syntax error buffered data starts with whitespace
+// before failed value, and after other errors it starts right after failed value.
+func (d *jsonlineDecoder) errorLine(err error) int {
+	buffered, _ := io.ReadAll(d.decoder.Buffered())
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		buffered = bytes.TrimLeft(buffered, " \t\r\n")
+	}
+	return 1 + d.lines.lines - bytes.Count(buffered, []byte("\n"))
+}
+
+// lineCountingReader counts lines, that are read from r.
+type lineCountingReader struct {
+	r     io.Reader
+	lines int
+}
+
+func (r *lineCountingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.lines += bytes.Count(p[:n], []byte("\n"))
+	return n, err
+}
+
 func (d *jsonlineDecoder) readArray() ([]DecodedAmmo, error) {
 	var data []entity
 	err := d.decoder.Decode(&data)
@@ -158,17 +188,7 @@ func (d *jsonlineDecoder) readArray() ([]DecodedAmmo, error) {
 	}
 	result := make([]DecodedAmmo, len(data))
 	for i, datum := range data {
-		header := d.decodedConfigHeaders.Clone()
-		for k, v := range datum.Headers {
-			header.Set(k, v)
-		}
-		url := "http://" + datum.Host + datum.URI // schema will be rewrite in gun
-		var body []byte
-		if datum.Body != "" {
-			body = []byte(datum.Body)
-		}
-		a := d.pool.Get().(*ammo.Ammo)
-		err = a.Setup(datum.Method, url, body, header, datum.Tag)
+		a, err := d.makeAmmo(datum)
 		if err != nil {
 			return nil, fmt.Errorf("cant readArray, err: %w", err)
 		}
@@ -178,19 +198,39 @@ func (d *jsonlineDecoder) readArray() ([]DecodedAmmo, error) {
 	return result, nil
 }
 
-func (d *jsonlineDecoder) scanAmmos() (DecodedAmmo, error) {
-	length := len(d.ammos)
-	if length == 0 {
-		return nil, ErrNoAmmo
+// Index of jsonline file expects ammo on separate lines. Array ammo is indexed in memory.
+func (d *jsonlineDecoder) Index(ctx context.Context) (int, error) {
+	if d.ammos != nil {
+		return len(d.ammos), nil
+	}
+	err := d.index.build(ctx, d.file, func(line string) (bool, int, error) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return false, 0, nil
+		}
+		if !json.Valid([]byte(line)) {
+			return false, 0, errors.New("invalid json: index needs ammo on separate lines")
+		}
+		return true, 0, nil
+	})
+	return len(d.index.offsets), err
+}
+
+func (d *jsonlineDecoder) ReadAmmo(i int) (DecodedAmmo, error) {
+	if d.ammos != nil {
+		return d.ammos[i], nil
 	}
-	if d.config.Passes != 0 && d.passNum >= d.config.Passes {
-		return nil, ErrPassLimit
+	r, err := d.index.seek(d.file, i)
+	if err != nil {
+		return nil, err
 	}
-	i := int(d.ammoNum) % length
-	a := d.ammos[i]
-	if d.ammoNum > 0 && i == length-1 {
-		d.passNum++
+	data, err := readRecordLine(r)
+	if err != nil {
+		return nil, err
+	}
+	var da entity
+	if err := json.Unmarshal([]byte(data), &da); err != nil {
+		return nil, fmt.Errorf("failed to decode ammo %d: %w", i, err)
 	}
-	d.ammoNum++
-	return a, nil
+	return d.makeAmmo(da)
 }
@@ -0,0 +1,160 @@
+package decoders
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/yandex/pandora/components/providers/http/config"
+	"github.com/yandex/pandora/components/providers/http/decoders/ammo"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/lib/pcap"
+)
+
+// pcapDroppedHeaders are hop-by-hop headers and headers, that HTTP client sets itself.
+var pcapDroppedHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Transfer-Encoding",
+	"Te",
+	"Trailer",
+	"Upgrade",
+	"Content-Length",
+}
+
+// newPCAPDecoder reads whole capture, because TCP streams should be reassembled before requests parse.
+// HTTP/1.x requests of all client streams are sorted by capture time of their first byte.
+func newPCAPDecoder(file io.ReadSeeker, cfg config.Config, decodedConfigHeaders http.Header) (*pcapDecoder, error) {
+	d := &pcapDecoder{
+		protoDecoder: protoDecoder{
+			file:                 file,
+			config:               cfg,
+			decodedConfigHeaders: decodedConfigHeaders,
+		},
+	}
+	r, err := pcap.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	streams, err := pcap.ReadStreams(r)
+	if err != nil {
+		return nil, err
+	}
+	ports := map[int]bool{}
+	for _, p := range cfg.PCAP.Ports {
+		ports[p] = true
+	}
+	var ammos []*ammo.Ammo
+	for _, s := range streams {
+		if len(ports) > 0 && !ports[int(s.Dst.Port())] {
+			continue
+		}
+		if !isHTTP1Request(s.Data) {
+			continue
+		}
+		ammos = append(ammos, d.readStream(s)...)
+	}
+	sort.SliceStable(ammos, func(i, j int) bool { return ammos[i].Timestamp().Before(ammos[j].Timestamp()) })
+	for _, a := range ammos {
+		d.ammos = append(d.ammos, a)
+	}
+	return d, nil
+}
+
+type pcapDecoder struct {
+	protoDecoder
+	ammos []DecodedAmmo
+}
+
+// readStream parses keep-alive requests of client stream one by one.
+// Stream is read till first incomplete or malformed request: capture could be stopped in the middle of request,
+// or connection could be upgraded to other protocol, like WebSocket.
+func (d *pcapDecoder) readStream(s *pcap.Stream) []*ammo.Ammo {
+	data := bytes.NewReader(s.Data)
+	br := bufio.NewReader(data)
+	var ammos []*ammo.Ammo
+	for {
+		offset := len(s.Data) - data.Len() - br.Buffered()
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return ammos
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return ammos
+		}
+		if req.Method == http.MethodConnect {
+			return ammos
+		}
+		a, err := d.makeAmmo(req, body)
+		if err != nil {
+			return ammos
+		}
+		a.SetTimestamp(s.TimeAt(offset))
+		ammos = append(ammos, a)
+	}
+}
+
+func (d *pcapDecoder) makeAmmo(req *http.Request, body []byte) (*ammo.Ammo, error) {
+	header := d.decodedConfigHeaders.Clone()
+	for key, values := range req.Header {
+		header[key] = values
+	}
+	for _, key := range pcapDroppedHeaders {
+		header.Del(key)
+	}
+	url := req.RequestURI
+	if !strings.Contains(url, "://") {
+		// Origin form, like /path?query. Proxy requests have absolute form.
+		url = "http://" + req.Host + url
+	}
+	if len(body) == 0 {
+		body = nil
+	}
+	a := &ammo.Ammo{}
+	err := a.Setup(req.Method, url, body, header, "")
+	return a, err
+}
+
+// isHTTP1Request checks, that data starts with HTTP/1.x request line, like "GET / HTTP/1.1".
+// It filters out server responses, TLS and other protocols.
+func isHTTP1Request(data []byte) bool {
+	line, _, ok := bytes.Cut(data, []byte("\r\n"))
+	if !ok {
+		return false
+	}
+	method, rest, ok := bytes.Cut(line, []byte(" "))
+	if !ok || len(method) == 0 || bytes.IndexFunc(method, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+		return false
+	}
+	return bytes.HasPrefix(rest[bytes.LastIndexByte(rest, ' ')+1:], []byte("HTTP/1."))
+}
+
+func (d *pcapDecoder) Release(core.Ammo) {}
+
+func (d *pcapDecoder) LoadAmmo(context.Context) ([]DecodedAmmo, error) {
+	if len(d.ammos) == 0 {
+		return nil, ErrNoAmmo
+	}
+	return d.ammos, nil
+}
+
+func (d *pcapDecoder) Scan(context.Context) (DecodedAmmo, error) {
+	if d.config.Limit != 0 && d.ammoNum >= d.config.Limit {
+		return nil, ErrAmmoLimit
+	}
+	return d.scanAmmos(d.ammos)
+}
+
+func (d *pcapDecoder) Index(context.Context) (int, error) {
+	return len(d.ammos), nil
+}
+
+func (d *pcapDecoder) ReadAmmo(i int) (DecodedAmmo, error) {
+	return d.ammos[i], nil
+}
@@ -0,0 +1,146 @@
+package decoders
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/http/config"
+	"github.com/yandex/pandora/lib/pcap"
+)
+
+type pcapTestSegment struct {
+	time     time.Time
+	src, dst string
+	seq      uint32
+	payload  string
+}
+
+// makePcap writes classic pcap with raw IPv4 packets. Connections have no handshake, like capture started later.
+func makePcap(segments []pcapTestSegment) []byte {
+	buf := binary.LittleEndian.AppendUint32(nil, 0xa1b2c3d4)
+	buf = binary.LittleEndian.AppendUint16(buf, 2)
+	buf = binary.LittleEndian.AppendUint16(buf, 4)
+	buf = append(buf, make([]byte, 12)...)
+	buf = binary.LittleEndian.AppendUint32(buf, pcap.LinkTypeRaw)
+	for _, s := range segments {
+		src, dst := netip.MustParseAddrPort(s.src), netip.MustParseAddrPort(s.dst)
+		packet := make([]byte, 40, 40+len(s.payload))
+		packet[0] = 0x45
+		binary.BigEndian.PutUint16(packet[2:4], uint16(40+len(s.payload)))
+		packet[9] = 6
+		srcIP, dstIP := src.Addr().As4(), dst.Addr().As4()
+		copy(packet[12:16], srcIP[:])
+		copy(packet[16:20], dstIP[:])
+		binary.BigEndian.PutUint16(packet[20:22], src.Port())
+		binary.BigEndian.PutUint16(packet[22:24], dst.Port())
+		binary.BigEndian.PutUint32(packet[24:28], s.seq)
+		packet[32] = 5 << 4
+		packet = append(packet, s.payload...)
+
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(s.time.Unix()))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(s.time.Nanosecond()/1000))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(packet)))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(packet)))
+		buf = append(buf, packet...)
+	}
+	return buf
+}
+
+func TestPCAPDecoder(t *testing.T) {
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	const (
+		client1 = "10.0.0.1:40001"
+		client2 = "10.0.0.1:40002"
+		server  = "10.0.0.2:80"
+		metrics = "10.0.0.3:9090"
+	)
+	capture := makePcap([]pcapTestSegment{
+		{at(0), client1, server, 1, "GET /items?page=1 HTTP/1.1\r\nHost: shop.example.com\r\nConnection: keep-alive\r\nAccept: */*\r\n\r\n"},
+		{at(5), server, client1, 1, "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"},
+		{at(10), client2, server, 1, "GET /cart HTTP/1.1\r\nHost: shop.example.com\r\n\r\n"},
+		{at(20), client1, server, 92, "POST /cart HTTP/1.1\r\nHost: shop.example.com\r\nTransfer-Encoding: chunked\r\n" +
+			"Content-Type: application/json\r\n\r\n4\r\n{\"id\r\n"},
+		{at(21), client1, server, 208, "4\r\n\":1}\r\n0\r\n\r\n"},
+		{at(30), client2, metrics, 1, "GET /metrics HTTP/1.1\r\nHost: monitoring\r\n\r\n"},
+		// Capture stopped in the middle of request.
+		{at(40), client2, server, 47, "GET /truncated HTTP/1.1\r\nHost: shop"},
+	})
+
+	type request struct {
+		method, url, body string
+		header            http.Header
+		time              time.Time
+	}
+	read := func(conf config.Config) []request {
+		d, err := newPCAPDecoder(bytes.NewReader(capture), conf, http.Header{"User-Agent": []string{"Pandora"}})
+		require.NoError(t, err)
+		var got []request
+		for {
+			a, err := d.Scan(context.Background())
+			if err == ErrPassLimit {
+				return got
+			}
+			require.NoError(t, err)
+			req, err := a.BuildRequest()
+			require.NoError(t, err)
+			var body []byte
+			if req.Body != nil {
+				body, err = io.ReadAll(req.Body)
+				require.NoError(t, err)
+			}
+			ts := a.(TimestampedAmmo).Timestamp().UTC()
+			got = append(got, request{method: req.Method, url: req.URL.String(), body: string(body), header: req.Header, time: ts})
+		}
+	}
+
+	assert.Equal(t, []request{
+		{
+			method: "GET",
+			url:    "http://shop.example.com/items?page=1",
+			header: http.Header{"Accept": []string{"*/*"}, "User-Agent": []string{"Pandora"}},
+			time:   at(0),
+		},
+		{
+			method: "GET",
+			url:    "http://shop.example.com/cart",
+			header: http.Header{"User-Agent": []string{"Pandora"}},
+			time:   at(10),
+		},
+		{
+			method: "POST",
+			url:    "http://shop.example.com/cart",
+			body:   `{"id":1}`,
+			header: http.Header{"Content-Type": []string{"application/json"}, "User-Agent": []string{"Pandora"}},
+			time:   at(20),
+		},
+		{
+			method: "GET",
+			url:    "http://monitoring/metrics",
+			header: http.Header{"User-Agent": []string{"Pandora"}},
+			time:   at(30),
+		},
+	}, read(config.Config{Passes: 1}))
+
+	got := read(config.Config{Passes: 1, PCAP: config.PCAPConfig{Ports: []int{80}}})
+	require.Len(t, got, 3)
+	assert.Equal(t, "http://shop.example.com/cart", got[2].url)
+}
+
+func TestPCAPDecoder_Errors(t *testing.T) {
+	_, err := newPCAPDecoder(bytes.NewReader([]byte("GET / HTTP/1.1\r\n\r\n")), config.Config{}, http.Header{})
+	assert.ErrorContains(t, err, "unknown capture format")
+
+	d, err := newPCAPDecoder(bytes.NewReader(makePcap(nil)), config.Config{}, http.Header{})
+	require.NoError(t, err)
+	_, err = d.LoadAmmo(context.Background())
+	assert.ErrorIs(t, err, ErrNoAmmo)
+}
@@ -2,6 +2,7 @@ package decoders
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"io"
 	"net/http"
@@ -59,7 +60,9 @@ User-Agent: xxx (shell 1)
 type rawDecoder struct {
 	protoDecoder
 	reader *bufio.Reader
+	line   uint
 	pool   *sync.Pool
+	index  fileIndex
 }
 
 func (d *rawDecoder) LoadAmmo(ctx context.Context) ([]DecodedAmmo, error) {
@@ -99,8 +102,10 @@ func (d *rawDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
 				return nil, err
 			}
 			d.reader.Reset(d.file)
+			d.line = 0
 			continue
 		}
+		d.line++
 		position := filePosition(d.file)
 		if err != nil {
 			return nil, xerrors.Errorf("reading ammo failed with err: %w, at position: %v", err, position)
@@ -112,20 +117,56 @@ func (d *rawDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
 		d.ammoNum++
 		reqSize, tag, err := raw.DecodeHeader(data)
 		if err != nil {
-			return nil, xerrors.Errorf("header decoding error for ammoNum %d: %w", d.ammoNum, err)
+			return nil, xerrors.Errorf("header decoding error at line %d: %w", d.line, err)
 		}
 
-		a := d.pool.Get().(*ammo.RawAmmo)
-		if reqSize != 0 {
-			buff := make([]byte, reqSize)
-			if n, err := io.ReadFull(d.reader, buff); err != nil {
-				return nil, xerrors.Errorf("failed to read ammo with err: %w, at position: %v; tried to read: %v; have read: %v", err, position, reqSize, n)
-			}
+		return d.readRequest(d.reader, reqSize, tag, position)
+	}
+}
+
+func (d *rawDecoder) readRequest(reader *bufio.Reader, reqSize int, tag string, position int64) (DecodedAmmo, error) {
+	a := d.pool.Get().(*ammo.RawAmmo)
+	if reqSize != 0 {
+		buff := make([]byte, reqSize)
+		if n, err := io.ReadFull(reader, buff); err != nil {
+			return nil, xerrors.Errorf("failed to read ammo of line %d with err: %w, at position: %v; tried to read: %v; have read: %v", d.line, err, position, reqSize, n)
+		}
+		d.line += uint(bytes.Count(buff, []byte("\n")))
+
+		a.Setup(buff, tag, position, d.decodedConfigHeaders)
+	} else {
+		a.Setup(nil, "", position, d.decodedConfigHeaders)
+	}
+	return a, nil
+}
 
-			a.Setup(buff, tag, position, d.decodedConfigHeaders)
-		} else {
-			a.Setup(nil, "", position, d.decodedConfigHeaders)
+func (d *rawDecoder) Index(ctx context.Context) (int, error) {
+	err := d.index.build(ctx, d.file, func(line string) (bool, int, error) {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			return false, 0, nil
+		}
+		reqSize, _, err := raw.DecodeHeader(line)
+		if err != nil {
+			return false, 0, err
 		}
-		return a, nil
+		return true, reqSize, nil
+	})
+	return len(d.index.offsets), err
+}
+
+func (d *rawDecoder) ReadAmmo(i int) (DecodedAmmo, error) {
+	r, err := d.index.seek(d.file, i)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readRecordLine(r)
+	if err != nil {
+		return nil, err
+	}
+	reqSize, tag, err := raw.DecodeHeader(strings.TrimSpace(data))
+	if err != nil {
+		return nil, xerrors.Errorf("header decoding error for ammo %d: %w", i, err)
 	}
+	return d.readRequest(r, reqSize, tag, d.index.offsets[i])
 }
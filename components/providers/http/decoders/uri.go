@@ -35,6 +35,10 @@ type uriDecoder struct {
 	Header  http.Header
 	line    uint
 	pool    *sync.Pool
+
+	index fileIndex
+	// indexHeaders are common headers of indexed ammo. Ammo with the same headers share them.
+	indexHeaders []http.Header
 }
 
 func (d *uriDecoder) readLine(data string, commonHeader http.Header) (DecodedAmmo, error) {
@@ -74,7 +78,7 @@ func (d *uriDecoder) readLine(data string, commonHeader http.Header) (DecodedAmm
 func (d *uriDecoder) Release(a core.Ammo) {
 	if am, ok := a.(*ammo.Ammo); ok {
 		am.Reset()
-		d.pool.Put(*am)
+		d.pool.Put(am)
 	}
 }
 
@@ -86,7 +90,7 @@ func (d *uriDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
 	if d.config.Limit != 0 && d.ammoNum >= d.config.Limit {
 		return nil, ErrAmmoLimit
 	}
-	for ; ; d.line++ {
+	for {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
@@ -110,10 +114,11 @@ func (d *uriDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
 			}
 			return nil, d.scanner.Err()
 		}
+		d.line++
 		data := d.scanner.Text()
 		a, err := d.readLine(data, d.Header)
 		if err != nil {
-			return nil, fmt.Errorf("decode at line %d `%s` error: %w", d.line+1, data, err)
+			return nil, fmt.Errorf("decode at line %d `%s` error: %w", d.line, data, err)
 		}
 		if a != nil {
 			d.ammoNum++
@@ -121,3 +126,47 @@ func (d *uriDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
 		}
 	}
 }
+
+func (d *uriDecoder) Index(ctx context.Context) (int, error) {
+	header := http.Header{}
+	d.indexHeaders = d.indexHeaders[:0]
+	err := d.index.build(ctx, d.file, func(line string) (bool, int, error) {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			return false, 0, nil
+		}
+		if line[0] == '[' {
+			key, val, err := util.DecodeHeader(line)
+			if err != nil {
+				return false, 0, fmt.Errorf("decoding header error: %w", err)
+			}
+			// Header is copied, because indexed ammo keeps previous one.
+			header = header.Clone()
+			header.Set(key, val)
+			return false, 0, nil
+		}
+		rawURL, _, _ := strings.Cut(line, " ")
+		if _, err := url.Parse(rawURL); err != nil {
+			return false, 0, err
+		}
+		d.indexHeaders = append(d.indexHeaders, header)
+		return true, 0, nil
+	})
+	return len(d.index.offsets), err
+}
+
+func (d *uriDecoder) ReadAmmo(i int) (DecodedAmmo, error) {
+	r, err := d.index.seek(d.file, i)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readRecordLine(r)
+	if err != nil {
+		return nil, err
+	}
+	a, err := d.readLine(data, d.indexHeaders[i])
+	if err != nil {
+		return nil, fmt.Errorf("decode ammo %d `%s` error: %w", i, data, err)
+	}
+	return a, nil
+}
@@ -2,8 +2,10 @@ package decoders
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -37,6 +39,10 @@ type uripostDecoder struct {
 	header http.Header
 	line   uint
 	pool   *sync.Pool
+
+	index fileIndex
+	// indexHeaders are common headers of indexed ammo. Ammo with the same headers share them.
+	indexHeaders []http.Header
 }
 
 func (d *uripostDecoder) Release(a core.Ammo) {
@@ -83,6 +89,7 @@ func (d *uripostDecoder) Scan(ctx context.Context) (DecodedAmmo, error) {
 			return nil, ErrNoAmmo
 		}
 		d.header = make(http.Header)
+		d.line = 0
 		_, err := d.file.Seek(0, io.SeekStart)
 		if err != nil {
 			return nil, err
@@ -99,6 +106,7 @@ func (d *uripostDecoder) readBlock(reader *bufio.Reader, commonHeader http.Heade
 	if err != nil {
 		return nil, err
 	}
+	d.line++
 	data = strings.TrimSpace(data)
 	if len(data) == 0 {
 		return nil, nil // skip empty lines
@@ -106,7 +114,7 @@ func (d *uripostDecoder) readBlock(reader *bufio.Reader, commonHeader http.Heade
 	if data[0] == '[' {
 		key, val, err := util.DecodeHeader(data)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("decode at line %d `%s` error: %w", d.line, data, err)
 		}
 		commonHeader.Set(key, val)
 		return nil, nil
@@ -114,19 +122,20 @@ func (d *uripostDecoder) readBlock(reader *bufio.Reader, commonHeader http.Heade
 
 	bodySize, uri, tag, err := uripost.DecodeURI(data)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("decode at line %d `%s` error: %w", d.line, data, err)
 	}
 	_, err = url.Parse(uri)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("decode at line %d `%s` error: %w", d.line, data, err)
 	}
 
 	buff := make([]byte, bodySize)
 	if bodySize != 0 {
 		if n, err := io.ReadFull(reader, buff); err != nil {
-			err = xerrors.Errorf("failed to read ammo with err: %w, at position: %v; tried to read: %v; have read: %v", err, filePosition(d.file), bodySize, n)
+			err = xerrors.Errorf("failed to read ammo of line %d with err: %w, at position: %v; tried to read: %v; have read: %v", d.line, err, filePosition(d.file), bodySize, n)
 			return nil, err
 		}
+		d.line += uint(bytes.Count(buff, []byte("\n")))
 	}
 
 	header := commonHeader.Clone()
@@ -139,3 +148,46 @@ func (d *uripostDecoder) readBlock(reader *bufio.Reader, commonHeader http.Heade
 	err = a.Setup("POST", uri, buff, header, tag)
 	return a, err
 }
+
+func (d *uripostDecoder) Index(ctx context.Context) (int, error) {
+	header := http.Header{}
+	d.indexHeaders = d.indexHeaders[:0]
+	err := d.index.build(ctx, d.file, func(line string) (bool, int, error) {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			return false, 0, nil
+		}
+		if line[0] == '[' {
+			key, val, err := util.DecodeHeader(line)
+			if err != nil {
+				return false, 0, err
+			}
+			// Header is copied, because indexed ammo keeps previous one.
+			header = header.Clone()
+			header.Set(key, val)
+			return false, 0, nil
+		}
+		bodySize, uri, _, err := uripost.DecodeURI(line)
+		if err != nil {
+			return false, 0, err
+		}
+		if _, err := url.Parse(uri); err != nil {
+			return false, 0, err
+		}
+		d.indexHeaders = append(d.indexHeaders, header)
+		return true, bodySize, nil
+	})
+	return len(d.index.offsets), err
+}
+
+func (d *uripostDecoder) ReadAmmo(i int) (DecodedAmmo, error) {
+	r, err := d.index.seek(d.file, i)
+	if err != nil {
+		return nil, err
+	}
+	a, err := d.readBlock(r, d.indexHeaders[i])
+	if err != nil {
+		return nil, fmt.Errorf("decode ammo %d error: %w", i, err)
+	}
+	return a, nil
+}
@@ -35,6 +35,26 @@ func Import(fs afero.Fs) {
 		return NewProvider(fs, cfg)
 	})
 
+	register.Provider("har", func(cfg config.Config) (core.Provider, error) {
+		cfg.Decoder = config.DecoderHAR
+		return NewProvider(fs, cfg)
+	})
+
+	register.Provider("accesslog", func(cfg config.Config) (core.Provider, error) {
+		cfg.Decoder = config.DecoderAccessLog
+		return NewProvider(fs, cfg)
+	})
+
+	register.Provider("curl", func(cfg config.Config) (core.Provider, error) {
+		cfg.Decoder = config.DecoderCurl
+		return NewProvider(fs, cfg)
+	})
+
+	register.Provider("pcap", func(cfg config.Config) (core.Provider, error) {
+		cfg.Decoder = config.DecoderPCAP
+		return NewProvider(fs, cfg)
+	})
+
 	httpRegister.HTTPMW("header/date", func(cfg headerdate.Config) (middleware.Middleware, error) {
 		return headerdate.NewMiddleware(cfg)
 	})
@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"io"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/afero"
@@ -18,6 +19,9 @@ func NewProvider(fs afero.Fs, conf config.Config) (core.Provider, error) {
 	if !conf.Decoder.IsValid() {
 		return nil, xerrors.Errorf("unknown decoder type faced")
 	}
+	if len(conf.Files) > 0 {
+		return newMixedProvider(fs, conf)
+	}
 	var (
 		readSeeker io.ReadSeeker
 		closer     io.Closer
@@ -46,6 +50,51 @@ func NewProvider(fs afero.Fs, conf config.Config) (core.Provider, error) {
 	}, nil
 }
 
+// newMixedProvider makes provider, that mixes ammo of files by weight. Every file has own provider
+// with the same config, except limit and middlewares, that are applied to the mixture.
+func newMixedProvider(fs afero.Fs, conf config.Config) (core.Provider, error) {
+	if conf.File != "" || len(conf.Uris) > 0 {
+		return nil, xerrors.Errorf("one should specify either 'files' or 'file' and 'uris', but not both of them")
+	}
+	sources := make([]provider.Source, 0, len(conf.Files))
+	names := map[string]bool{}
+	for _, f := range conf.Files {
+		name := f.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(f.File), filepath.Ext(f.File))
+		}
+		if names[name] {
+			return nil, xerrors.Errorf("source name %q of file %s is not unique, set name", name, f.File)
+		}
+		names[name] = true
+		weight := f.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		fileConf := conf
+		fileConf.File = f.File
+		fileConf.Files = nil
+		fileConf.Limit = 0
+		fileConf.Middlewares = nil
+		p, err := NewProvider(fs, fileConf)
+		if err != nil {
+			for _, s := range sources {
+				_ = s.Provider.Close()
+			}
+			return nil, xerrors.Errorf("file %s: %w", f.File, err)
+		}
+		sources = append(sources, provider.Source{Name: name, Weight: weight, Provider: p.(*provider.Provider)})
+	}
+	return &provider.Provider{
+		ProviderBase: base.ProviderBase{
+			FS: fs,
+		},
+		Config:  conf,
+		Sources: sources,
+		Sink:    make(chan decoders.DecodedAmmo),
+	}, nil
+}
+
 func fileReadSeekCloser(fs afero.Fs, path string) (io.ReadSeeker, io.Closer, error) {
 	if path == "" {
 		return nil, nil, xerrors.Errorf("one should specify either 'file' or 'uris'")
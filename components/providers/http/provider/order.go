@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"math/rand"
+
+	"github.com/yandex/pandora/components/providers/http/config"
+)
+
+// ammoOrder picks ammo numbers in order of config.
+type ammoOrder struct {
+	order string
+	rnd   *rand.Rand
+	ids   []int
+}
+
+func newAmmoOrder(order string, seed int64, ids []int) *ammoOrder {
+	return &ammoOrder{
+		order: order,
+		rnd:   rand.New(rand.NewSource(seed)),
+		ids:   ids,
+	}
+}
+
+// next returns number of ammo, that is sent as ammoNum. It should be called with ammoNum from zero in a row.
+func (o *ammoOrder) next(ammoNum uint) int {
+	length := uint(len(o.ids))
+	switch o.order {
+	case config.OrderShuffle:
+		if ammoNum%length == 0 {
+			o.rnd.Shuffle(len(o.ids), func(i, j int) { o.ids[i], o.ids[j] = o.ids[j], o.ids[i] })
+		}
+	case config.OrderRandom:
+		return o.ids[o.rnd.Intn(len(o.ids))]
+	}
+	return o.ids[ammoNum%length]
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"time"
 
 	"github.com/yandex/pandora/components/providers/base"
 	httpProvider "github.com/yandex/pandora/components/providers/http/ammo"
@@ -24,6 +26,34 @@ type Provider struct {
 
 	Sink  chan decoders.DecodedAmmo
 	ammos []decoders.DecodedAmmo
+
+	// Sources are providers of files, that are mixed by weight. Provider has no decoder with them.
+	Sources []Source
+}
+
+// Source is provider of file of weighted mixture.
+type Source struct {
+	// Name prefixes tags of source ammo.
+	Name     string
+	Weight   int
+	Provider *Provider
+}
+
+// sourceAmmo is ammo of mixture, that has tag prefixed by name of its source.
+type sourceAmmo struct {
+	decoders.DecodedAmmo
+	tag string
+}
+
+func (a *sourceAmmo) Tag() string {
+	return a.tag
+}
+
+func (a *sourceAmmo) Timestamp() time.Time {
+	if ta, ok := a.DecodedAmmo.(decoders.TimestampedAmmo); ok {
+		return ta.Timestamp()
+	}
+	return time.Time{}
 }
 
 func (p *Provider) Acquire() (core.Ammo, bool) {
@@ -43,11 +73,14 @@ func (p *Provider) Acquire() (core.Ammo, bool) {
 			return ammo, false
 		}
 	}
+	if ta, ok := ammo.(decoders.TimestampedAmmo); ok {
+		return httpProvider.NewTimestampedGunAmmo(req, ammo.Tag(), p.NextID(), ta.Timestamp()), true
+	}
 	return httpProvider.NewGunAmmo(req, ammo.Tag(), p.NextID()), ok
 }
 
 func (p *Provider) Release(a core.Ammo) {
-	if p.Preload {
+	if p.Preload || p.Decoder == nil {
 		return
 	}
 	p.Decoder.Release(a)
@@ -78,13 +111,21 @@ func (p *Provider) Run(ctx context.Context, deps core.ProviderDeps) (err error)
 		}
 	}
 
-	if p.Config.Preload {
+	switch {
+	case len(p.Sources) > 0:
+		err = p.runMixed(ctx, deps)
+	case p.Config.Preload:
 		err = p.loadAmmo(ctx)
 		if err == nil {
 			err = p.runPreloaded(ctx)
 		}
-	} else {
+	case p.Order == "" || p.Order == config.OrderSequential:
 		err = p.runFullScan(ctx)
+	default:
+		err = p.runIndexed(ctx)
+	}
+	if sc, ok := p.Decoder.(decoders.SkipCounter); ok && sc.Skipped() > 0 {
+		deps.Log.Warn("Malformed ammo records skipped", zap.String("file", p.Config.File), zap.Int("count", sc.Skipped()))
 	}
 
 	return
@@ -136,10 +177,58 @@ func (p *Provider) loadAmmo(ctx context.Context) error {
 }
 
 func (p *Provider) runPreloaded(ctx context.Context) error {
-	length := uint(len(p.ammos))
+	ids := make([]int, len(p.ammos))
+	for i := range ids {
+		ids[i] = i
+	}
+	return p.runOrdered(ctx, ids, func(i int) (decoders.DecodedAmmo, error) {
+		return p.ammos[i], nil
+	})
+}
+
+// seed returns seed of random orders. It is random, if not set.
+func (p *Provider) seed() int64 {
+	if p.Seed != 0 {
+		return p.Seed
+	}
+	return time.Now().UnixNano()
+}
+
+// runIndexed sends ammo in shuffle or random order, reading it by index of decoder.
+func (p *Provider) runIndexed(ctx context.Context) error {
+	indexer, ok := p.Decoder.(decoders.Indexer)
+	if !ok {
+		return fmt.Errorf("decoder %s doesn't support order %s without preload", p.Config.Decoder, p.Order)
+	}
+	n, err := indexer.Index(ctx)
+	if err != nil {
+		return fmt.Errorf("cant Index, err: %w", err)
+	}
+	ids := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if len(p.Config.ChosenCases) == 0 {
+			ids = append(ids, i)
+			continue
+		}
+		ammo, err := indexer.ReadAmmo(i)
+		if err != nil {
+			return err
+		}
+		if confutil.IsChosenCase(ammo.Tag(), p.Config.ChosenCases) {
+			ids = append(ids, i)
+		}
+		p.Decoder.Release(ammo)
+	}
+	return p.runOrdered(ctx, ids, indexer.ReadAmmo)
+}
+
+// runOrdered sends ammo with numbers ids in order of config. Pass is len(ids) ammo.
+func (p *Provider) runOrdered(ctx context.Context, ids []int, get func(i int) (decoders.DecodedAmmo, error)) error {
+	length := uint(len(ids))
 	if length == 0 {
 		return decoders.ErrNoAmmo
 	}
+	order := newAmmoOrder(p.Order, p.seed(), ids)
 	ammoNum := uint(0)
 	passNum := uint(0)
 	for {
@@ -150,7 +239,6 @@ func (p *Provider) runPreloaded(ctx context.Context) error {
 			}
 			return err
 		}
-		i := ammoNum % length
 		passNum = ammoNum / length
 		if p.Passes != 0 && passNum >= p.Passes {
 			return decoders.ErrPassLimit
@@ -158,8 +246,11 @@ func (p *Provider) runPreloaded(ctx context.Context) error {
 		if p.Limit != 0 && ammoNum >= p.Limit {
 			return decoders.ErrAmmoLimit
 		}
+		ammo, err := get(order.next(ammoNum))
+		if err != nil {
+			return err
+		}
 		ammoNum++
-		ammo := p.ammos[i]
 		select {
 		case <-ctx.Done():
 			err = ctx.Err()
@@ -172,4 +263,69 @@ func (p *Provider) runPreloaded(ctx context.Context) error {
 	}
 }
 
+// runMixed runs providers of sources and sends their ammo, choosing source by weight for every ammo.
+// Source is done after its passes, so the rest are mixed in proportion to their weights.
+func (p *Provider) runMixed(ctx context.Context, deps core.ProviderDeps) error {
+	ctx, cancel := context.WithCancel(ctx)
+	errs := make([]chan error, len(p.Sources))
+	mix := make([]base.MixSource[decoders.DecodedAmmo], len(p.Sources))
+	for i, s := range p.Sources {
+		errs[i] = make(chan error, 1)
+		go func(s Source, res chan<- error) {
+			res <- s.Provider.Run(ctx, deps)
+		}(s, errs[i])
+		name := s.Name
+		mix[i] = base.MixSource[decoders.DecodedAmmo]{
+			Weight: s.Weight,
+			Ammo:   s.Provider.Sink,
+			Done: func() error {
+				err := <-errs[i]
+				errs[i] = nil
+				if err == nil || errors.Is(err, decoders.ErrPassLimit) || errors.Is(err, decoders.ErrAmmoLimit) {
+					return nil
+				}
+				return fmt.Errorf("source %s: %w", name, err)
+			},
+		}
+	}
+	defer func() {
+		// Providers of sources are stopped and their files are closed before return.
+		cancel()
+		for i, s := range p.Sources {
+			if errs[i] != nil {
+				for range s.Provider.Sink {
+				}
+				<-errs[i]
+			}
+		}
+	}()
+
+	rnd := rand.New(rand.NewSource(p.seed()))
+	ammoNum := uint(0)
+	err := base.Mix(ctx, rnd, mix, func(source int, ammo decoders.DecodedAmmo) bool {
+		if p.Limit != 0 && ammoNum >= p.Limit {
+			return false
+		}
+		ammoNum++
+		tag := p.Sources[source].Name
+		if ammo.Tag() != "" {
+			tag += "." + ammo.Tag()
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case p.Sink <- &sourceAmmo{DecodedAmmo: ammo, tag: tag}:
+			return true
+		}
+	})
+	// Context is canceled only on return, so its error is error of parent context.
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if errors.Is(ctxErr, context.Canceled) {
+			return nil
+		}
+		return xerrors.Errorf("error from context: %w", ctxErr)
+	}
+	return err
+}
+
 var _ core.Provider = (*Provider)(nil)
@@ -2,7 +2,9 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -134,3 +136,48 @@ func TestLoadAmmo_ChosenTags(t *testing.T) {
 	assert.Len(t, provider.ammos, len(expectedAmmos))
 	assert.Equal(t, provider.ammos, expectedAmmos)
 }
+
+func TestProvider_runIndexed(t *testing.T) {
+	const uris = "[Host: example.com]\n/1 one\n/2 two\n/3 one\n/4 two\n/5 one\n"
+	run := func(cfg config.Config) []string {
+		cfg.Decoder = config.DecoderURI
+		decoder, err := decoders.NewDecoder(cfg, strings.NewReader(uris))
+		require.NoError(t, err)
+		provider := &Provider{
+			Config:  cfg,
+			Decoder: decoder,
+			Sink:    make(chan decoders.DecodedAmmo),
+		}
+		var paths []string
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for a := range provider.Sink {
+				req, err := a.BuildRequest()
+				assert.NoError(t, err)
+				paths = append(paths, req.URL.Path)
+			}
+		}()
+		err = provider.runIndexed(context.Background())
+		close(provider.Sink)
+		<-done
+		assert.True(t, errors.Is(err, decoders.ErrPassLimit) || errors.Is(err, decoders.ErrAmmoLimit), err)
+		return paths
+	}
+
+	shuffled := run(config.Config{Order: config.OrderShuffle, Seed: 42, Passes: 3})
+	require.Len(t, shuffled, 15)
+	for pass := 0; pass < 3; pass++ {
+		assert.ElementsMatch(t, []string{"/1", "/2", "/3", "/4", "/5"}, shuffled[pass*5:pass*5+5])
+	}
+	assert.NotEqual(t, shuffled[:5], shuffled[5:10])
+	assert.Equal(t, shuffled, run(config.Config{Order: config.OrderShuffle, Seed: 42, Passes: 3}))
+
+	random := run(config.Config{Order: config.OrderRandom, Seed: 42, Limit: 100})
+	require.Len(t, random, 100)
+	assert.Subset(t, []string{"/1", "/2", "/3", "/4", "/5"}, random)
+	assert.Equal(t, random, run(config.Config{Order: config.OrderRandom, Seed: 42, Limit: 100}))
+
+	chosen := run(config.Config{Order: config.OrderShuffle, Seed: 42, Passes: 2, ChosenCases: []string{"two"}})
+	assert.ElementsMatch(t, []string{"/2", "/4", "/2", "/4"}, chosen)
+}
@@ -1,14 +1,19 @@
 package http
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/http/ammo"
 	"github.com/yandex/pandora/components/providers/http/config"
 	"github.com/yandex/pandora/components/providers/http/provider"
+	"github.com/yandex/pandora/core"
+	"go.uber.org/zap"
 )
 
 func TestNewProvider_invalidDecoder(t *testing.T) {
@@ -97,3 +102,86 @@ func TestNewProvider(t *testing.T) {
 		})
 	}
 }
+
+func TestNewProvider_Files(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "main.uri", []byte("[Host: example.com]\n/1 item\n/2 item\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "rare.uri", []byte("[Host: example.com]\n/3\n"), 0644))
+
+	conf := config.Config{
+		Decoder: config.DecoderURI,
+		Passes:  3,
+		Files: []config.SourceFile{
+			{File: "main.uri", Weight: 3},
+			{File: "rare.uri"},
+		},
+	}
+	p, err := NewProvider(fs, conf)
+	require.NoError(t, err)
+
+	tags := map[string]int{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			a, ok := p.Acquire()
+			if !ok {
+				return
+			}
+			_, sample := a.(ammo.GunAmmo).Request()
+			tags[sample.Tags()]++
+		}
+	}()
+	err = p.Run(context.Background(), core.ProviderDeps{Log: zap.NewNop()})
+	require.NoError(t, err)
+	<-done
+	assert.Equal(t, map[string]int{"main.item": 6, "rare": 3}, tags)
+
+	conf.Files = append(conf.Files, config.SourceFile{File: "other/main.uri"})
+	_, err = NewProvider(fs, conf)
+	assert.ErrorContains(t, err, `source name "main" of file other/main.uri is not unique`)
+
+	conf.File = "main.uri"
+	_, err = NewProvider(fs, conf)
+	assert.ErrorContains(t, err, "either 'files' or 'file'")
+}
+
+func TestNewProvider_Timestamps(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	const log = `127.0.0.1 - - [19/Oct/2026:12:00:00 +0300] "GET /1 HTTP/1.1" 200 0 "-" "-"
+127.0.0.1 - - [19/Oct/2026:12:00:02 +0300] "GET /2 HTTP/1.1" 200 0 "-" "-"
+`
+	require.NoError(t, afero.WriteFile(fs, "access.log", []byte(log), 0644))
+	want := []time.Time{
+		time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 19, 9, 0, 2, 0, time.UTC),
+	}
+
+	for _, conf := range []config.Config{
+		{File: "access.log"},
+		{Files: []config.SourceFile{{File: "access.log"}}},
+	} {
+		conf.Decoder = config.DecoderAccessLog
+		conf.Passes = 1
+		conf.AccessLog.Timestamps = true
+		p, err := NewProvider(fs, conf)
+		require.NoError(t, err)
+
+		var got []time.Time
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				a, ok := p.Acquire()
+				if !ok {
+					return
+				}
+				got = append(got, a.(ammo.GunAmmo).Timestamp().UTC())
+			}
+		}()
+		err = p.Run(context.Background(), core.ProviderDeps{Log: zap.NewNop()})
+		require.NoError(t, err)
+		<-done
+		assert.Equal(t, want, got, "%+v", conf)
+	}
+}
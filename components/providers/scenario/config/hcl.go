@@ -7,10 +7,12 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/spf13/afero"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
 	"github.com/zclconf/go-cty/cty/function/stdlib"
+	"gopkg.in/yaml.v2"
 )
 
 type AmmoHCL struct {
@@ -126,6 +128,35 @@ func ParseHCLFile(file afero.File) (AmmoHCL, error) {
 	return config, nil
 }
 
+// WriteHCL writes ammo in HCL format.
+func WriteHCL(w io.Writer, ammo AmmoHCL) error {
+	f := hclwrite.NewEmptyFile()
+	gohcl.EncodeIntoBody(ammo, f.Body())
+	_, err := f.WriteTo(w)
+	return err
+}
+
+// WriteYAML writes ammo in YAML format.
+func WriteYAML(w io.Writer, ammo AmmoHCL) error {
+	data, err := yaml.Marshal(ammo)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadYAML reads ammo in YAML format, that WriteYAML writes.
+func ReadYAML(r io.Reader) (AmmoHCL, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return AmmoHCL{}, err
+	}
+	var ammo AmmoHCL
+	err = yaml.Unmarshal(data, &ammo)
+	return ammo, err
+}
+
 func decodeLocals(localsBodyContent *hcl.BodyContent) (*hcl.EvalContext, hcl.Diagnostics) {
 	vars := map[string]cty.Value{}
 	hclContext := buildHclContext(vars)
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -68,3 +69,46 @@ func TestParseHCLFile(t *testing.T) {
 			Variables: &(map[string]string{"header": "yandex", "b": "s"})})
 	})
 }
+
+func TestWriteHCL(t *testing.T) {
+	ammo := testWriteAmmo()
+	var buf bytes.Buffer
+	require.NoError(t, WriteHCL(&buf, ammo))
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "ammo.hcl", buf.Bytes(), 0644))
+	file, err := fs.Open("ammo.hcl")
+	require.NoError(t, err)
+	defer file.Close()
+	parsed, err := ParseHCLFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, ammo, parsed)
+}
+
+func TestWriteYAML(t *testing.T) {
+	ammo := testWriteAmmo()
+	var buf bytes.Buffer
+	require.NoError(t, WriteYAML(&buf, ammo))
+	parsed, err := ReadYAML(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, ammo.Requests, parsed.Requests)
+	assert.Equal(t, ammo.Scenarios, parsed.Scenarios)
+}
+
+func testWriteAmmo() AmmoHCL {
+	return AmmoHCL{
+		Requests: []RequestHCL{{
+			Name:    "create",
+			Method:  "POST",
+			URI:     "/items",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Tag:     pointer.ToString("create"),
+			Body:    pointer.ToString(`{"name": "${name}"}`),
+		}},
+		Scenarios: []ScenarioHCL{{
+			Name:     "create",
+			Weight:   pointer.ToInt64(10),
+			Requests: []string{"create(1)"},
+		}},
+	}
+}
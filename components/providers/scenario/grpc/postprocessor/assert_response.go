@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/yandex/pandora/core/aggregator/netsample"
 )
 
 type errAssert struct {
@@ -16,6 +17,8 @@ func (e *errAssert) Error() string {
 	return "assert failed: " + e.t + " does not contain " + e.pattern
 }
 
+func (e *errAssert) ErrorKind() string { return netsample.ErrKindAssertionFailed }
+
 type AssertResponse struct {
 	Payload    []string
 	StatusCode int `config:"status_code"`
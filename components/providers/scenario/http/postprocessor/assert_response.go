@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/yandex/pandora/core/aggregator/netsample"
 )
 
 type errAssert struct {
@@ -17,6 +19,8 @@ func (e *errAssert) Error() string {
 	return "assert failed: " + e.t + " does not contain " + e.pattern
 }
 
+func (e *errAssert) ErrorKind() string { return netsample.ErrKindAssertionFailed }
+
 type AssertSize struct {
 	Val int
 	Op  string
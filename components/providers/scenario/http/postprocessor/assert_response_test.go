@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/yandex/pandora/core/aggregator/netsample"
 )
 
 func TestAssertResponse_Process(t *testing.T) {
@@ -164,3 +165,11 @@ func TestAssertResponse_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestAssertResponse_ErrorKind(t *testing.T) {
+	a := AssertResponse{StatusCode: 200}
+	_, err := a.Process(&http.Response{StatusCode: 500}, nil)
+	assert.Error(t, err)
+	wrapped := fmt.Errorf("postprocessor %w", netsample.WithErrorKind(err, netsample.ErrKindPostprocessor))
+	assert.Equal(t, netsample.ErrKindAssertionFailed, netsample.ClassifyError(wrapped))
+}
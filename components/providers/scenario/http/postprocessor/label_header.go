@@ -0,0 +1,37 @@
+package postprocessor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/yandex/pandora/core/aggregator/netsample"
+)
+
+// LabelHeaderPostprocessor attaches response headers to request sample as labels.
+// Mapping is label name to header name, with same modifiers as in VarHeaderPostprocessor.
+type LabelHeaderPostprocessor struct {
+	VarHeaderPostprocessor
+}
+
+// Process returns no variables: headers are saved only as sample labels.
+func (p *LabelHeaderPostprocessor) Process(_ *http.Response, _ io.Reader) (map[string]any, error) {
+	return nil, nil
+}
+
+func (p *LabelHeaderPostprocessor) ProcessSample(sample *netsample.Sample, resp *http.Response) error {
+	labels, err := p.VarHeaderPostprocessor.Process(resp, nil)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // Stable labels order in output.
+	for _, k := range keys {
+		sample.SetLabel(k, fmt.Sprint(labels[k]))
+	}
+	return nil
+}
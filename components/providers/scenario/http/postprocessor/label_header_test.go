@@ -0,0 +1,35 @@
+package postprocessor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+)
+
+func TestLabelHeaderPostprocessor(t *testing.T) {
+	p := &LabelHeaderPostprocessor{VarHeaderPostprocessor{Mapping: map[string]string{
+		"server": "Server|lower",
+		"region": "X-Region",
+		"absent": "X-Absent",
+	}}}
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Server", "Nginx")
+	resp.Header.Set("X-Region", "eu")
+
+	vars, err := p.Process(resp, nil)
+	require.NoError(t, err)
+	assert.Nil(t, vars)
+
+	sample := netsample.Acquire("tag")
+	require.NoError(t, p.ProcessSample(sample, resp))
+	assert.Equal(t, []netsample.Label{{Key: "region", Value: "eu"}, {Key: "server", Value: "nginx"}}, sample.Labels())
+}
+
+func TestLabelHeaderPostprocessorError(t *testing.T) {
+	p := &LabelHeaderPostprocessor{VarHeaderPostprocessor{Mapping: map[string]string{"a": "header||"}}}
+	err := p.ProcessSample(netsample.Acquire("tag"), &http.Response{Header: http.Header{}})
+	assert.Error(t, err)
+}
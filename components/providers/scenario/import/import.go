@@ -44,6 +44,7 @@ func Import(fs afero.Fs) {
 		RegisterPostprocessor("var/jsonpath", NewVarJsonpathPostprocessor)
 		RegisterPostprocessor("var/xpath", NewVarXpathPostprocessor)
 		RegisterPostprocessor("var/header", NewVarHeaderPostprocessor)
+		RegisterPostprocessor("label/header", NewLabelHeaderPostprocessor)
 		RegisterPostprocessor("assert/response", NewAssertResponsePostprocessor)
 
 		RegisterTemplater("text", func() gun.Templater {
@@ -101,6 +102,12 @@ func NewVarHeaderPostprocessor(cfg postprocessor.Config) gun.Postprocessor {
 	}
 }
 
+func NewLabelHeaderPostprocessor(cfg postprocessor.Config) gun.Postprocessor {
+	return &postprocessor.LabelHeaderPostprocessor{
+		VarHeaderPostprocessor: postprocessor.VarHeaderPostprocessor{Mapping: cfg.Mapping},
+	}
+}
+
 func NewVarJsonpathPostprocessor(cfg postprocessor.Config) gun.Postprocessor {
 	return &postprocessor.VarJsonpathPostprocessor{
 		Mapping: cfg.Mapping,
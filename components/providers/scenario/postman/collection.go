@@ -0,0 +1,156 @@
+// Package postman converts Postman v2.1 collections to HTTP scenario ammo.
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Collection is subset of Postman Collection v2.1 format, that is needed to make requests.
+// See https://schema.postman.com/collection/json/v2.1.0/draft-07/docs/index.html
+type Collection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []Item     `json:"item"`
+	Variable []Variable `json:"variable"`
+	Auth     *Auth      `json:"auth"`
+}
+
+// Item is request, or folder of items.
+type Item struct {
+	Name    string   `json:"name"`
+	Item    []Item   `json:"item"`
+	Request *Request `json:"request"`
+	Auth    *Auth    `json:"auth"`
+}
+
+func (i Item) isFolder() bool {
+	return i.Request == nil
+}
+
+type Request struct {
+	Method string     `json:"method"`
+	URL    URL        `json:"url"`
+	Header []KeyValue `json:"header"`
+	Body   *Body      `json:"body"`
+	Auth   *Auth      `json:"auth"`
+}
+
+// UnmarshalJSON supports request, that is just URL string.
+func (r *Request) UnmarshalJSON(data []byte) error {
+	var rawURL string
+	if err := json.Unmarshal(data, &rawURL); err == nil {
+		*r = Request{Method: "GET", URL: URL{Raw: rawURL}}
+		return nil
+	}
+	type request Request
+	return json.Unmarshal(data, (*request)(r))
+}
+
+type URL struct {
+	Raw      string     `json:"raw"`
+	Host     []string   `json:"host"`
+	Path     []string   `json:"path"`
+	Query    []KeyValue `json:"query"`
+	Variable []Variable `json:"variable"`
+}
+
+// UnmarshalJSON supports URL, that is string.
+func (u *URL) UnmarshalJSON(data []byte) error {
+	var rawURL string
+	if err := json.Unmarshal(data, &rawURL); err == nil {
+		*u = URL{Raw: rawURL}
+		return nil
+	}
+	type urlObject URL
+	return json.Unmarshal(data, (*urlObject)(u))
+}
+
+type KeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+type Variable struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+type Body struct {
+	Mode       string     `json:"mode"`
+	Raw        string     `json:"raw"`
+	URLEncoded []KeyValue `json:"urlencoded"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+// Auth is request authorization. Attributes of type are list of key-value pairs, like
+// {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}"}]}.
+type Auth struct {
+	Type   string     `json:"type"`
+	Bearer []Variable `json:"bearer"`
+	Basic  []Variable `json:"basic"`
+	APIKey []Variable `json:"apikey"`
+}
+
+// Environment is Postman environment export.
+type Environment struct {
+	Name   string `json:"name"`
+	Values []struct {
+		Key     string `json:"key"`
+		Value   any    `json:"value"`
+		Enabled *bool  `json:"enabled"`
+	} `json:"values"`
+}
+
+// ParseCollection reads Postman v2.1 collection.
+func ParseCollection(r io.Reader) (*Collection, error) {
+	var c Collection
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("postman collection parse failed: %w", err)
+	}
+	if !strings.Contains(c.Info.Schema, "/collection/v2.1") {
+		return nil, fmt.Errorf("only Postman v2.1 collections are supported, got schema %q", c.Info.Schema)
+	}
+	return &c, nil
+}
+
+func ParseEnvironment(r io.Reader) (*Environment, error) {
+	var env Environment
+	if err := json.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("postman environment parse failed: %w", err)
+	}
+	return &env, nil
+}
+
+// variableString formats variable value: Postman stores numbers and booleans as is.
+func variableString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+func attribute(attrs []Variable, key string) string {
+	for _, a := range attrs {
+		if a.Key == key {
+			return variableString(a.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,387 @@
+package postman
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yandex/pandora/components/providers/scenario/config"
+)
+
+// VariableSource is name of variable source with collection and environment variables.
+// Postman {{name}} is converted to {{.source.variables.name}}.
+const VariableSource = "variables"
+
+var (
+	postmanVariable = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+	nonNameChars    = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	identifier      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// dynamicVariables are Postman dynamic variables, that have scenario function equivalents.
+var dynamicVariables = map[string]string{
+	"$guid":       "{{ uuid }}",
+	"$randomUUID": "{{ uuid }}",
+	"$randomInt":  "{{ randInt 0 1000 }}",
+}
+
+// Convert makes scenario ammo from collection. Every folder with requests becomes scenario, that runs folder
+// requests in order. Requests outside of folders make scenario named after collection.
+// Environment variables override collection ones. Environment can be nil.
+// Warnings describe parts of collection, that can't be converted, like form data bodies or scripts variables.
+func Convert(c *Collection, env *Environment) (ammo config.AmmoHCL, warnings []string, err error) {
+	conv := &converter{
+		collection: c.Info.Name,
+		variables:  map[string]string{},
+		referenced: map[string]bool{},
+		names:      map[string]bool{},
+		warned:     map[string]bool{},
+	}
+	for _, v := range c.Variable {
+		conv.variables[v.Key] = variableString(v.Value)
+	}
+	if env != nil {
+		for _, v := range env.Values {
+			if v.Enabled == nil || *v.Enabled {
+				conv.variables[v.Key] = variableString(v.Value)
+			}
+		}
+	}
+	conv.walk("", c.Item, []*Auth{c.Auth})
+	if conv.err != nil {
+		return ammo, nil, conv.err
+	}
+	ammo.Requests = conv.requests
+	ammo.Scenarios = conv.scenarios
+
+	if len(conv.referenced) > 0 {
+		names := make([]string, 0, len(conv.referenced))
+		for name := range conv.referenced {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		variables := map[string]string{}
+		for _, name := range names {
+			value, ok := conv.variables[name]
+			if !ok {
+				conv.warnf("variable %q is not defined in collection or environment, it is set to empty string", name)
+			}
+			variables[name] = value
+		}
+		ammo.VariableSources = []config.SourceHCL{{Name: VariableSource, Type: "variables", Variables: &variables}}
+	}
+	return ammo, conv.warnings, nil
+}
+
+type converter struct {
+	collection string
+	variables  map[string]string
+	referenced map[string]bool
+	names      map[string]bool
+	requests   []config.RequestHCL
+	scenarios  []config.ScenarioHCL
+	warnings   []string
+	warned     map[string]bool
+	err        error
+}
+
+func (c *converter) warnf(format string, args ...any) {
+	w := fmt.Sprintf(format, args...)
+	if !c.warned[w] {
+		c.warned[w] = true
+		c.warnings = append(c.warnings, w)
+	}
+}
+
+// walk converts items of folder. Folder is path of nested folder names, empty for collection root.
+// Auths are auths of collection and parent folders, innermost last.
+// Folder scenario goes before scenarios of nested folders.
+func (c *converter) walk(folder string, items []Item, auths []*Auth) {
+	var requests []string
+	for _, item := range items {
+		if item.isFolder() {
+			continue
+		}
+		name := c.uniqueName(item.Name)
+		req, err := c.request(name, item, auths)
+		if err != nil {
+			c.err = fmt.Errorf("request %q: %w", item.Name, err)
+			return
+		}
+		c.requests = append(c.requests, req)
+		requests = append(requests, name+"(1)")
+	}
+	if len(requests) > 0 {
+		scenario := folder
+		if scenario == "" {
+			scenario = c.collection
+		}
+		c.scenarios = append(c.scenarios, config.ScenarioHCL{Name: c.name(scenario), Requests: requests})
+	}
+	for _, item := range items {
+		if item.isFolder() && c.err == nil {
+			c.walk(strings.TrimPrefix(folder+" "+item.Name, " "), item.Item, append(auths[:len(auths):len(auths)], item.Auth))
+		}
+	}
+}
+
+// name makes identifier from Postman name: "Get item / v2" is converted to Get_item_v2.
+func (c *converter) name(s string) string {
+	name := strings.Trim(nonNameChars.ReplaceAllString(s, "_"), "_")
+	if name == "" {
+		name = "request"
+	}
+	return name
+}
+
+func (c *converter) uniqueName(s string) string {
+	base := c.name(s)
+	name := base
+	for i := 2; c.names[name]; i++ {
+		name = base + "_" + strconv.Itoa(i)
+	}
+	c.names[name] = true
+	return name
+}
+
+func (c *converter) request(name string, item Item, auths []*Auth) (config.RequestHCL, error) {
+	r := item.Request
+	method := strings.ToUpper(r.Method)
+	if method == "" {
+		method = "GET"
+	}
+	req := config.RequestHCL{
+		Name:    name,
+		Method:  method,
+		Headers: map[string]string{},
+		Tag:     &name,
+	}
+	for _, h := range r.Header {
+		if !h.Disabled {
+			req.Headers[h.Key] = c.template(h.Value)
+		}
+	}
+	query := c.query(r.URL)
+	switch auth := effectiveAuth(r.Auth, item.Auth, auths); {
+	case auth == nil:
+	case auth.Type == "bearer":
+		req.Headers["Authorization"] = "Bearer " + c.template(attribute(auth.Bearer, "token"))
+	case auth.Type == "basic":
+		user, pass := attribute(auth.Basic, "username"), attribute(auth.Basic, "password")
+		if postmanVariable.MatchString(user + pass) {
+			c.warnf("request %q: basic auth with variables is not supported, set Authorization header manually", name)
+			break
+		}
+		req.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+	case auth.Type == "apikey":
+		key, value := c.template(attribute(auth.APIKey, "key")), c.template(attribute(auth.APIKey, "value"))
+		if attribute(auth.APIKey, "in") == "query" {
+			query = append(query, key+"="+value)
+		} else {
+			req.Headers[key] = value
+		}
+	default:
+		c.warnf("request %q: %s auth is not supported", name, auth.Type)
+	}
+	req.URI = c.path(name, r.URL)
+	if len(query) > 0 {
+		req.URI += "?" + strings.Join(query, "&")
+	}
+	if r.Body != nil {
+		body, contentType, err := c.body(name, r.Body)
+		if err != nil {
+			return req, err
+		}
+		if body != "" {
+			req.Body = &body
+		}
+		if contentType != "" && !hasHeader(req.Headers, "Content-Type") {
+			req.Headers["Content-Type"] = contentType
+		}
+	}
+	return req, nil
+}
+
+// effectiveAuth returns auth of request, or auth inherited from item, folders or collection.
+// Nil means no auth.
+func effectiveAuth(request, item *Auth, parents []*Auth) *Auth {
+	chain := append([]*Auth{request, item}, reversed(parents)...)
+	for _, auth := range chain {
+		if auth == nil || auth.Type == "inherit" {
+			continue
+		}
+		if auth.Type == "noauth" {
+			return nil
+		}
+		return auth
+	}
+	return nil
+}
+
+func reversed(auths []*Auth) []*Auth {
+	result := make([]*Auth, len(auths))
+	for i, a := range auths {
+		result[len(auths)-1-i] = a
+	}
+	return result
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// path returns URL path without host, because host is set by gun target. If host is variable with path,
+// like {{baseUrl}} = https://example.com/api, its path is kept.
+func (c *converter) path(name string, u URL) string {
+	host, path := u.hostAndPath()
+	resolved := postmanVariable.ReplaceAllStringFunc(host, func(m string) string {
+		return c.variables[m[2:len(m)-2]]
+	})
+	if _, rest, ok := strings.Cut(resolved, "://"); ok {
+		resolved = rest
+	}
+	var prefix string
+	if i := strings.IndexByte(resolved, '/'); i >= 0 {
+		prefix = strings.TrimSuffix(resolved[i:], "/")
+	}
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, s := range segments {
+		if !strings.HasPrefix(s, ":") {
+			continue
+		}
+		found := false
+		for _, v := range u.Variable {
+			if v.Key == s[1:] {
+				segments[i] = variableString(v.Value)
+				found = true
+			}
+		}
+		if !found {
+			c.warnf("request %q: path variable %s has no value", name, s)
+		}
+	}
+	return c.template(prefix + "/" + strings.Join(segments, "/"))
+}
+
+func (u URL) hostAndPath() (host, path string) {
+	if len(u.Host) > 0 || len(u.Path) > 0 {
+		return strings.Join(u.Host, "."), "/" + strings.Join(u.Path, "/")
+	}
+	raw, _, _ := strings.Cut(u.Raw, "?")
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		scheme, rest = "", raw
+	}
+	host, path, _ = strings.Cut(rest, "/")
+	if scheme != "" {
+		host = scheme + "://" + host
+	}
+	return host, "/" + path
+}
+
+// query returns enabled query parameters as is: Postman sends them as they are typed.
+func (c *converter) query(u URL) []string {
+	params := u.Query
+	if len(u.Host) == 0 && len(u.Path) == 0 {
+		if _, rawQuery, ok := strings.Cut(u.Raw, "?"); ok && rawQuery != "" {
+			params = nil
+			for _, p := range strings.Split(rawQuery, "&") {
+				key, value, _ := strings.Cut(p, "=")
+				params = append(params, KeyValue{Key: key, Value: value})
+			}
+		}
+	}
+	var query []string
+	for _, p := range params {
+		if p.Disabled {
+			continue
+		}
+		query = append(query, c.template(p.Key)+"="+c.template(p.Value))
+	}
+	return query
+}
+
+var rawLanguageTypes = map[string]string{
+	"json":       "application/json",
+	"xml":        "application/xml",
+	"html":       "text/html",
+	"text":       "text/plain",
+	"javascript": "application/javascript",
+}
+
+// body returns request body and its content type.
+func (c *converter) body(name string, b *Body) (string, string, error) {
+	switch b.Mode {
+	case "", "none":
+		return "", "", nil
+	case "raw":
+		return c.template(b.Raw), rawLanguageTypes[b.Options.Raw.Language], nil
+	case "urlencoded":
+		var form []string
+		for _, p := range b.URLEncoded {
+			if !p.Disabled {
+				form = append(form, c.escapeTemplate(p.Key)+"="+c.escapeTemplate(p.Value))
+			}
+		}
+		return strings.Join(form, "&"), "application/x-www-form-urlencoded", nil
+	case "graphql":
+		if b.GraphQL == nil {
+			return "", "", nil
+		}
+		body := map[string]any{"query": b.GraphQL.Query}
+		if strings.TrimSpace(b.GraphQL.Variables) != "" {
+			body["variables"] = json.RawMessage(b.GraphQL.Variables)
+		}
+		data, err := json.Marshal(body)
+		if err != nil {
+			return "", "", fmt.Errorf("graphql variables are invalid json: %w", err)
+		}
+		return c.template(string(data)), "application/json", nil
+	}
+	c.warnf("request %q: %s body is not supported", name, b.Mode)
+	return "", "", nil
+}
+
+// template converts Postman {{variable}} references to scenario template ones.
+func (c *converter) template(s string) string {
+	return postmanVariable.ReplaceAllStringFunc(s, c.variable)
+}
+
+// escapeTemplate escapes form value, except of variable references.
+func (c *converter) escapeTemplate(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range postmanVariable.FindAllStringIndex(s, -1) {
+		b.WriteString(url.QueryEscape(s[last:m[0]]))
+		b.WriteString(c.variable(s[m[0]:m[1]]))
+		last = m[1]
+	}
+	b.WriteString(url.QueryEscape(s[last:]))
+	return b.String()
+}
+
+func (c *converter) variable(ref string) string {
+	name := strings.TrimSpace(ref[2 : len(ref)-2])
+	if strings.HasPrefix(name, "$") {
+		if f, ok := dynamicVariables[name]; ok {
+			return f
+		}
+		c.warnf("dynamic variable %s is not supported, it is kept as is", name)
+		return `{{"` + ref + `"}}`
+	}
+	c.referenced[name] = true
+	if identifier.MatchString(name) {
+		return "{{.source." + VariableSource + "." + name + "}}"
+	}
+	return `{{index .source.` + VariableSource + ` "` + name + `"}}`
+}
@@ -0,0 +1,134 @@
+package postman
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/scenario/config"
+	"github.com/yandex/pandora/lib/pointer"
+)
+
+const testCollection = `{
+  "info": {"name": "Shop API", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+  "variable": [{"key": "baseUrl", "value": "https://shop.example.com/api"}, {"key": "token", "value": "collection-token"}],
+  "item": [
+    {"name": "Health", "request": "https://shop.example.com/health"},
+    {
+      "name": "Items",
+      "item": [
+        {
+          "name": "Get item",
+          "request": {
+            "method": "GET",
+            "header": [{"key": "Accept", "value": "application/json"}, {"key": "X-Debug", "value": "1", "disabled": true}],
+            "url": {
+              "raw": "{{baseUrl}}/items/:id?lang={{lang}}&trace={{$guid}}",
+              "host": ["{{baseUrl}}"],
+              "path": ["items", ":id"],
+              "query": [{"key": "lang", "value": "{{lang}}"}, {"key": "trace", "value": "{{$guid}}"}, {"key": "off", "value": "1", "disabled": true}],
+              "variable": [{"key": "id", "value": "42"}]
+            }
+          }
+        },
+        {
+          "name": "Create item",
+          "request": {
+            "method": "POST",
+            "auth": {"type": "noauth"},
+            "url": "{{baseUrl}}/items",
+            "body": {"mode": "raw", "raw": "{\"name\": \"{{item-name}}\", \"at\": {{$timestamp}}}", "options": {"raw": {"language": "json"}}}
+          }
+        },
+        {
+          "name": "Admin",
+          "auth": {"type": "basic", "basic": [{"key": "username", "value": "admin"}, {"key": "password", "value": "secret"}]},
+          "item": [
+            {
+              "name": "Login",
+              "request": {
+                "method": "POST",
+                "url": {"raw": "{{baseUrl}}/login", "host": ["{{baseUrl}}"], "path": ["login"]},
+                "body": {"mode": "urlencoded", "urlencoded": [{"key": "user", "value": "a b"}, {"key": "pass", "value": "{{pass}}"}]}
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+const testEnvironment = `{"name": "dev", "values": [{"key": "token", "value": "env-token", "enabled": true}, {"key": "lang", "value": "en", "enabled": true}, {"key": "pass", "value": "p", "enabled": false}, {"key": "item-name", "value": "Tea"}]}`
+
+func TestConvert(t *testing.T) {
+	collection, err := ParseCollection(strings.NewReader(testCollection))
+	require.NoError(t, err)
+	env, err := ParseEnvironment(strings.NewReader(testEnvironment))
+	require.NoError(t, err)
+
+	ammo, warnings, err := Convert(collection, env)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"dynamic variable $timestamp is not supported, it is kept as is",
+		`variable "pass" is not defined in collection or environment, it is set to empty string`,
+	}, warnings)
+	assert.Equal(t, []config.SourceHCL{{
+		Name: "variables",
+		Type: "variables",
+		Variables: &map[string]string{
+			"item-name": "Tea",
+			"lang":      "en",
+			"pass":      "",
+			"token":     "env-token",
+		},
+	}}, ammo.VariableSources)
+	assert.Equal(t, []config.RequestHCL{
+		{
+			Name:    "Health",
+			Method:  "GET",
+			URI:     "/health",
+			Headers: map[string]string{"Authorization": "Bearer {{.source.variables.token}}"},
+			Tag:     pointer.ToString("Health"),
+		},
+		{
+			Name:   "Get_item",
+			Method: "GET",
+			URI:    "/api/items/42?lang={{.source.variables.lang}}&trace={{ uuid }}",
+			Headers: map[string]string{
+				"Accept":        "application/json",
+				"Authorization": "Bearer {{.source.variables.token}}",
+			},
+			Tag: pointer.ToString("Get_item"),
+		},
+		{
+			Name:    "Create_item",
+			Method:  "POST",
+			URI:     "/api/items",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Tag:     pointer.ToString("Create_item"),
+			Body:    pointer.ToString(`{"name": "{{index .source.variables "item-name"}}", "at": {{"{{$timestamp}}"}}}`),
+		},
+		{
+			Name:   "Login",
+			Method: "POST",
+			URI:    "/api/login",
+			Headers: map[string]string{
+				"Authorization": "Basic YWRtaW46c2VjcmV0",
+				"Content-Type":  "application/x-www-form-urlencoded",
+			},
+			Tag:  pointer.ToString("Login"),
+			Body: pointer.ToString("user=a+b&pass={{.source.variables.pass}}"),
+		},
+	}, ammo.Requests)
+	assert.Equal(t, []config.ScenarioHCL{
+		{Name: "Shop_API", Requests: []string{"Health(1)"}},
+		{Name: "Items", Requests: []string{"Get_item(1)", "Create_item(1)"}},
+		{Name: "Items_Admin", Requests: []string{"Login(1)"}},
+	}, ammo.Scenarios)
+
+	_, err = ParseCollection(strings.NewReader(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v2.0.0/collection.json"}}`))
+	assert.ErrorContains(t, err, "only Postman v2.1")
+}
@@ -56,11 +56,13 @@ func NewEncoderAggregator(
 	newEncoder NewSampleEncoder,
 	conf EncoderAggregatorConfig,
 ) core.Aggregator {
-	return &dataSinkAggregator{
+	a := &dataSinkAggregator{
 		Reporter:   *NewReporter(conf.ReporterConfig),
 		newEncoder: newEncoder,
 		conf:       conf,
 	}
+	a.EnableSpill(newEncoder)
+	return a
 }
 
 type dataSinkAggregator struct {
@@ -82,6 +84,7 @@ func (a *dataSinkAggregator) Run(ctx context.Context, deps core.AggregatorDeps)
 		closeErr := sink.Close()
 		err = errutil.Join(err, closeErr)
 		err = errutil.Join(err, a.DroppedErr())
+		a.LogOverflow(a.Log)
 	}()
 
 	var flushes int
@@ -89,13 +92,15 @@ func (a *dataSinkAggregator) Run(ctx context.Context, deps core.AggregatorDeps)
 		flushes++
 	})
 	defer func() {
+		// Spilled samples are appended after all handled samples, so they are out of time order.
+		flushErr := encoder.Flush()
+		err = errutil.Join(err, errors.WithMessage(flushErr, "final flush failed"))
+		err = errutil.Join(err, a.CopySpilled(sink))
 		if encoder, ok := encoder.(io.Closer); ok {
+			// Encoder close may write some format trailer, so spilled samples go before it.
 			closeErr := encoder.Close()
 			err = errutil.Join(err, errors.WithMessage(closeErr, "encoder close failed"))
-			return
 		}
-		flushErr := encoder.Flush()
-		err = errutil.Join(err, errors.WithMessage(flushErr, "final flush failed"))
 	}()
 
 	var flushTick <-chan time.Time
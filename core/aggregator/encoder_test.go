@@ -1,6 +1,7 @@
 package aggregator
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -61,7 +62,7 @@ func NewEncoderAggregatorTester(t testutil.TestingT) *EncoderAggregatorTester {
 	tr.conf = EncoderAggregatorConfig{
 		Sink:           tr.sink,
 		FlushInterval:  time.Second,
-		ReporterConfig: ReporterConfig{100},
+		ReporterConfig: ReporterConfig{SampleQueueSize: 100},
 	}
 	tr.ctx, tr.cancel = context.WithCancel(context.Background())
 	tr.deps = core.AggregatorDeps{Log: zap.L()}
@@ -130,6 +131,7 @@ func TestEncoderAggregator_CloseSampleEncoder(t *testing.T) {
 	tr.enc.On("Encode", 0).Once().Return(nil)
 	testee.Report(0)
 
+	tr.enc.On("Flush").Once().Return(nil)
 	tr.enc.On("Close").Once().Return(func() error {
 		tr.wc.On("Close").Once().Return(nil)
 		return nil
@@ -141,6 +143,48 @@ func TestEncoderAggregator_CloseSampleEncoder(t *testing.T) {
 	tr.AssertExpectations()
 }
 
+// trailerEncoder writes trailer on close, like encoders of formats with closing bracket.
+type trailerEncoder struct {
+	SampleEncoder
+	w io.Writer
+}
+
+func (e trailerEncoder) Close() error {
+	if err := e.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "end\n")
+	return err
+}
+
+type bufferSink struct{ bytes.Buffer }
+
+func (s *bufferSink) OpenSink() (io.WriteCloser, error) { return s, nil }
+
+func (s *bufferSink) Close() error { return nil }
+
+func TestEncoderAggregator_CloseSampleEncoderWithSpill(t *testing.T) {
+	sink := &bufferSink{}
+	testee := NewEncoderAggregator(func(w io.Writer, _ func()) SampleEncoder {
+		return trailerEncoder{NewJSONEncoder(w, JSONLineEncoderConfig{}), w}
+	}, EncoderAggregatorConfig{
+		Sink: sink,
+		ReporterConfig: ReporterConfig{
+			SampleQueueSize: 1,
+			OnOverflow:      OverflowSpill,
+			SpillDir:        t.TempDir(),
+		},
+	})
+	for i := 0; i < 3; i++ {
+		testee.Report(i)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := testee.Run(ctx, core.AggregatorDeps{Log: zap.L()})
+	require.NoError(t, err)
+	assert.Equal(t, "0\n1\n2\nend\n", sink.String(), "handled samples, then spilled, then trailer")
+}
+
 func TestEncoderAggregator_EverythingFailed(t *testing.T) {
 	tr := NewEncoderAggregatorTester(t)
 	tr.conf.ReporterConfig.SampleQueueSize = 1
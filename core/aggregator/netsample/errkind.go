@@ -0,0 +1,134 @@
+package netsample
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Error kinds of failed samples. Unlike net code, error kind tells at which stage shot failed,
+// so errors can be counted without log grepping.
+const (
+	ErrKindDNSFailure       = "dns_failure"
+	ErrKindConnectRefused   = "connect_refused"
+	ErrKindConnectTimeout   = "connect_timeout"
+	ErrKindConnectError     = "connect_error" // Other dial errors. For example, network is unreachable.
+	ErrKindTLSError         = "tls_error"
+	ErrKindReadTimeout      = "read_timeout" // Timeout after connection was established.
+	ErrKindResetByPeer      = "reset_by_peer"
+	ErrKindConnectionClosed = "connection_closed" // Unexpected EOF or broken pipe.
+	ErrKindCanceled         = "canceled"
+	ErrKindProtoError       = "proto_error" // Malformed response or protocol level error status.
+	ErrKindAssertionFailed  = "assertion_failed"
+	ErrKindTemplateError    = "template_error"
+	ErrKindPreprocessor     = "preprocessor_error"
+	ErrKindPostprocessor    = "postprocessor_error"
+	ErrKindUnknown          = "unknown"
+)
+
+// ErrorKinder is implemented by errors, that know their kind. For example, assertion errors.
+type ErrorKinder interface {
+	ErrorKind() string
+}
+
+// WithErrorKind returns err, that is classified as kind by ClassifyError.
+// Returns err as is, if it is nil or already has kind. So, for example, assertion error
+// returned by postprocessor is not classified as postprocessor error.
+func WithErrorKind(err error, kind string) error {
+	var kinder ErrorKinder
+	if err == nil || errors.As(err, &kinder) {
+		return err
+	}
+	return &kindError{error: err, kind: kind}
+}
+
+type kindError struct {
+	error
+	kind string
+}
+
+func (e *kindError) ErrorKind() string { return e.kind }
+func (e *kindError) Unwrap() error     { return e.error }
+
+// ClassifyError returns error kind. Errors wrapped with %w or github.com/pkg/errors are unwrapped.
+// Returns empty string for nil error and ErrKindUnknown, if error is not recognized.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+	var kinder ErrorKinder
+	if errors.As(err, &kinder) {
+		return kinder.ErrorKind()
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrKindDNSFailure
+	}
+	if isTLSError(err) {
+		return ErrKindTLSError
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		switch {
+		case errors.Is(err, syscall.ECONNREFUSED):
+			return ErrKindConnectRefused
+		case opErr.Timeout():
+			return ErrKindConnectTimeout
+		}
+		return ErrKindConnectError
+	}
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrKindConnectRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return ErrKindResetByPeer
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.EPIPE):
+		return ErrKindConnectionClosed
+	case errors.Is(err, context.Canceled):
+		return ErrKindCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrKindReadTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrKindReadTimeout
+	}
+	if isProtoError(err) {
+		return ErrKindProtoError
+	}
+	return ErrKindUnknown
+}
+
+func isTLSError(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return true
+	}
+	// Most of handshake errors are not typed.
+	return strings.Contains(err.Error(), "tls: ")
+}
+
+// isProtoError recognizes untyped net/http and x/net/http2 errors about malformed messages.
+func isProtoError(err error) bool {
+	msg := err.Error()
+	for _, s := range []string{"malformed HTTP", "http2: ", "server gave HTTP response to HTTPS client"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
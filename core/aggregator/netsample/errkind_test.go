@@ -0,0 +1,70 @@
+package netsample
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://localhost", Err: err}
+	}
+	opErr := func(op string, err error) error {
+		return &net.OpError{Op: op, Net: "tcp", Err: err}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"dns", urlErr(opErr("dial", &net.DNSError{Err: "no such host", Name: "unknown"})), ErrKindDNSFailure},
+		{"connect refused", urlErr(opErr("dial", &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED})), ErrKindConnectRefused},
+		{"connect timeout", urlErr(opErr("dial", os.ErrDeadlineExceeded)), ErrKindConnectTimeout},
+		{"connect error", opErr("dial", &os.SyscallError{Syscall: "connect", Err: syscall.ENETUNREACH}), ErrKindConnectError},
+		{"tls", urlErr(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}), ErrKindTLSError},
+		{"tls untyped", errors.New("remote error: tls: handshake failure"), ErrKindTLSError},
+		{"read timeout", urlErr(opErr("read", os.ErrDeadlineExceeded)), ErrKindReadTimeout},
+		{"client timeout", urlErr(context.DeadlineExceeded), ErrKindReadTimeout},
+		{"reset", fmt.Errorf("g.Do %w", urlErr(opErr("read", &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}))), ErrKindResetByPeer},
+		{"closed", pkgerrors.WithStack(urlErr(io.EOF)), ErrKindConnectionClosed},
+		{"canceled", urlErr(context.Canceled), ErrKindCanceled},
+		{"proto", errors.New(`net/http: HTTP/1.x transport connection broken: malformed HTTP response "xx"`), ErrKindProtoError},
+		{"explicit kind", fmt.Errorf("postprocessor %w", WithErrorKind(io.EOF, ErrKindAssertionFailed)), ErrKindAssertionFailed},
+		{"unknown", errors.New("something"), ErrKindUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyError(tt.err))
+		})
+	}
+}
+
+func TestSetErrKind(t *testing.T) {
+	s := Acquire("")
+	s.SetErr(fmt.Errorf("g.Do %w", opErrConnRefused()))
+	assert.Equal(t, ErrKindConnectRefused, s.ErrKind())
+	// Wrapped errno is not lost.
+	assert.Equal(t, int(syscall.ECONNREFUSED), s.NetCode())
+
+	data, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"error_kind":"connect_refused"`)
+}
+
+func opErrConnRefused() error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+}
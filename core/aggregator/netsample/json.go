@@ -0,0 +1,135 @@
+package netsample
+
+import (
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// MarshalJSON encodes sample as flat JSON object, so jsonlines aggregator output
+// can be read back by lib/phout. Example:
+// {"ts":1335524833.562001,"tag":"get|index","id":42,"rtt_us":1503,...,"proto_code":200}
+// Timestamp is UNIX time in seconds with microsecond precision. Durations are in microseconds.
+// Labels and metrics are written as nested objects: "labels":{"region":"eu"},"metrics":{"items":3}.
+// If shot scheduled time is known, response time since scheduled shot start is written as "response_us".
+// Error kind is written as "error_kind".
+// Zero id, nil error, empty error kind, empty labels and metrics and not finite metric values are omitted.
+func (s *Sample) MarshalJSON() ([]byte, error) {
+	return appendJSON(s, make([]byte, 0, 256)), nil
+}
+
+var jsonFieldNames = [fieldsNum]string{
+	keyRTTMicro:           "rtt_us",
+	keyConnectMicro:       "connect_us",
+	keySendMicro:          "send_us",
+	keyLatencyMicro:       "latency_us",
+	keyReceiveMicro:       "receive_us",
+	keyIntervalEventMicro: "interval_event_us",
+	keyRequestBytes:       "request_bytes",
+	keyResponseBytes:      "response_bytes",
+	keyErrno:              "net_code",
+	keyProtoCode:          "proto_code",
+	keyDNSMicro:           "dns_us",
+	keyTCPConnectMicro:    "tcp_connect_us",
+	keyTLSHandshakeMicro:  "tls_handshake_us",
+	keyTTFBMicro:          "ttfb_us",
+}
+
+func appendJSON(s *Sample, dst []byte) []byte {
+	dst = append(dst, `{"ts":`...)
+	micros := s.timeStamp.UnixMicro()
+	dst = strconv.AppendInt(dst, micros/1e6, 10)
+	dst = append(dst, '.')
+	frac := micros % 1e6
+	for div := int64(1e5); div > 0; div /= 10 {
+		dst = append(dst, byte('0'+frac/div%10))
+	}
+	dst = append(dst, `,"tag":`...)
+	dst = appendJSONString(dst, s.tags)
+	if s.id != 0 {
+		dst = append(dst, `,"id":`...)
+		dst = strconv.AppendUint(dst, s.id, 10)
+	}
+	for k, v := range s.fields {
+		dst = append(dst, ',', '"')
+		dst = append(dst, jsonFieldNames[k]...)
+		dst = append(dst, '"', ':')
+		dst = strconv.AppendInt(dst, int64(v), 10)
+	}
+	if !s.scheduled.IsZero() {
+		dst = append(dst, `,"response_us":`...)
+		dst = strconv.AppendInt(dst, s.ResponseTime().Microseconds(), 10)
+	}
+	if s.err != nil {
+		dst = append(dst, `,"error":`...)
+		dst = appendJSONString(dst, s.err.Error())
+	}
+	if s.errKind != "" {
+		dst = append(dst, `,"error_kind":`...)
+		dst = appendJSONString(dst, s.errKind)
+	}
+	if len(s.labels) > 0 {
+		dst = append(dst, `,"labels":{`...)
+		for i, l := range s.labels {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, l.Key)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, l.Value)
+		}
+		dst = append(dst, '}')
+	}
+	if len(s.metrics) > 0 {
+		dst = append(dst, `,"metrics":{`...)
+		first := true
+		for _, m := range s.metrics {
+			if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+				continue
+			}
+			if !first {
+				dst = append(dst, ',')
+			}
+			first = false
+			dst = appendJSONString(dst, m.Name)
+			dst = append(dst, ':')
+			dst = strconv.AppendFloat(dst, m.Value, 'g', -1, 64)
+		}
+		dst = append(dst, '}')
+	}
+	return append(dst, '}')
+}
+
+func appendJSONString(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				dst = append(dst, `\ufffd`...)
+			} else {
+				dst = append(dst, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+		switch {
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c == '\n':
+			dst = append(dst, '\\', 'n')
+		case c == '\t':
+			dst = append(dst, '\\', 't')
+		case c == '\r':
+			dst = append(dst, '\\', 'r')
+		case c < 0x20:
+			dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		default:
+			dst = append(dst, c)
+		}
+		i++
+	}
+	return append(dst, '"')
+}
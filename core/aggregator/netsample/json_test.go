@@ -0,0 +1,103 @@
+package netsample
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/lib/phout"
+)
+
+func TestSampleMarshalJSON(t *testing.T) {
+	s := Acquire("tag1")
+	s.timeStamp = time.Unix(1484660999, 2001*int64(time.Microsecond)+999)
+	s.AddTag("\"quoted\"\t\x01")
+	s.SetID(42)
+	s.SetUserDuration(333 * time.Millisecond)
+	s.SetLatency(3 * time.Microsecond)
+	s.SetDNSTime(4 * time.Microsecond)
+	s.SetTTFB(5 * time.Microsecond)
+	s.SetRequestBytes(6)
+	s.SetResponseBytes(7)
+	s.SetUserProto(200)
+	s.err = errors.New("some error")
+
+	data, err := jsoniter.Marshal(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ts":1484660999.002001,"tag":"tag1|\"quoted\"\t\u0001","id":42,"rtt_us":333000,`+
+		`"connect_us":0,"send_us":0,"latency_us":3,"receive_us":0,"interval_event_us":0,`+
+		`"request_bytes":6,"response_bytes":7,"net_code":0,"proto_code":200,"dns_us":4,"tcp_connect_us":0,`+
+		`"tls_handshake_us":0,"ttfb_us":5,"error":"some error"}`, string(data))
+	stdData, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(stdData))
+
+	var rec phout.Record
+	require.NoError(t, phout.ParseJSONLine(data, &rec))
+	assert.Equal(t, phout.Record{
+		Time:          time.Unix(1484660999, 2001*int64(time.Microsecond)),
+		Tag:           s.Tags(),
+		ID:            42,
+		RTT:           333 * time.Millisecond,
+		Latency:       3 * time.Microsecond,
+		RequestBytes:  6,
+		ResponseBytes: 7,
+		ProtoCode:     200,
+		DNSTime:       4 * time.Microsecond,
+		TTFB:          5 * time.Microsecond,
+		Error:         "some error",
+	}, rec)
+}
+
+func TestSampleMarshalJSONOmitsEmpty(t *testing.T) {
+	s := Acquire("")
+	s.timeStamp = time.Unix(10, 0)
+	data, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"ts":10.000000,"tag":"","rtt_us":0,"connect_us":0,"send_us":0,"latency_us":0,`+
+		`"receive_us":0,"interval_event_us":0,"request_bytes":0,"response_bytes":0,"net_code":0,"proto_code":0,`+
+		`"dns_us":0,"tcp_connect_us":0,"tls_handshake_us":0,"ttfb_us":0}`, string(data))
+}
+
+func TestSampleMarshalJSONResponseTime(t *testing.T) {
+	s := Acquire("")
+	s.timeStamp = time.Unix(10, 0)
+	s.SetScheduledTime(s.timeStamp.Add(-2 * time.Millisecond))
+	s.SetUserDuration(time.Millisecond)
+	assert.Equal(t, time.Millisecond, s.ServiceTime())
+	assert.Equal(t, 3*time.Millisecond, s.ResponseTime())
+
+	data, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `,"response_us":3000`)
+	var rec phout.Record
+	require.NoError(t, phout.ParseJSONLine(data, &rec))
+	assert.Equal(t, time.Millisecond, rec.RTT)
+	assert.Equal(t, 3*time.Millisecond, rec.ResponseTime)
+}
+
+func TestSampleMarshalJSONLabelsAndMetrics(t *testing.T) {
+	s := Acquire("tag")
+	s.timeStamp = time.Unix(10, 0)
+	s.SetLabel("region", "eu")
+	s.SetLabel("server", "nginx")
+	s.SetLabel("region", "us")
+	s.SetMetric("items", 3)
+	s.SetMetric("nan", math.NaN())
+	s.SetMetric("ratio", 0.25)
+	assert.Equal(t, []Label{{"region", "us"}, {"server", "nginx"}}, s.Labels())
+
+	data, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `,"labels":{"region":"us","server":"nginx"},"metrics":{"items":3,"ratio":0.25}}`)
+
+	var rec phout.Record
+	require.NoError(t, phout.ParseJSONLine(data, &rec))
+	assert.Equal(t, map[string]string{"region": "us", "server": "nginx"}, rec.Labels)
+	assert.Equal(t, map[string]float64{"items": 3, "ratio": 0.25}, rec.Metrics)
+}
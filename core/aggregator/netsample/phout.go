@@ -12,21 +12,39 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator"
 	"github.com/yandex/pandora/core/coreutil"
+	"github.com/yandex/pandora/lib/errutil"
 )
 
 type PhoutConfig struct {
-	Destination     string                    // Destination file name
-	ID              bool                      // Print ammo ids if true.
-	FlushTime       time.Duration             `config:"flush-time"`
-	SampleQueueSize int                       `config:"sample-queue-size"`
-	Buffer          coreutil.BufferSizeConfig `config:",squash"`
+	Destination string // Destination file name
+	ID          bool   // Print ammo ids if true.
+	// Sink is used instead of Destination, if set. For example, file sink with rotation or compression.
+	Sink core.DataSink `config:"sink"`
+	// ExtendedTimings appends DNS, TCP connect, TLS handshake and TTFB columns.
+	// Such phout is not compatible with Yandex.Tank.
+	ExtendedTimings bool `config:"extended-timings"`
+	// ErrorKind appends error kind column. "-" is written for samples without error kind.
+	// Such phout is not compatible with Yandex.Tank.
+	ErrorKind bool `config:"error-kind"`
+	// RTT is service or response. With response, rtt column is time since scheduled shot start,
+	// and timestamp column is scheduled time.
+	RTT             string        `config:"rtt" validate:"oneof=service response"`
+	FlushTime       time.Duration `config:"flush-time"`
+	SampleQueueSize int           `config:"sample-queue-size"`
+	// OnOverflow is block, drop or spill. See aggregator.ReporterConfig for details.
+	OnOverflow string                    `config:"on-overflow" validate:"oneof=drop block spill"`
+	SpillDir   string                    `config:"spill-dir"`
+	Buffer     coreutil.BufferSizeConfig `config:",squash"`
 }
 
 func DefaultPhoutConfig() PhoutConfig {
 	return PhoutConfig{
 		FlushTime:       time.Second,
 		SampleQueueSize: 256 * 1024,
+		RTT:             RTTService,
+		OnOverflow:      aggregator.OverflowBlock,
 		Buffer: coreutil.BufferSizeConfig{
 			BufferSize: 8 * datasize.MB,
 		},
@@ -34,46 +52,74 @@ func DefaultPhoutConfig() PhoutConfig {
 }
 
 func NewPhout(fs afero.Fs, conf PhoutConfig) (a Aggregator, err error) {
-	filename := conf.Destination
-	var file afero.File = os.Stdout
-	if filename != "" {
+	var file io.WriteCloser = os.Stdout
+	switch {
+	case conf.Sink != nil:
+		file, err = conf.Sink.OpenSink()
+	case conf.Destination != "":
 		file, err = fs.Create(conf.Destination)
 	}
 	if err != nil {
 		err = errors.Wrap(err, "phout output file open failed")
 		return
 	}
+	reporter := aggregator.NewReporter(aggregator.ReporterConfig{
+		SampleQueueSize: conf.SampleQueueSize,
+		OnOverflow:      conf.OnOverflow,
+		SpillDir:        conf.SpillDir,
+	})
+	reporter.EnableSpill(func(w io.Writer, _ func()) aggregator.SampleEncoder {
+		return &phoutEncoder{
+			writer: bufio.NewWriter(w),
+			format: conf.format(),
+		}
+	})
 	a = &phoutAggregator{
-		config: conf,
-		sink:   make(chan *Sample, conf.SampleQueueSize),
-		writer: bufio.NewWriterSize(file, conf.Buffer.BufferSizeOrDefault()),
-		buf:    make([]byte, 0, 1024),
-		file:   file,
+		format:   conf.format(),
+		reporter: reporter,
+		writer:   bufio.NewWriterSize(file, conf.Buffer.BufferSizeOrDefault()),
+		buf:      make([]byte, 0, 1024),
+		file:     file,
 	}
 	return
 }
 
+func (conf PhoutConfig) format() phoutFormat {
+	return phoutFormat{
+		id:       conf.ID,
+		extended: conf.ExtendedTimings,
+		errKind:  conf.ErrorKind,
+		response: conf.RTT == RTTResponse,
+	}
+}
+
 type phoutAggregator struct {
-	config PhoutConfig
-	sink   chan *Sample
-	writer *bufio.Writer
-	buf    []byte
-	file   io.Closer
+	format   phoutFormat
+	reporter *aggregator.Reporter
+	writer   *bufio.Writer
+	buf      []byte
+	file     io.Closer
 }
 
-func (a *phoutAggregator) Report(s *Sample) { a.sink <- s }
+func (a *phoutAggregator) Report(s *Sample) { a.reporter.Report(s) }
 
-func (a *phoutAggregator) Run(ctx context.Context, _ core.AggregatorDeps) error {
+func (a *phoutAggregator) Run(ctx context.Context, deps core.AggregatorDeps) (err error) {
 	shouldFlush := time.NewTicker(1 * time.Second)
 	defer func() {
+		_ = a.writer.Flush()
+		err = errutil.Join(err, a.reporter.CopySpilled(a.writer))
 		_ = a.writer.Flush()
 		_ = a.file.Close()
 		shouldFlush.Stop()
+		err = errutil.Join(err, a.reporter.DroppedErr())
+		if deps.Log != nil {
+			a.reporter.LogOverflow(deps.Log)
+		}
 	}()
 loop:
 	for {
 		select {
-		case r := <-a.sink:
+		case r := <-a.reporter.Incomming:
 			if err := a.handle(r); err != nil {
 				return err
 			}
@@ -88,7 +134,7 @@ loop:
 			// Context is done, but we should read all data from sink
 			for {
 				select {
-				case r := <-a.sink:
+				case r := <-a.reporter.Incomming:
 					if err := a.handle(r); err != nil {
 						return err
 					}
@@ -101,8 +147,9 @@ loop:
 	return nil
 }
 
-func (a *phoutAggregator) handle(s *Sample) error {
-	a.buf = appendPhout(s, a.buf, a.config.ID)
+func (a *phoutAggregator) handle(sample core.Sample) error {
+	s := sample.(*Sample)
+	a.buf = appendPhout(s, a.buf, a.format)
 	a.buf = append(a.buf, '\n')
 	_, err := a.writer.Write(a.buf)
 	a.buf = a.buf[:0]
@@ -112,18 +159,62 @@ func (a *phoutAggregator) handle(s *Sample) error {
 
 const phoutDelimiter = '\t'
 
-func appendPhout(s *Sample, dst []byte, id bool) []byte {
-	dst = appendTimestamp(s.timeStamp, dst)
+// phoutEncoder encodes samples spilled on queue overflow.
+type phoutEncoder struct {
+	writer *bufio.Writer
+	buf    []byte
+	format phoutFormat
+}
+
+func (e *phoutEncoder) Encode(sample core.Sample) error {
+	s := sample.(*Sample)
+	e.buf = appendPhout(s, e.buf[:0], e.format)
+	e.buf = append(e.buf, '\n')
+	_, err := e.writer.Write(e.buf)
+	releaseSample(s)
+	return err
+}
+
+func (e *phoutEncoder) Flush() error { return e.writer.Flush() }
+
+type phoutFormat struct {
+	id       bool
+	extended bool
+	errKind  bool
+	// response makes rtt column response time, and timestamp column scheduled time,
+	// so timestamp + rtt is still response end time.
+	response bool
+}
+
+func appendPhout(s *Sample, dst []byte, format phoutFormat) []byte {
+	ts, fields := s.timeStamp, s.fields
+	if format.response {
+		ts = s.ScheduledTime()
+		fields[keyRTTMicro] = int(s.ResponseTime().Microseconds())
+	}
+	dst = appendTimestamp(ts, dst)
 	dst = append(dst, phoutDelimiter)
 	dst = append(dst, s.tags...)
-	if id {
+	if format.id {
 		dst = append(dst, '#')
 		dst = strconv.AppendInt(dst, int64(s.ID()), 10)
 	}
-	for _, v := range s.fields {
+	n := phoutFieldsNum
+	if format.extended {
+		n = fieldsNum
+	}
+	for _, v := range fields[:n] {
 		dst = append(dst, phoutDelimiter)
 		dst = strconv.AppendInt(dst, int64(v), 10)
 	}
+	if format.errKind {
+		dst = append(dst, phoutDelimiter)
+		if s.errKind == "" {
+			dst = append(dst, '-')
+		} else {
+			dst = append(dst, s.errKind...)
+		}
+	}
 	return dst
 }
 
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/datasink"
 )
 
 func TestPhout(t *testing.T) {
@@ -18,6 +19,7 @@ func TestPhout(t *testing.T) {
 	tests := []struct {
 		name      string
 		resetConf func(cfg *PhoutConfig)
+		sample    func() *Sample
 		reportCnt int
 		want      string
 	}{
@@ -34,6 +36,50 @@ func TestPhout(t *testing.T) {
 			reportCnt: 1,
 			want:      testSamplePhout + "\n",
 		},
+		{
+			name: "extended timings",
+			resetConf: func(cfg *PhoutConfig) {
+				cfg.ExtendedTimings = true
+			},
+			reportCnt: 1,
+			want:      testSampleNoIDPhout + "\t1\t2\t3\t4\n",
+		},
+		{
+			name: "error kind",
+			resetConf: func(cfg *PhoutConfig) {
+				cfg.ErrorKind = true
+			},
+			sample: func() *Sample {
+				s := newTestSample()
+				s.SetErrKind(ErrKindReadTimeout)
+				return s
+			},
+			reportCnt: 1,
+			want:      testSampleNoIDPhout + "\tread_timeout\n",
+		},
+		{
+			name: "response rtt",
+			resetConf: func(cfg *PhoutConfig) {
+				cfg.RTT = RTTResponse
+			},
+			sample: func() *Sample {
+				s := newTestSample()
+				s.SetScheduledTime(s.timeStamp.Add(-time.Second))
+				return s
+			},
+			reportCnt: 1,
+			want:      "1484660998.002	tag1|tag2	1333333	0	0	0	0	0	0	0	13	999\n",
+		},
+		{
+			name: "service rtt ignores schedule",
+			sample: func() *Sample {
+				s := newTestSample()
+				s.SetScheduledTime(s.timeStamp.Add(-time.Second))
+				return s
+			},
+			reportCnt: 1,
+			want:      testSampleNoIDPhout + "\n",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -53,8 +99,12 @@ func TestPhout(t *testing.T) {
 				runErr <- testee.Run(ctx, core.AggregatorDeps{})
 			}()
 
+			newSample := newTestSample
+			if tt.sample != nil {
+				newSample = tt.sample
+			}
 			for i := 0; i < tt.reportCnt; i++ {
-				testee.Report(newTestSample())
+				testee.Report(newSample())
 			}
 			cancel()
 			err = <-runErr
@@ -68,6 +118,25 @@ func TestPhout(t *testing.T) {
 	}
 }
 
+func TestPhout_Sink(t *testing.T) {
+	sink := datasink.NewBuffer()
+	conf := DefaultPhoutConfig()
+	conf.Destination = "ignored.txt"
+	conf.Sink = sink
+	fs := afero.NewMemMapFs()
+	testee, err := NewPhout(fs, conf)
+	require.NoError(t, err)
+	testee.Report(newTestSample())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, testee.Run(ctx, core.AggregatorDeps{}))
+
+	assert.Equal(t, testSampleNoIDPhout+"\n", sink.String())
+	exists, err := afero.Exists(fs, conf.Destination)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
 const (
 	testSamplePhout     = "1484660999.002	tag1|tag2#42	333333	0	0	0	0	0	0	0	13	999"
 	testSampleNoIDPhout = "1484660999.002	tag1|tag2	333333	0	0	0	0	0	0	0	13	999"
@@ -81,5 +150,9 @@ func newTestSample() *Sample {
 	s.setDuration(keyRTTMicro, time.Second/3)
 	s.set(keyErrno, 13)
 	s.set(keyProtoCode, ProtoCodeError)
+	s.SetDNSTime(1 * time.Microsecond)
+	s.SetTCPConnectTime(2 * time.Microsecond)
+	s.SetTLSHandshakeTime(3 * time.Microsecond)
+	s.SetTTFB(4 * time.Microsecond)
 	return s
 }
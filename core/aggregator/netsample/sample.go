@@ -17,9 +17,17 @@ const (
 	DiscardedShootTag       = "discarded"
 )
 
+// RTT modes of aggregator output.
+const (
+	// RTTService is time since actual shot start. See Sample.ServiceTime.
+	RTTService = "service"
+	// RTTResponse is time since scheduled shot start. See Sample.ResponseTime.
+	RTTResponse = "response"
+)
+
 const (
 	keyRTTMicro     = iota
-	keyConnectMicro // TODO (skipor): set all for HTTP using httptrace and helper structs
+	keyConnectMicro // Time of getting connection: DNS, TCP connect and TLS handshake.
 	keySendMicro
 	keyLatencyMicro
 	keyReceiveMicro
@@ -28,9 +36,17 @@ const (
 	keyResponseBytes
 	keyErrno
 	keyProtoCode
+	// Connection timings breakdown. Not written to phout, unless extended timings are enabled.
+	keyDNSMicro
+	keyTCPConnectMicro
+	keyTLSHandshakeMicro
+	keyTTFBMicro
 	fieldsNum
 )
 
+// phoutFieldsNum is number of fields in Yandex.Tank compatible phout format.
+const phoutFieldsNum = keyProtoCode + 1
+
 func Acquire(tag string) *Sample {
 	s := samplePool.Get().(*Sample)
 	*s = Sample{
@@ -46,12 +62,44 @@ var samplePool = &sync.Pool{New: func() interface{} { return &Sample{} }}
 
 type Sample struct {
 	timeStamp time.Time
+	scheduled time.Time
 	tags      string
 	id        uint64
 	fields    [fieldsNum]int
 	err       error
+	errKind   string
+	labels    []Label
+	metrics   []Metric
+}
+
+// Label is custom key/value sample attribute. For example: tenant, region or server header.
+type Label struct {
+	Key   string
+	Value string
+}
+
+// Metric is custom numeric sample value. For example: number of returned items or cache hit.
+type Metric struct {
+	Name  string
+	Value float64
 }
 
+func (s *Sample) Timestamp() time.Time { return s.timeStamp }
+
+// SetScheduledTime sets time, when shot was scheduled by load profile. See core.GunDeps.ScheduledTime.
+func (s *Sample) SetScheduledTime(t time.Time) { s.scheduled = t }
+
+// ScheduledTime returns time, when shot was scheduled, or Timestamp, if it is unknown.
+func (s *Sample) ScheduledTime() time.Time {
+	if s.scheduled.IsZero() || s.scheduled.After(s.timeStamp) {
+		return s.timeStamp
+	}
+	return s.scheduled
+}
+
+// ScheduleDelay returns how much shot start was behind schedule.
+func (s *Sample) ScheduleDelay() time.Duration { return s.timeStamp.Sub(s.ScheduledTime()) }
+
 func (s *Sample) Tags() string { return s.tags }
 func (s *Sample) AddTag(tag string) {
 	if s.tags == "" {
@@ -61,6 +109,34 @@ func (s *Sample) AddTag(tag string) {
 	s.tags += "|" + tag
 }
 
+// SetLabel sets label value. Labels are written by jsonlines, clickhouse, statsd and graphite aggregators, but not by phout.
+func (s *Sample) SetLabel(key, value string) {
+	for i := range s.labels {
+		if s.labels[i].Key == key {
+			s.labels[i].Value = value
+			return
+		}
+	}
+	s.labels = append(s.labels, Label{Key: key, Value: value})
+}
+
+// Labels returns labels in order of first set. Returned slice should not be modified.
+func (s *Sample) Labels() []Label { return s.labels }
+
+// SetMetric sets custom metric value. Metrics are written by jsonlines, clickhouse, statsd and graphite aggregators, but not by phout.
+func (s *Sample) SetMetric(name string, value float64) {
+	for i := range s.metrics {
+		if s.metrics[i].Name == name {
+			s.metrics[i].Value = value
+			return
+		}
+	}
+	s.metrics = append(s.metrics, Metric{Name: name, Value: value})
+}
+
+// Metrics returns custom metrics in order of first set. Returned slice should not be modified.
+func (s *Sample) Metrics() []Metric { return s.metrics }
+
 func (s *Sample) ID() uint64      { return s.id }
 func (s *Sample) SetID(id uint64) { s.id = id }
 
@@ -71,15 +147,26 @@ func (s *Sample) SetProtoCode(code int) {
 }
 
 func (s *Sample) Err() error { return s.err }
+
+// SetErr sets error, its net code, and its kind, classified by ClassifyError.
 func (s *Sample) SetErr(err error) {
 	s.err = err
+	s.errKind = ClassifyError(err)
 	s.set(keyErrno, getErrno(err))
 	s.setRTT()
 }
 
+// ErrKind returns error kind. Empty, if sample is not failed, or failed only by protocol code.
+func (s *Sample) ErrKind() string { return s.errKind }
+
+// SetErrKind overrides error kind. Useful for failures, that are not reported via SetErr.
+// For example, for gRPC status errors.
+func (s *Sample) SetErrKind(kind string) { s.errKind = kind }
+
 func (s *Sample) get(k int) int                      { return s.fields[k] }
 func (s *Sample) set(k, v int)                       { s.fields[k] = v }
 func (s *Sample) setDuration(k int, d time.Duration) { s.set(k, int(d.Nanoseconds()/1000)) }
+func (s *Sample) getDuration(k int) time.Duration    { return time.Duration(s.get(k)) * time.Microsecond }
 func (s *Sample) setRTT() {
 	if s.get(keyRTTMicro) == 0 {
 		s.setDuration(keyRTTMicro, time.Since(s.timeStamp))
@@ -114,6 +201,23 @@ func (s *Sample) SetReceiveTime(d time.Duration) {
 	s.setDuration(keyReceiveMicro, d)
 }
 
+func (s *Sample) SetDNSTime(d time.Duration) {
+	s.setDuration(keyDNSMicro, d)
+}
+
+func (s *Sample) SetTCPConnectTime(d time.Duration) {
+	s.setDuration(keyTCPConnectMicro, d)
+}
+
+func (s *Sample) SetTLSHandshakeTime(d time.Duration) {
+	s.setDuration(keyTLSHandshakeMicro, d)
+}
+
+// SetTTFB sets time to first byte: time since start of getting connection till first response byte.
+func (s *Sample) SetTTFB(d time.Duration) {
+	s.setDuration(keyTTFBMicro, d)
+}
+
 func (s *Sample) SetRequestBytes(b int) {
 	s.set(keyRequestBytes, b)
 }
@@ -122,13 +226,34 @@ func (s *Sample) SetResponseBytes(b int) {
 	s.set(keyResponseBytes, b)
 }
 
+func (s *Sample) RTT() time.Duration              { return s.getDuration(keyRTTMicro) }
+func (s *Sample) ConnectTime() time.Duration      { return s.getDuration(keyConnectMicro) }
+func (s *Sample) SendTime() time.Duration         { return s.getDuration(keySendMicro) }
+func (s *Sample) Latency() time.Duration          { return s.getDuration(keyLatencyMicro) }
+func (s *Sample) ReceiveTime() time.Duration      { return s.getDuration(keyReceiveMicro) }
+func (s *Sample) IntervalEvent() time.Duration    { return s.getDuration(keyIntervalEventMicro) }
+func (s *Sample) DNSTime() time.Duration          { return s.getDuration(keyDNSMicro) }
+func (s *Sample) TCPConnectTime() time.Duration   { return s.getDuration(keyTCPConnectMicro) }
+func (s *Sample) TLSHandshakeTime() time.Duration { return s.getDuration(keyTLSHandshakeMicro) }
+func (s *Sample) TTFB() time.Duration             { return s.getDuration(keyTTFBMicro) }
+func (s *Sample) RequestBytes() int               { return s.get(keyRequestBytes) }
+func (s *Sample) ResponseBytes() int              { return s.get(keyResponseBytes) }
+func (s *Sample) NetCode() int                    { return s.get(keyErrno) }
+
+// ServiceTime is time since actual shot start till response. Same as RTT.
+func (s *Sample) ServiceTime() time.Duration { return s.RTT() }
+
+// ResponseTime is time since scheduled shot start till response. Unlike ServiceTime, it includes
+// delay of instance behind schedule, so tail latency is not understated due to coordinated omission.
+func (s *Sample) ResponseTime() time.Duration { return s.RTT() + s.ScheduleDelay() }
+
 func (s *Sample) String() string {
-	return string(appendPhout(s, nil, true))
+	return string(appendPhout(s, nil, phoutFormat{id: true}))
 }
 
 func getErrno(err error) int {
-	//
-	if e, ok := err.(net.Error); ok && e.Timeout() {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
 		return 110 // Handle client Timeout as if it connection timeout
 	}
 	// stackerr.Error and etc.
@@ -154,6 +279,11 @@ func getErrno(err error) int {
 		case syscall.Errno:
 			return int(typed)
 		default:
+			// Errors wrapped with fmt.Errorf %w.
+			var errno syscall.Errno
+			if errors.As(err, &errno) {
+				return int(errno)
+			}
 			// Legacy default.
 			return ProtoCodeError
 		}
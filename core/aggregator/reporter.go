@@ -2,17 +2,35 @@ package aggregator
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/coreutil"
+	"github.com/yandex/pandora/lib/monitoring"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 )
 
+// Queue overflow modes.
+const (
+	// OverflowDrop drops sample. Shooting is never slowed down by aggregator.
+	OverflowDrop = "drop"
+	// OverflowBlock blocks Report until there is room in the queue. No sample is lost,
+	// but slow aggregator slows down shooting.
+	OverflowBlock = "block"
+	// OverflowSpill writes sample to temporary file, that is appended to aggregator output at the end.
+	// Supported only by aggregators, that call Reporter.EnableSpill. Others block.
+	OverflowSpill = "spill"
+)
+
 type ReporterConfig struct {
 	// SampleQueueSize is number maximum number of unhandled samples.
-	// On queue overflow, samples are dropped.
+	// On queue overflow, OnOverflow is applied.
 	SampleQueueSize int `config:"sample-queue-size" validate:"min=1"`
+	// OnOverflow is drop, block or spill.
+	OnOverflow string `config:"on-overflow" validate:"oneof=drop block spill"`
+	// SpillDir is directory for spill file. System temporary directory by default.
+	SpillDir string `config:"spill-dir"`
 }
 
 const (
@@ -22,15 +40,25 @@ const (
 	DefaultSampleQueueSize                 = 2 * samplesInQueueAfterDiskWriteUpperBound
 )
 
+// Overflow metrics of all reporters. Published via expvar.
+var (
+	samplesDroppedMetric = monitoring.NewCounter("aggregator_SamplesDropped")
+	blockedMetric        = monitoring.NewCounter("aggregator_BlockedMicroseconds")
+	samplesSpilledMetric = monitoring.NewCounter("aggregator_SamplesSpilled")
+)
+
 func DefaultReporterConfig() ReporterConfig {
 	return ReporterConfig{
 		SampleQueueSize: DefaultSampleQueueSize,
+		OnOverflow:      OverflowDrop,
 	}
 }
 
 func NewReporter(conf ReporterConfig) *Reporter {
 	return &Reporter{
-		Incomming: make(chan core.Sample, conf.SampleQueueSize),
+		Incomming:  make(chan core.Sample, conf.SampleQueueSize),
+		onOverflow: conf.OnOverflow,
+		spillDir:   conf.SpillDir,
 	}
 }
 
@@ -38,6 +66,42 @@ type Reporter struct {
 	Incomming          chan core.Sample
 	samplesDropped     atomic.Int64
 	lastSampleDropWarn atomic.Int64
+	blockedNanos       atomic.Int64
+	onOverflow         string
+	spillDir           string
+	spill              *spill
+}
+
+// OverflowStats describes what happened with samples, that didn't fit into queue.
+type OverflowStats struct {
+	Dropped int64
+	Blocked time.Duration
+	Spilled int64
+	// SpilledBytes is number of bytes written to spill file. Encoder buffer is not counted.
+	SpilledBytes int64
+}
+
+func (a *Reporter) OverflowStats() OverflowStats {
+	stats := OverflowStats{
+		Dropped: a.samplesDropped.Load(),
+		Blocked: time.Duration(a.blockedNanos.Load()),
+	}
+	if a.spill != nil {
+		stats.Spilled, stats.SpilledBytes = a.spill.stats()
+	}
+	return stats
+}
+
+// LogOverflow logs overflow stats, if any sample didn't fit into queue.
+func (a *Reporter) LogOverflow(log *zap.Logger) {
+	stats := a.OverflowStats()
+	if stats.Blocked > 0 {
+		log.Info("Sample reporting was blocked by queue overflow", zap.Duration("blocked", stats.Blocked))
+	}
+	if stats.Spilled > 0 {
+		log.Info("Samples were spilled to disk on queue overflow",
+			zap.Int64("samples", stats.Spilled), zap.Int64("bytes", stats.SpilledBytes))
+	}
 }
 
 func (a *Reporter) DroppedErr() error {
@@ -51,6 +115,20 @@ func (a *Reporter) DroppedErr() error {
 func (a *Reporter) Report(s core.Sample) {
 	select {
 	case a.Incomming <- s:
+		return
+	default:
+	}
+	switch {
+	case a.onOverflow == OverflowSpill && a.spill != nil:
+		if err := a.spill.write(s); err != nil {
+			a.dropSample(s)
+		}
+	case a.onOverflow == OverflowBlock || a.onOverflow == OverflowSpill:
+		start := time.Now()
+		a.Incomming <- s
+		blocked := time.Since(start)
+		a.blockedNanos.Add(int64(blocked))
+		blockedMetric.Add(blocked.Microseconds())
 	default:
 		a.dropSample(s)
 	}
@@ -58,6 +136,7 @@ func (a *Reporter) Report(s core.Sample) {
 
 func (a *Reporter) dropSample(s core.Sample) {
 	dropped := a.samplesDropped.Inc()
+	samplesDroppedMetric.Add(1)
 	if dropped == 1 {
 		// AggregatorDeps may not be passed, because Run was not called.
 		zap.L().Warn("First sample is dropped. More information in Run error")
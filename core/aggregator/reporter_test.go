@@ -1,7 +1,10 @@
 package aggregator
 
 import (
+	"bytes"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -15,7 +18,7 @@ func TestReporter_DroppedErr(t *testing.T) {
 	core, entries := observer.New(zap.DebugLevel)
 	zap.ReplaceGlobals(zap.New(core))
 	defer testutil.ReplaceGlobalLogger()
-	reporter := NewReporter(ReporterConfig{1})
+	reporter := NewReporter(ReporterConfig{SampleQueueSize: 1})
 	reporter.Report(1)
 
 	assert.NoError(t, reporter.DroppedErr())
@@ -28,7 +31,7 @@ func TestReporter_DroppedErr(t *testing.T) {
 }
 
 func TestReporter_BorrowedSampleReturnedOnDrop(t *testing.T) {
-	reporter := NewReporter(ReporterConfig{1})
+	reporter := NewReporter(ReporterConfig{SampleQueueSize: 1})
 
 	reporter.Report(1)
 	borrowed := &coremock.BorrowedSample{}
@@ -37,3 +40,39 @@ func TestReporter_BorrowedSampleReturnedOnDrop(t *testing.T) {
 	reporter.Report(borrowed)
 	borrowed.AssertExpectations(t)
 }
+
+func TestReporter_Block(t *testing.T) {
+	reporter := NewReporter(ReporterConfig{SampleQueueSize: 1, OnOverflow: OverflowBlock})
+	reporter.Report(1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-reporter.Incomming
+	}()
+	reporter.Report(2)
+
+	assert.NoError(t, reporter.DroppedErr())
+	assert.Equal(t, 2, <-reporter.Incomming)
+	assert.GreaterOrEqual(t, reporter.OverflowStats().Blocked, 5*time.Millisecond)
+}
+
+func TestReporter_Spill(t *testing.T) {
+	reporter := NewReporter(ReporterConfig{SampleQueueSize: 1, OnOverflow: OverflowSpill, SpillDir: t.TempDir()})
+	reporter.EnableSpill(func(w io.Writer, _ func()) SampleEncoder {
+		return NewJSONEncoder(w, JSONLineEncoderConfig{})
+	})
+	for i := 0; i < 3; i++ {
+		reporter.Report(i)
+	}
+	assert.NoError(t, reporter.DroppedErr())
+	assert.EqualValues(t, 2, reporter.OverflowStats().Spilled)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, reporter.CopySpilled(buf))
+	assert.Equal(t, "1\n2\n", buf.String())
+	assert.EqualValues(t, 4, reporter.OverflowStats().SpilledBytes)
+	assert.Equal(t, 0, <-reporter.Incomming)
+
+	reporter.Report(3)
+	reporter.Report(4)
+	assert.Error(t, reporter.DroppedErr(), "spill is closed after copy")
+}
@@ -0,0 +1,124 @@
+package aggregator
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/coreutil"
+	"go.uber.org/zap"
+)
+
+// EnableSpill makes Reporter write samples, that didn't fit into queue, to temporary file,
+// when OnOverflow is spill. Samples are encoded by encoder created with newEncoder, so spill
+// file content is already in aggregator output format, and should be appended to output
+// with CopySpilled, when all queued samples are handled.
+// Should be called before first Report.
+func (a *Reporter) EnableSpill(newEncoder NewSampleEncoder) {
+	if a.onOverflow != OverflowSpill {
+		return
+	}
+	a.spill = &spill{dir: a.spillDir, newEncoder: newEncoder}
+}
+
+// CopySpilled writes spilled samples to w, and removes spill file.
+// Spilled samples are not merged with handled ones, so caller appends them out of time order.
+// Samples, that overflow queue after CopySpilled call, are dropped.
+func (a *Reporter) CopySpilled(w io.Writer) error {
+	if a.spill == nil {
+		return nil
+	}
+	return a.spill.copyTo(w)
+}
+
+type spill struct {
+	dir        string
+	newEncoder NewSampleEncoder
+
+	mu           sync.Mutex
+	file         *os.File
+	counter      *countingWriter
+	encoder      SampleEncoder
+	closed       bool
+	spilled      int64
+	openFailed   bool
+	encodeFailed bool
+}
+
+func (s *spill) write(sample core.Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.openFailed {
+		return errors.New("spill is not available")
+	}
+	if s.file == nil {
+		file, err := os.CreateTemp(s.dir, "pandora-spill-*")
+		if err != nil {
+			s.openFailed = true
+			zap.L().Error("Spill file create failed. Overflowed samples will be dropped", zap.Error(err))
+			return err
+		}
+		zap.L().Info("Sample queue overflow. Spilling samples to disk", zap.String("file", file.Name()))
+		s.file = file
+		s.counter = &countingWriter{w: file}
+		s.encoder = s.newEncoder(s.counter, func() {})
+	}
+	err := s.encoder.Encode(sample)
+	if err != nil {
+		if !s.encodeFailed {
+			s.encodeFailed = true
+			zap.L().Error("Sample spill failed", zap.Error(err))
+		}
+		return err
+	}
+	coreutil.ReturnSampleIfBorrowed(sample)
+	s.spilled++
+	samplesSpilledMetric.Add(1)
+	return nil
+}
+
+func (s *spill) stats() (samples, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counter != nil {
+		bytes = s.counter.n
+	}
+	return s.spilled, bytes
+}
+
+func (s *spill) copyTo(w io.Writer) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.file == nil {
+		return nil
+	}
+	defer func() {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+	}()
+	// Spill encoder is flushed, but not closed: output encoder writes format trailer after spilled samples.
+	err = s.encoder.Flush()
+	if err != nil {
+		return errors.WithMessage(err, "spill encoder flush failed")
+	}
+	_, err = s.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return errors.WithMessage(err, "spill file seek failed")
+	}
+	_, err = io.Copy(w, s.file)
+	return errors.WithMessage(err, "spilled samples copy failed")
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -105,6 +105,13 @@ type GunDeps struct {
 
 	Shared any
 
+	// ScheduledTime returns time, when current Shoot was scheduled by load profile.
+	// If Instance is behind schedule, it is earlier than Shoot start, and difference is
+	// queueing delay, that is hidden from latency measured since Shoot start.
+	// Gun SHOULD pass it to Sample, to measure response time free of coordinated omission.
+	// MUST be called only during Shoot. MAY be nil, if Gun is run not by engine.
+	ScheduledTime func() time.Time
+
 	// TODO(skipor): https://github.com/yandex/pandora/issues/71
 	// Pass parallelism value. InstanceId MUST be -1 if parallelism > 1.
 }
@@ -154,7 +161,8 @@ type Aggregator interface {
 // WARN: another fields could be added in next MINOR versions.
 // That is NOT considered as a breaking compatibility change.
 type AggregatorDeps struct {
-	Log *zap.Logger
+	Log    *zap.Logger
+	PoolID string
 }
 
 //go:generate mockery --name=Schedule --case=underscore --outpkg=coremock
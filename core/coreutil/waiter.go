@@ -13,6 +13,7 @@ const MaxOverdueDuration = 2 * time.Second
 type Waiter struct {
 	sched           core.Schedule
 	overdueDuration time.Duration
+	scheduled       time.Time
 
 	// Lazy initialized.
 	timer   *time.Timer
@@ -39,6 +40,7 @@ func (w *Waiter) Wait(ctx context.Context) (ok bool) {
 		w.overdueDuration = 0
 		return false
 	}
+	w.scheduled = next
 	// Get current time lazily.
 	// For once schedule, for example, we need to get it only once.
 	waitFor := next.Sub(w.lastNow)
@@ -67,6 +69,12 @@ func (w *Waiter) Wait(ctx context.Context) (ok bool) {
 	}
 }
 
+// Scheduled returns time of last waited schedule event. If waiter is behind schedule,
+// it is earlier than Wait return time.
+func (w *Waiter) Scheduled() time.Time {
+	return w.scheduled
+}
+
 // IsSlowDown returns true, if schedule contains 2 elements before current time.
 func (w *Waiter) IsSlowDown(ctx context.Context) (ok bool) {
 	select {
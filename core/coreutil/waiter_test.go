@@ -63,3 +63,16 @@ func TestWaiter_ContextCanceledDuringWait(t *testing.T) {
 	require.True(t, since > timeout)
 	require.True(t, since < 10*timeout)
 }
+
+func TestWaiter_Scheduled(t *testing.T) {
+	sched := schedule.NewOnce(2)
+	start := time.Now().Add(-time.Second)
+	sched.Start(start)
+	w := NewWaiter(sched)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		require.True(t, w.Wait(ctx))
+		// Waiter is behind schedule, so scheduled time is start, not wait return time.
+		require.Equal(t, start, w.Scheduled())
+	}
+}
@@ -3,15 +3,43 @@ package datasink
 import (
 	"io"
 	"os"
+	"time"
 
+	"github.com/c2h5oh/datasize"
 	"github.com/spf13/afero"
 	"github.com/yandex/pandora/core"
 )
 
-// TODO(skipor): gzip on flag
+const (
+	CompressNone = "none"
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+
+	RotateNamingNumber    = "number"
+	RotateNamingTimestamp = "timestamp"
+)
 
 type FileConfig struct {
 	Path string `config:"path" validate:"required"`
+	// Compress is none, gzip or zstd. Written stream is compressed, so path SHOULD have .gz or .zst extension.
+	Compress string `config:"compress" validate:"oneof=none gzip zstd"`
+	// RotateSize rotates file, when its size on disk reaches this limit. Zero means no size rotation.
+	// With compression, size is checked approximately, because compressor buffers data.
+	RotateSize datasize.ByteSize `config:"rotate-size"`
+	// RotateInterval rotates file, when it is written longer than interval. Zero means no time rotation.
+	RotateInterval time.Duration `config:"rotate-interval"`
+	// RotateNaming is number or timestamp. Rotated phout.log is renamed to phout.1.log, phout.2.log and so on,
+	// or to phout.20061021T150405.log, where timestamp is time, when file was opened.
+	RotateNaming string `config:"rotate-naming" validate:"oneof=number timestamp"`
+	// MaxFiles limits number of rotated files. Oldest are removed. Zero means no limit.
+	MaxFiles int `config:"max-files" validate:"min=0"`
+}
+
+func DefaultFileConfig() FileConfig {
+	return FileConfig{
+		Compress:     CompressNone,
+		RotateNaming: RotateNamingNumber,
+	}
 }
 
 func NewFile(fs afero.Fs, conf FileConfig) core.DataSink {
@@ -24,7 +52,10 @@ type fileSink struct {
 }
 
 func (s *fileSink) OpenSink() (wc io.WriteCloser, err error) {
-	return s.fs.OpenFile(s.conf.Path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if (s.conf.Compress == "" || s.conf.Compress == CompressNone) && s.conf.RotateSize == 0 && s.conf.RotateInterval == 0 {
+		return s.fs.OpenFile(s.conf.Path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	}
+	return newRotatingWriter(s.fs, s.conf, time.Now)
 }
 
 func NewStdout() core.DataSink {
@@ -0,0 +1,215 @@
+package datasink
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
+	"github.com/yandex/pandora/lib/errutil"
+)
+
+const rotateTimestampFormat = "20060102T150405"
+
+// rotatingWriter writes file, that is optionally compressed and rotated by size or time.
+// File is rotated only at line boundary, so lines of phout or JSON Lines are never split between files.
+// Current file is always written to configured path, rotated files are renamed.
+type rotatingWriter struct {
+	fs   afero.Afero
+	conf FileConfig
+	now  func() time.Time
+
+	file       afero.File
+	compressor io.WriteCloser // Nil, if compression is off.
+	out        io.Writer
+	written    int64 // Bytes written to file on disk.
+	dirty      bool  // Something was written to current file.
+	lineStart  bool  // Last written byte is newline.
+	opened     time.Time
+	seq        int
+	rotated    []string // Rotated file names, oldest first.
+}
+
+func newRotatingWriter(fs afero.Afero, conf FileConfig, now func() time.Time) (*rotatingWriter, error) {
+	w := &rotatingWriter{fs: fs, conf: conf, now: now}
+	if conf.RotateNaming != RotateNamingTimestamp {
+		seq, err := lastRotatedSeq(fs, conf.Path)
+		if err != nil {
+			return nil, fmt.Errorf("rotated files lookup failed: %w", err)
+		}
+		w.seq = seq
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// lastRotatedSeq returns the highest number of files rotated by previous runs, so they are not overwritten.
+func lastRotatedSeq(fs afero.Afero, path string) (int, error) {
+	stem, ext := splitExt(path)
+	dir, prefix := filepath.Split(stem)
+	if dir == "" {
+		dir = "."
+	}
+	files, err := fs.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var last int
+	for _, f := range files {
+		name := f.Name()
+		if !strings.HasPrefix(name, prefix+".") || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, prefix+"."), ext))
+		if err == nil && seq > last {
+			last = seq
+		}
+	}
+	return last, nil
+}
+
+func (w *rotatingWriter) open() error {
+	file, err := w.fs.OpenFile(w.conf.Path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.written = 0
+	w.dirty = false
+	w.lineStart = true
+	w.opened = w.now()
+	w.out = &countingWriter{w: file, n: &w.written}
+	w.compressor = nil
+	switch w.conf.Compress {
+	case CompressGzip:
+		w.compressor = gzip.NewWriter(w.out)
+	case CompressZstd:
+		w.compressor, err = zstd.NewWriter(w.out)
+		if err != nil {
+			_ = file.Close()
+			return fmt.Errorf("zstd compressor create failed: %w", err)
+		}
+	}
+	if w.compressor != nil {
+		w.out = w.compressor
+	}
+	return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if w.lineStart && w.dirty && w.rotationDue() {
+			if err := w.rotate(); err != nil {
+				return n, fmt.Errorf("file rotation failed: %w", err)
+			}
+		}
+		chunk := p
+		if w.rotationDue() {
+			// Write only rest of current line, to rotate right after it.
+			if i := bytes.IndexByte(p, '\n'); i >= 0 {
+				chunk = p[:i+1]
+			}
+		}
+		m, err := w.out.Write(chunk)
+		n += m
+		if err != nil {
+			return n, err
+		}
+		w.dirty = true
+		w.lineStart = chunk[len(chunk)-1] == '\n'
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+func (w *rotatingWriter) rotationDue() bool {
+	return w.conf.RotateSize > 0 && w.written >= int64(w.conf.RotateSize) ||
+		w.conf.RotateInterval > 0 && w.now().Sub(w.opened) >= w.conf.RotateInterval
+}
+
+func (w *rotatingWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	name, err := w.rotatedName()
+	if err != nil {
+		return err
+	}
+	if err := w.fs.Rename(w.conf.Path, name); err != nil {
+		return err
+	}
+	w.rotated = append(w.rotated, name)
+	if w.conf.MaxFiles > 0 && len(w.rotated) > w.conf.MaxFiles {
+		if err := w.fs.Remove(w.rotated[0]); err != nil {
+			return err
+		}
+		w.rotated = w.rotated[1:]
+	}
+	return w.open()
+}
+
+func (w *rotatingWriter) rotatedName() (string, error) {
+	stem, ext := splitExt(w.conf.Path)
+	if w.conf.RotateNaming == RotateNamingTimestamp {
+		base := stem + "." + w.opened.Format(rotateTimestampFormat)
+		name := base + ext
+		// Size rotation can happen more than once a second.
+		for i := 1; ; i++ {
+			exists, err := w.fs.Exists(name)
+			if err != nil || !exists {
+				return name, err
+			}
+			name = base + "-" + strconv.Itoa(i) + ext
+		}
+	}
+	w.seq++
+	return stem + "." + strconv.Itoa(w.seq) + ext, nil
+}
+
+// splitExt splits path to stem and extension. Compression extension is kept together with
+// previous one: phout.log.gz is split to phout and .log.gz.
+func splitExt(path string) (stem, ext string) {
+	ext = filepath.Ext(path)
+	stem = strings.TrimSuffix(path, ext)
+	if ext == ".gz" || ext == ".zst" {
+		inner := filepath.Ext(stem)
+		stem = strings.TrimSuffix(stem, inner)
+		ext = inner + ext
+	}
+	return stem, ext
+}
+
+func (w *rotatingWriter) closeFile() error {
+	var err error
+	if w.compressor != nil {
+		err = w.compressor.Close()
+	}
+	return errutil.Join(err, w.file.Close())
+}
+
+func (w *rotatingWriter) Close() error {
+	return w.closeFile()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	*w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,129 @@
+package datasink
+
+import (
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/lib/testutil"
+)
+
+func TestFileSink_RotateSize(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	conf := DefaultFileConfig()
+	conf.Path = "/out/phout.log"
+	conf.RotateSize = 10
+	conf.MaxFiles = 2
+	require.NoError(t, fs.MkdirAll("/out", 0755))
+	wc, err := NewFile(fs, conf).OpenSink()
+	require.NoError(t, err)
+
+	// Lines are not split between files, even if they are written in parts.
+	for _, s := range []string{"line1\nli", "ne2\n", "line3\nline4\n", "line5\n", "line6\nline7\nline8\n", "line9\n"} {
+		_, err = io.WriteString(wc, s)
+		require.NoError(t, err)
+	}
+	require.NoError(t, wc.Close())
+
+	_, err = fs.Stat("/out/phout.1.log")
+	assert.True(t, err != nil, "oldest file should be removed")
+	testutil.AssertFileEqual(t, fs, "/out/phout.2.log", "line3\nline4\n")
+	testutil.AssertFileEqual(t, fs, "/out/phout.3.log", "line5\nline6\nline7\nline8\n")
+	testutil.AssertFileEqual(t, fs, "/out/phout.log", "line9\n")
+}
+
+func TestFileSink_RotateSizeKeepsPreviousRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	conf := DefaultFileConfig()
+	conf.Path = "/out/phout.log"
+	conf.RotateSize = 6
+	require.NoError(t, afero.WriteFile(fs, "/out/phout.1.log", []byte("old1\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/out/phout.2.log", []byte("old2\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/out/phout.x.log", []byte("other\n"), 0644))
+	wc, err := NewFile(fs, conf).OpenSink()
+	require.NoError(t, err)
+
+	for _, s := range []string{"line1\n", "line2\n"} {
+		_, err = io.WriteString(wc, s)
+		require.NoError(t, err)
+	}
+	require.NoError(t, wc.Close())
+
+	testutil.AssertFileEqual(t, fs, "/out/phout.1.log", "old1\n")
+	testutil.AssertFileEqual(t, fs, "/out/phout.2.log", "old2\n")
+	testutil.AssertFileEqual(t, fs, "/out/phout.3.log", "line1\n")
+	testutil.AssertFileEqual(t, fs, "/out/phout.log", "line2\n")
+}
+
+func TestFileSink_RotateInterval(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	conf := DefaultFileConfig()
+	conf.Path = "/errors.jsonl.gz"
+	conf.Compress = CompressGzip
+	conf.RotateInterval = time.Minute
+	conf.RotateNaming = RotateNamingTimestamp
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	w, err := newRotatingWriter(fs, conf, func() time.Time { return now })
+	require.NoError(t, err)
+
+	_, err = io.WriteString(w, "first\n")
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+	_, err = io.WriteString(w, "second\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "first\n", readGzip(t, fs, "/errors.20261019T120000.jsonl.gz"))
+	assert.Equal(t, "second\n", readGzip(t, fs, "/errors.jsonl.gz"))
+}
+
+func TestFileSink_Zstd(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	conf := DefaultFileConfig()
+	conf.Path = "/phout.log.zst"
+	conf.Compress = CompressZstd
+	wc, err := NewFile(fs, conf).OpenSink()
+	require.NoError(t, err)
+	_, err = io.WriteString(wc, "data\n")
+	require.NoError(t, err)
+	require.NoError(t, wc.Close())
+
+	f, err := fs.Open(conf.Path)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := zstd.NewReader(f)
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "data\n", string(data))
+}
+
+func TestSplitExt(t *testing.T) {
+	for path, want := range map[string][2]string{
+		"/out/phout.log":    {"/out/phout", ".log"},
+		"phout.log.gz":      {"phout", ".log.gz"},
+		"errors.jsonl.zst":  {"errors", ".jsonl.zst"},
+		"phout":             {"phout", ""},
+		"/out.d/phout.json": {"/out.d/phout", ".json"},
+	} {
+		stem, ext := splitExt(path)
+		assert.Equal(t, want, [2]string{stem, ext}, path)
+	}
+}
+
+func readGzip(t *testing.T, fs afero.Afero, name string) string {
+	data, err := fs.ReadFile(name)
+	require.NoError(t, err)
+	r, err := gzip.NewReader(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	result, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(result)
+}
@@ -0,0 +1,74 @@
+package datasink
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/afero"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/lib/errutil"
+	"github.com/yandex/pandora/lib/s3"
+)
+
+type S3Config struct {
+	S3 s3.Config `config:",squash"`
+}
+
+func DefaultS3Config() S3Config {
+	return S3Config{S3: s3.DefaultConfig()}
+}
+
+// NewS3 returns sink, that caches written data in temporary file and uploads it
+// to S3 compatible storage on Close. Credentials are taken from environment, see s3.CredentialsFromEnv.
+func NewS3(fs afero.Fs, conf S3Config) (core.DataSink, error) {
+	client, err := s3.NewClient(conf.S3)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Sink{fs: afero.Afero{Fs: fs}, client: client}, nil
+}
+
+type s3Sink struct {
+	fs     afero.Afero
+	client *s3.Client
+}
+
+func (s *s3Sink) OpenSink() (wc io.WriteCloser, err error) {
+	tmp, err := s.fs.TempFile("", "pandora-s3-")
+	if err != nil {
+		return nil, fmt.Errorf("temporary file create failed: %w", err)
+	}
+	return &s3Writer{File: tmp, fs: s.fs, client: s.client}, nil
+}
+
+type s3Writer struct {
+	afero.File
+	fs     afero.Fs
+	client *s3.Client
+}
+
+// Close uploads written data and removes temporary file. File is kept on upload failure,
+// so results are not lost, and its path is reported in error.
+func (w *s3Writer) Close() error {
+	uploadErr := w.upload()
+	err := w.File.Close()
+	if uploadErr != nil {
+		return errutil.Join(fmt.Errorf("%w: results are kept in %s", uploadErr, w.File.Name()), err)
+	}
+	return errutil.Join(err, w.fs.Remove(w.File.Name()))
+}
+
+func (w *s3Writer) upload() error {
+	size, err := w.File.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := w.File.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := w.client.Put(context.Background(), w.File, size); err != nil {
+		return fmt.Errorf("results upload failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package datasink
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/lib/s3/s3test"
+)
+
+func TestS3Sink(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	server := s3test.NewServer()
+	defer server.Close()
+	server.AccessKeyID = "key"
+
+	fs := afero.NewMemMapFs()
+	conf := DefaultS3Config()
+	conf.S3.Endpoint = server.URL
+	conf.S3.Bucket = "bucket"
+	conf.S3.Key = "results/phout.log"
+	sink, err := NewS3(fs, conf)
+	require.NoError(t, err)
+	wc, err := sink.OpenSink()
+	require.NoError(t, err)
+	_, err = io.WriteString(wc, "line1\n")
+	require.NoError(t, err)
+	_, err = io.WriteString(wc, "line2\n")
+	require.NoError(t, err)
+	_, ok := server.Object("bucket", "results/phout.log")
+	assert.False(t, ok, "data should be uploaded on close")
+
+	require.NoError(t, wc.Close())
+	data, ok := server.Object("bucket", "results/phout.log")
+	require.True(t, ok)
+	assert.Equal(t, "line1\nline2\n", string(data))
+	files, err := afero.ReadDir(fs, os.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, files, "temporary file should be removed on close")
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "other")
+	sink, err = NewS3(fs, conf)
+	require.NoError(t, err)
+	wc, err = sink.OpenSink()
+	require.NoError(t, err)
+	_, err = io.WriteString(wc, "line3\n")
+	require.NoError(t, err)
+	closeErr := wc.Close()
+	assert.ErrorContains(t, closeErr, "AccessDenied")
+	files, err = afero.ReadDir(fs, os.TempDir())
+	require.NoError(t, err)
+	require.Len(t, files, 1, "temporary file should be kept on upload failure")
+	name := filepath.Join(os.TempDir(), files[0].Name())
+	assert.ErrorContains(t, closeErr, name)
+	data, err = afero.ReadFile(fs, name)
+	require.NoError(t, err)
+	assert.Equal(t, "line3\n", string(data))
+}
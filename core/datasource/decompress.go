@@ -0,0 +1,194 @@
+package datasource
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/yandex/pandora/lib/errutil"
+)
+
+type compression struct {
+	name       string
+	magic      []byte
+	extensions []string
+	newReader  func(r io.Reader) (io.ReadCloser, error)
+}
+
+var compressions = []compression{
+	{
+		name:       "gzip",
+		magic:      []byte{0x1f, 0x8b},
+		extensions: []string{".gz", ".gzip"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name:       "zstd",
+		magic:      []byte{0x28, 0xb5, 0x2f, 0xfd},
+		extensions: []string{".zst", ".zstd"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	},
+	{
+		name:       "bzip2",
+		magic:      []byte("BZh"),
+		extensions: []string{".bz2"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+	{
+		name:       "xz",
+		magic:      []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		extensions: []string{".xz"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			x, err := xz.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(x), nil
+		},
+	},
+}
+
+const (
+	magicLen             = 6
+	decompressBufferSize = 64 * 1024
+)
+
+// detectCompression returns compression by magic bytes of header, or by name extension,
+// if header is not recognized. Returns nil, if content is not compressed.
+func detectCompression(header []byte, name string) *compression {
+	for i := range compressions {
+		if bytes.HasPrefix(header, compressions[i].magic) {
+			return &compressions[i]
+		}
+	}
+	ext := strings.ToLower(path.Ext(name))
+	for i := range compressions {
+		for _, e := range compressions[i].extensions {
+			if ext == e {
+				return &compressions[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Decompress returns rc, that transparently decompresses gzip, zstd, bzip2 or xz content of source.
+// Compression is detected by magic bytes, or by name extension. Name is file path or URL, and MAY be empty.
+// Source is returned as is, if it is seekable and not compressed.
+// Returned rc closes source on Close. Returned rc can be sought to start, if source can be sought.
+func Decompress(source io.ReadCloser, name string) (rc io.ReadCloser, err error) {
+	defer func() {
+		if err != nil {
+			_ = source.Close()
+		}
+	}()
+	if rs, ok := source.(io.ReadSeeker); ok {
+		// Pipes, like stdin, implement io.Seeker, but can't be sought.
+		if pos, seekErr := rs.Seek(0, io.SeekCurrent); seekErr == nil && pos == 0 {
+			return decompressSeeker(source, rs, name)
+		}
+	}
+	buf := bufio.NewReaderSize(source, decompressBufferSize)
+	header, err := buf.Peek(magicLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("source header read failed: %w", err)
+	}
+	c := detectCompression(header, name)
+	if c == nil {
+		return &struct {
+			io.Reader
+			io.Closer
+		}{buf, source}, nil
+	}
+	return newDecompressReader(c, source, buf)
+}
+
+func decompressSeeker(source io.ReadCloser, rs io.ReadSeeker, name string) (io.ReadCloser, error) {
+	header := make([]byte, magicLen)
+	n, err := io.ReadFull(rs, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("source header read failed: %w", err)
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("source seek failed: %w", err)
+	}
+	c := detectCompression(header[:n], name)
+	if c == nil {
+		return source, nil
+	}
+	r, err := newDecompressReader(c, source, bufio.NewReaderSize(source, decompressBufferSize))
+	if err != nil {
+		return nil, err
+	}
+	return &seekableDecompressReader{decompressReader: r, seeker: rs}, nil
+}
+
+func newDecompressReader(c *compression, source io.ReadCloser, buf *bufio.Reader) (*decompressReader, error) {
+	r := &decompressReader{compression: c, source: source, buf: buf}
+	var err error
+	r.ReadCloser, err = c.newReader(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s decompression failed: %w", c.name, err)
+	}
+	return r, nil
+}
+
+type decompressReader struct {
+	io.ReadCloser
+	compression *compression
+	source      io.ReadCloser
+	buf         *bufio.Reader
+}
+
+func (r *decompressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("%s decompression failed: %w", r.compression.name, err)
+	}
+	return n, err
+}
+
+func (r *decompressReader) Close() error {
+	return errutil.Join(r.ReadCloser.Close(), r.source.Close())
+}
+
+// seekableDecompressReader supports only seek to start, that is enough for multi pass reading.
+type seekableDecompressReader struct {
+	*decompressReader
+	seeker io.Seeker
+}
+
+func (r *seekableDecompressReader) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekStart {
+		return 0, fmt.Errorf("%s decompressed source can be sought only to start", r.compression.name)
+	}
+	if _, err := r.seeker.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	_ = r.ReadCloser.Close()
+	r.buf.Reset(r.source)
+	var err error
+	r.ReadCloser, err = r.compression.newReader(r.buf)
+	if err != nil {
+		r.ReadCloser = io.NopCloser(bytes.NewReader(nil))
+		return 0, fmt.Errorf("%s decompression failed: %w", r.compression.name, err)
+	}
+	return 0, nil
+}
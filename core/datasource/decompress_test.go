@@ -0,0 +1,106 @@
+package datasource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+const decompressContent = "{\"a\":1}\n{\"a\":2}\n"
+
+// decompressContent compressed by python bz2 module. Go has no bzip2 compressor.
+const decompressContentBzip2 = "425a6839314159265359229de2e900000659800010100030102000000a2000310c0812807a89c226868be2ee48a70a120453bc5d20"
+
+func compressTestContent(t *testing.T, name string) []byte {
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	var err error
+	switch name {
+	case "gzip":
+		w = gzip.NewWriter(buf)
+	case "zstd":
+		w, err = zstd.NewWriter(buf)
+	case "xz":
+		w, err = xz.NewWriter(buf)
+	case "bzip2":
+		data, err := hex.DecodeString(decompressContentBzip2)
+		require.NoError(t, err)
+		return data
+	case "plain":
+		return []byte(decompressContent)
+	}
+	require.NoError(t, err)
+	_, err = io.WriteString(w, decompressContent)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestFileSource_Decompress(t *testing.T) {
+	for _, name := range []string{"plain", "gzip", "zstd", "bzip2", "xz"} {
+		t.Run(name, func(t *testing.T) {
+			const filename = "/ammo"
+			fs := afero.NewMemMapFs()
+			compressed := compressTestContent(t, name)
+			require.NoError(t, afero.WriteFile(fs, filename, compressed, 0644))
+
+			rc, err := NewFile(fs, FileConfig{Path: filename}).OpenSource()
+			require.NoError(t, err)
+			data, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			assert.Equal(t, decompressContent, string(data))
+
+			// Multi pass reading requires seek to start.
+			seeker, ok := rc.(io.Seeker)
+			require.True(t, ok)
+			_, err = seeker.Seek(0, io.SeekStart)
+			require.NoError(t, err)
+			data, err = io.ReadAll(rc)
+			require.NoError(t, err)
+			assert.Equal(t, decompressContent, string(data))
+			require.NoError(t, rc.Close())
+
+			rc, err = NewFile(fs, FileConfig{Path: filename, DisableDecompression: true}).OpenSource()
+			require.NoError(t, err)
+			data, err = io.ReadAll(rc)
+			require.NoError(t, err)
+			assert.Equal(t, compressed, data)
+			require.NoError(t, rc.Close())
+		})
+	}
+}
+
+func TestDecompress_NotSeekable(t *testing.T) {
+	for _, name := range []string{"plain", "gzip", "zstd", "bzip2", "xz"} {
+		t.Run(name, func(t *testing.T) {
+			source := io.NopCloser(bytes.NewReader(compressTestContent(t, name)))
+			rc, err := Decompress(source, "")
+			require.NoError(t, err)
+			data, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			assert.Equal(t, decompressContent, string(data))
+			require.NoError(t, rc.Close())
+		})
+	}
+}
+
+func TestDecompress_Extension(t *testing.T) {
+	source := io.NopCloser(bytes.NewReader([]byte(decompressContent)))
+	_, err := Decompress(source, "https://example.com/ammo.jsonl.gz")
+	assert.ErrorContains(t, err, "gzip decompression failed")
+
+	source = io.NopCloser(bytes.NewReader(nil))
+	rc, err := Decompress(source, "ammo.jsonl")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
@@ -8,10 +8,10 @@ import (
 	"github.com/yandex/pandora/core"
 )
 
-// TODO(skipor): auto unzip with option to turn this behaviour off.
-
 type FileConfig struct {
 	Path string `config:"path" validate:"required"`
+	// DisableDecompression turns off transparent decompression of gzip, zstd, bzip2 and xz files.
+	DisableDecompression bool `config:"disable-decompression"`
 }
 
 func NewFile(fs afero.Fs, conf FileConfig) core.DataSource {
@@ -24,17 +24,38 @@ type fileSource struct {
 }
 
 func (s *fileSource) OpenSource() (wc io.ReadCloser, err error) {
-	return s.fs.Open(s.conf.Path)
+	f, err := s.fs.Open(s.conf.Path)
+	if err != nil || s.conf.DisableDecompression {
+		return f, err
+	}
+	return Decompress(f, s.conf.Path)
+}
+
+type StdinConfig struct {
+	// DisableDecompression turns off transparent decompression of gzip, zstd, bzip2 and xz input.
+	DisableDecompression bool `config:"disable-decompression"`
 }
 
 func NewStdin() core.DataSource {
-	return hideCloseFileSource{os.Stdin}
+	return NewStdinConf(StdinConfig{})
 }
 
-type hideCloseFileSource struct{ afero.File }
+func NewStdinConf(conf StdinConfig) core.DataSource {
+	return &stdinSource{file: hideCloseFile{os.Stdin}, conf: conf}
+}
 
-func (f hideCloseFileSource) OpenSource() (wc io.ReadCloser, err error) {
-	return f, nil
+type stdinSource struct {
+	file hideCloseFile
+	conf StdinConfig
 }
 
-func (f hideCloseFileSource) Close() error { return nil }
+func (s *stdinSource) OpenSource() (wc io.ReadCloser, err error) {
+	if s.conf.DisableDecompression {
+		return s.file, nil
+	}
+	return Decompress(s.file, "")
+}
+
+type hideCloseFile struct{ afero.File }
+
+func (f hideCloseFile) Close() error { return nil }
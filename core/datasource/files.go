@@ -0,0 +1,183 @@
+package datasource
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/yandex/pandora/core"
+)
+
+const (
+	FilesOrderSorted   = "sorted"
+	FilesOrderShuffled = "shuffled"
+)
+
+type FilesConfig struct {
+	// Paths are file paths or glob patterns. Example: ["./ammo/2026-10-*.jsonl.gz", "./extra.jsonl"]
+	Paths []string `config:"paths" validate:"required"`
+	// Order is sorted (by path) or shuffled.
+	Order string `config:"order" validate:"oneof=sorted shuffled"`
+	// Seed of shuffle. Random, if zero.
+	Seed int64 `config:"seed"`
+	// Parts and Part split files between pools: pool reads every file, which index in sorted list
+	// modulo Parts equals Part. No split, if Parts is zero.
+	Parts int `config:"parts" validate:"min=0"`
+	Part  int `config:"part" validate:"min=0"`
+	// DisableDecompression turns off transparent decompression of gzip, zstd, bzip2 and xz files.
+	DisableDecompression bool `config:"disable-decompression"`
+}
+
+func DefaultFilesConfig() FilesConfig {
+	return FilesConfig{
+		Order: FilesOrderSorted,
+	}
+}
+
+// NewFiles returns source, that reads files one by one, as one stream. Glob patterns are expanded on OpenSource.
+// Files are separated with newline, if file doesn't end with it, so last line of file is not merged
+// with first line of next one.
+func NewFiles(fs afero.Fs, conf FilesConfig) (core.DataSource, error) {
+	if conf.Parts > 0 && conf.Part >= conf.Parts {
+		return nil, fmt.Errorf("part %d is out of range: there are only %d parts", conf.Part, conf.Parts)
+	}
+	if conf.Seed == 0 {
+		conf.Seed = time.Now().UnixNano()
+	}
+	return &filesSource{fs: afero.Afero{Fs: fs}, conf: conf}, nil
+}
+
+type filesSource struct {
+	fs   afero.Afero
+	conf FilesConfig
+}
+
+func (s *filesSource) OpenSource() (rc io.ReadCloser, err error) {
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	return &filesReader{source: s, files: files}, nil
+}
+
+// files returns paths to read in order.
+func (s *filesSource) files() ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	for _, pattern := range s.conf.Paths {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			matches, err = afero.Glob(s.fs, pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	sort.Strings(files)
+	if s.conf.Parts > 0 {
+		part := files[:0]
+		for i, f := range files {
+			if i%s.conf.Parts == s.conf.Part {
+				part = append(part, f)
+			}
+		}
+		files = part
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %v", s.conf.Paths)
+	}
+	if s.conf.Order == FilesOrderShuffled {
+		r := rand.New(rand.NewSource(s.conf.Seed))
+		r.Shuffle(len(files), func(i, j int) { files[i], files[j] = files[j], files[i] })
+	}
+	return files, nil
+}
+
+func (s *filesSource) open(name string) (io.ReadCloser, error) {
+	f, err := s.fs.Open(name)
+	if err != nil || s.conf.DisableDecompression {
+		return f, err
+	}
+	return Decompress(f, name)
+}
+
+// filesReader reads files one by one. It can be sought to start, so files can be read in several passes.
+type filesReader struct {
+	source  *filesSource
+	files   []string
+	next    int
+	current io.ReadCloser
+	// lastByte is last byte read from current file. Zero, if nothing read yet.
+	lastByte byte
+}
+
+func (r *filesReader) Read(p []byte) (n int, err error) {
+	for {
+		if r.current == nil {
+			if r.next == len(r.files) {
+				return 0, io.EOF
+			}
+			r.current, err = r.source.open(r.files[r.next])
+			if err != nil {
+				return 0, err
+			}
+			r.next++
+			r.lastByte = 0
+		}
+		n, err = r.current.Read(p)
+		if n > 0 {
+			r.lastByte = p[n-1]
+		}
+		if err != io.EOF {
+			return n, err
+		}
+		closeErr := r.current.Close()
+		r.current = nil
+		if closeErr != nil {
+			return n, closeErr
+		}
+		if r.lastByte != 0 && r.lastByte != '\n' && r.next < len(r.files) {
+			r.lastByte = '\n'
+			if n < len(p) {
+				p[n] = '\n'
+				return n + 1, nil
+			}
+			// Newline is returned on next Read, from empty file.
+			r.current = io.NopCloser(strings.NewReader("\n"))
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (r *filesReader) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekStart {
+		return 0, fmt.Errorf("files source can be sought only to start")
+	}
+	if err := r.Close(); err != nil {
+		return 0, err
+	}
+	r.next = 0
+	return 0, nil
+}
+
+func (r *filesReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
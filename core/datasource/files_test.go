@@ -0,0 +1,87 @@
+package datasource
+
+import (
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFilesTestFs(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for name, data := range map[string]string{
+		"/ammo/01.jsonl":    "1\n",
+		"/ammo/02.jsonl":    "2", // No trailing newline.
+		"/ammo/03.jsonl":    "3\n",
+		"/ammo/04.jsonl.gz": string(compressTestContent(t, "gzip")),
+		"/ammo/skip.txt":    "skip\n",
+	} {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(data), 0644))
+	}
+	return fs
+}
+
+func readFiles(t *testing.T, fs afero.Fs, conf FilesConfig) string {
+	source, err := NewFiles(fs, conf)
+	require.NoError(t, err)
+	rc, err := source.OpenSource()
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestFilesSource(t *testing.T) {
+	fs := newFilesTestFs(t)
+	conf := DefaultFilesConfig()
+	conf.Paths = []string{"/ammo/*.jsonl*", "/ammo/01.jsonl"}
+	assert.Equal(t, "1\n2\n3\n"+decompressContent, readFiles(t, fs, conf))
+
+	conf.Parts = 2
+	conf.Part = 1
+	assert.Equal(t, "2\n"+decompressContent, readFiles(t, fs, conf))
+
+	conf.Parts = 0
+	conf.Order = FilesOrderShuffled
+	conf.Seed = 1
+	shuffled := readFiles(t, fs, conf)
+	assert.Len(t, shuffled, len("1\n2\n3\n"+decompressContent))
+	assert.Equal(t, shuffled, readFiles(t, fs, conf), "same seed should give same order")
+
+	conf.Paths = []string{"/ammo/*.csv"}
+	source, err := NewFiles(fs, conf)
+	require.NoError(t, err)
+	_, err = source.OpenSource()
+	assert.ErrorContains(t, err, "no files match")
+
+	conf.Parts = 2
+	conf.Part = 2
+	_, err = NewFiles(fs, conf)
+	assert.Error(t, err)
+}
+
+func TestFilesSource_Seek(t *testing.T) {
+	fs := newFilesTestFs(t)
+	conf := DefaultFilesConfig()
+	conf.Paths = []string{"/ammo/0[1-3].jsonl"}
+	source, err := NewFiles(fs, conf)
+	require.NoError(t, err)
+	rc, err := source.OpenSource()
+	require.NoError(t, err)
+	defer rc.Close()
+
+	// One byte reads check, that newline is inserted even if there is no room for it in buffer.
+	data, err := io.ReadAll(iotest.OneByteReader(rc))
+	require.NoError(t, err)
+	assert.Equal(t, "1\n2\n3\n", string(data))
+
+	_, err = rc.(io.Seeker).Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	data, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n2\n3\n", string(data))
+}
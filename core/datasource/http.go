@@ -0,0 +1,201 @@
+package datasource
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/lib/errutil"
+	"github.com/yandex/pandora/lib/netutil"
+)
+
+type HTTPConfig struct {
+	URL     string            `config:"url" validate:"required,url"`
+	Headers map[string]string `config:"headers"`
+	// Timeout of connect and of waiting for response headers. Body download is not limited,
+	// because ammo files may be large. Zero means no timeout.
+	Timeout time.Duration `config:"timeout"`
+	// Checksum is optional expected checksum of content in <algorithm>:<hex> format.
+	// Supported algorithms: md5, sha1, sha256, sha512. Example: sha256:9f86d08...
+	Checksum string `config:"checksum"`
+	// CacheDir is optional directory, where downloaded content is kept between runs.
+	// Cached content is revalidated by ETag and is not downloaded again, if it is not modified.
+	// If CacheDir is empty, content is downloaded to temporary file, that is deleted on Close.
+	CacheDir string `config:"cache-dir"`
+	// DisableDecompression turns off transparent decompression of gzip, zstd, bzip2 and xz content.
+	// Checksum and cache are applied to content as it is downloaded, regardless of this option.
+	DisableDecompression bool `config:"disable-decompression"`
+}
+
+func DefaultHTTPConfig() HTTPConfig {
+	return HTTPConfig{
+		Timeout: time.Minute,
+	}
+}
+
+func NewHTTP(fs afero.Fs, conf HTTPConfig) (core.DataSource, error) {
+	s := &httpSource{
+		fs:     afero.Afero{Fs: fs},
+		conf:   conf,
+		client: netutil.NewHTTPClient(conf.Timeout),
+	}
+	if conf.Checksum != "" {
+		algorithm, sum, ok := strings.Cut(conf.Checksum, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid checksum %q: expected <algorithm>:<hex> format", conf.Checksum)
+		}
+		newHash, ok := checksumAlgorithms[strings.ToLower(algorithm)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+		}
+		s.newHash = newHash
+		s.checksum = strings.ToLower(sum)
+	}
+	return s, nil
+}
+
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+type httpSource struct {
+	fs       afero.Afero
+	conf     HTTPConfig
+	client   *http.Client
+	newHash  func() hash.Hash
+	checksum string
+}
+
+func (s *httpSource) OpenSource() (rc io.ReadCloser, err error) {
+	rc, err = s.open()
+	if err != nil || s.conf.DisableDecompression {
+		return rc, err
+	}
+	name := s.conf.URL
+	if u, err := url.Parse(s.conf.URL); err == nil {
+		name = u.Path
+	}
+	return Decompress(rc, name)
+}
+
+// open returns downloaded or cached file.
+func (s *httpSource) open() (rc io.ReadCloser, err error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, s.conf.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request create failed: %w", err)
+	}
+	for k, v := range s.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	var dataPath, etagPath string
+	if s.conf.CacheDir != "" {
+		key := sha256.Sum256([]byte(s.conf.URL))
+		dataPath = filepath.Join(s.conf.CacheDir, hex.EncodeToString(key[:]))
+		etagPath = dataPath + ".etag"
+		etag, err := s.fs.ReadFile(etagPath)
+		if err == nil && len(etag) > 0 {
+			if ok, _ := s.fs.Exists(dataPath); ok {
+				req.Header.Set("If-None-Match", string(etag))
+			}
+		}
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s download failed: %w", s.conf.URL, err)
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusNotModified && dataPath != "":
+		return s.fs.Open(dataPath)
+	case res.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("%s download failed: unexpected status %s: %s", s.conf.URL, res.Status, body)
+	}
+
+	etag := res.Header.Get("ETag")
+	cache := dataPath != "" && etag != ""
+	tmpDir := ""
+	if cache {
+		if err := s.fs.MkdirAll(s.conf.CacheDir, 0755); err != nil {
+			return nil, fmt.Errorf("cache dir create failed: %w", err)
+		}
+		// Temporary file is created near cache file, so it can be renamed.
+		tmpDir = s.conf.CacheDir
+	}
+	tmp, err := s.fs.TempFile(tmpDir, "pandora-http-")
+	if err != nil {
+		return nil, fmt.Errorf("temporary file create failed: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = s.fs.Remove(tmp.Name())
+		}
+	}()
+	if err = s.download(tmp, res.Body); err != nil {
+		return nil, err
+	}
+	if !cache {
+		if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return &tempFile{File: tmp, fs: s.fs}, nil
+	}
+	if err = tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err = s.fs.Rename(tmp.Name(), dataPath); err != nil {
+		return nil, fmt.Errorf("cache file rename failed: %w", err)
+	}
+	if err = s.fs.WriteFile(etagPath, []byte(etag), 0644); err != nil {
+		return nil, fmt.Errorf("cache etag write failed: %w", err)
+	}
+	return s.fs.Open(dataPath)
+}
+
+// download copies body to file and verifies checksum, if it is set.
+func (s *httpSource) download(file io.Writer, body io.Reader) error {
+	var h hash.Hash
+	if s.newHash != nil {
+		h = s.newHash()
+		file = io.MultiWriter(file, h)
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		return fmt.Errorf("%s download failed: %w", s.conf.URL, err)
+	}
+	if h == nil {
+		return nil
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != s.checksum {
+		return fmt.Errorf("%s checksum mismatch: expected %s, got %s", s.conf.URL, s.checksum, sum)
+	}
+	return nil
+}
+
+// tempFile is removed on Close.
+type tempFile struct {
+	afero.File
+	fs afero.Fs
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	return errutil.Join(err, f.fs.Remove(f.File.Name()))
+}
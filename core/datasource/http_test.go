@@ -0,0 +1,135 @@
+package datasource
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	httpContent       = "test"
+	httpContentSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+)
+
+func newHTTPTestServer(t *testing.T) (*httptest.Server, *int) {
+	var downloads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "OAuth token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		_, _ = io.WriteString(w, httpContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, &downloads
+}
+
+func readSource(t *testing.T, fs afero.Fs, conf HTTPConfig) (string, error) {
+	source, err := NewHTTP(fs, conf)
+	require.NoError(t, err)
+	rc, err := source.OpenSource()
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	return string(data), nil
+}
+
+func TestHTTPSource(t *testing.T) {
+	server, downloads := newHTTPTestServer(t)
+	fs := afero.NewMemMapFs()
+	conf := DefaultHTTPConfig()
+	conf.URL = server.URL
+	conf.Headers = map[string]string{"Authorization": "OAuth token"}
+	conf.Checksum = "sha256:" + httpContentSHA256
+
+	data, err := readSource(t, fs, conf)
+	require.NoError(t, err)
+	assert.Equal(t, httpContent, data)
+	assert.Equal(t, 1, *downloads)
+	files, err := afero.ReadDir(fs, os.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, files, "temporary file should be removed on close")
+
+	conf.Headers = nil
+	_, err = readSource(t, fs, conf)
+	assert.ErrorContains(t, err, "401 Unauthorized")
+}
+
+func TestHTTPSource_ChecksumMismatch(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	conf := DefaultHTTPConfig()
+	conf.URL = server.URL
+	conf.Headers = map[string]string{"Authorization": "OAuth token"}
+	conf.Checksum = "md5:00000000000000000000000000000000"
+
+	_, err := readSource(t, afero.NewMemMapFs(), conf)
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	conf.Checksum = "crc32:00000000"
+	_, err = NewHTTP(afero.NewMemMapFs(), conf)
+	assert.ErrorContains(t, err, "unsupported checksum algorithm")
+}
+
+func TestHTTPSource_Timeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			time.Sleep(2 * timeout)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		// Body download takes longer than timeout, but it is not limited.
+		for i := 0; i < len(httpContent); i++ {
+			time.Sleep(timeout / 2)
+			_, _ = io.WriteString(w, httpContent[i:i+1])
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+	conf := DefaultHTTPConfig()
+	conf.Timeout = timeout
+
+	conf.URL = server.URL + "/slow-body"
+	data, err := readSource(t, afero.NewMemMapFs(), conf)
+	require.NoError(t, err)
+	assert.Equal(t, httpContent, data)
+
+	conf.URL = server.URL + "/slow-headers"
+	_, err = readSource(t, afero.NewMemMapFs(), conf)
+	assert.ErrorContains(t, err, "timeout awaiting response headers")
+}
+
+func TestHTTPSource_Cache(t *testing.T) {
+	server, downloads := newHTTPTestServer(t)
+	fs := afero.NewMemMapFs()
+	conf := DefaultHTTPConfig()
+	conf.URL = server.URL
+	conf.Headers = map[string]string{"Authorization": "OAuth token"}
+	conf.CacheDir = "/cache"
+
+	for i := 0; i < 3; i++ {
+		data, err := readSource(t, fs, conf)
+		require.NoError(t, err)
+		assert.Equal(t, httpContent, data)
+	}
+	assert.Equal(t, 1, *downloads)
+	files, err := afero.ReadDir(fs, conf.CacheDir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2, "content and etag files are expected")
+}
@@ -0,0 +1,65 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/afero"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/lib/s3"
+)
+
+type S3Config struct {
+	S3 s3.Config `config:",squash"`
+	// DisableDecompression turns off transparent decompression of gzip, zstd, bzip2 and xz objects.
+	DisableDecompression bool `config:"disable-decompression"`
+}
+
+func DefaultS3Config() S3Config {
+	return S3Config{S3: s3.DefaultConfig()}
+}
+
+// NewS3 returns source, that downloads object from S3 compatible storage to temporary file.
+// Credentials are taken from environment, see s3.CredentialsFromEnv.
+func NewS3(fs afero.Fs, conf S3Config) (core.DataSource, error) {
+	client, err := s3.NewClient(conf.S3)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Source{fs: afero.Afero{Fs: fs}, conf: conf, client: client}, nil
+}
+
+type s3Source struct {
+	fs     afero.Afero
+	conf   S3Config
+	client *s3.Client
+}
+
+func (s *s3Source) OpenSource() (rc io.ReadCloser, err error) {
+	body, err := s.client.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	tmp, err := s.fs.TempFile("", "pandora-s3-")
+	if err != nil {
+		return nil, fmt.Errorf("temporary file create failed: %w", err)
+	}
+	rc = &tempFile{File: tmp, fs: s.fs}
+	defer func() {
+		if err != nil {
+			_ = rc.Close()
+		}
+	}()
+	if _, err = io.Copy(tmp, body); err != nil {
+		return nil, fmt.Errorf("%s download failed: %w", s.client.URL(), err)
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if s.conf.DisableDecompression {
+		return rc, nil
+	}
+	return Decompress(rc, s.conf.S3.Key)
+}
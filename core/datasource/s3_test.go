@@ -0,0 +1,44 @@
+package datasource
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/lib/s3/s3test"
+)
+
+func TestS3Source(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	server := s3test.NewServer()
+	defer server.Close()
+	server.AccessKeyID = "key"
+	server.SetObject("bucket", "ammo.jsonl.gz", compressTestContent(t, "gzip"))
+
+	fs := afero.NewMemMapFs()
+	conf := DefaultS3Config()
+	conf.S3.Endpoint = server.URL
+	conf.S3.Bucket = "bucket"
+	conf.S3.Key = "ammo.jsonl.gz"
+	source, err := NewS3(fs, conf)
+	require.NoError(t, err)
+	rc, err := source.OpenSource()
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, decompressContent, string(data))
+	files, err := afero.ReadDir(fs, os.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, files, "temporary file should be removed on close")
+
+	conf.S3.Key = "missing"
+	source, err = NewS3(fs, conf)
+	require.NoError(t, err)
+	_, err = source.OpenSource()
+	assert.ErrorContains(t, err, "NoSuchKey")
+}
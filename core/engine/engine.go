@@ -225,7 +225,7 @@ func (p *instancePool) runAsync(runCtx context.Context) (*poolAsyncRunHandle, er
 		providerErr <- p.Provider.Run(runCtx, deps)
 	}()
 	go func() {
-		deps := core.AggregatorDeps{Log: p.log}
+		deps := core.AggregatorDeps{Log: p.log, PoolID: p.ID}
 		aggregatorErr <- p.Aggregator.Run(runCtx, deps)
 	}()
 	go func() {
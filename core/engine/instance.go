@@ -13,20 +13,21 @@ import (
 )
 
 type instance struct {
-	log      *zap.Logger
-	id       int
-	gun      core.Gun
-	schedule core.Schedule
+	log    *zap.Logger
+	id     int
+	gun    core.Gun
+	waiter *coreutil.Waiter
 	instanceSharedDeps
 }
 
 func newInstance(ctx context.Context, log *zap.Logger, poolID string, id int, deps instanceDeps) (*instance, error) {
 	log = log.With(zap.Int("instance", id))
-	gunDeps := core.GunDeps{Ctx: ctx, Log: log, PoolID: poolID, InstanceID: id, Shared: deps.gunDeps}
 	sched, err := deps.newSchedule()
 	if err != nil {
 		return nil, err
 	}
+	waiter := coreutil.NewWaiter(sched)
+	gunDeps := core.GunDeps{Ctx: ctx, Log: log, PoolID: poolID, InstanceID: id, Shared: deps.gunDeps, ScheduledTime: waiter.Scheduled}
 	gun, err := deps.newGun()
 	if err != nil {
 		return nil, err
@@ -36,7 +37,7 @@ func newInstance(ctx context.Context, log *zap.Logger, poolID string, id int, de
 	if err != nil {
 		return nil, err
 	}
-	inst := &instance{log: log, id: id, gun: gun, schedule: sched, instanceSharedDeps: deps.instanceSharedDeps}
+	inst := &instance{log: log, id: id, gun: gun, waiter: waiter, instanceSharedDeps: deps.instanceSharedDeps}
 	return inst, nil
 }
 
@@ -69,7 +70,7 @@ func (i *instance) Run(ctx context.Context) (recoverErr error) {
 	i.log.Debug("Instance started")
 	i.metrics.InstanceStart.Add(1)
 
-	waiter := coreutil.NewWaiter(i.schedule)
+	waiter := i.waiter
 	// Checking, that schedule is not finished, required, to not consume extra ammo,
 	// on finish in case of per instance schedule.
 	for !waiter.IsFinished(ctx) {
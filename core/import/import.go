@@ -2,6 +2,7 @@ package coreimport
 
 import (
 	"reflect"
+	"strings"
 
 	"github.com/spf13/afero"
 	"github.com/yandex/pandora/core"
@@ -22,6 +23,7 @@ import (
 
 const (
 	fileDataKey          = "file"
+	s3DataKey            = "s3"
 	compositeScheduleKey = "composite"
 )
 
@@ -34,11 +36,14 @@ func Import(fs afero.Fs) {
 
 	register.DataSink(fileDataKey, func(conf datasink.FileConfig) core.DataSink {
 		return datasink.NewFile(fs, conf)
-	})
+	}, datasink.DefaultFileConfig)
 	const (
 		stdoutSinkKey = "stdout"
 		stderrSinkKey = "stderr"
 	)
+	register.DataSink(s3DataKey, func(conf datasink.S3Config) (core.DataSink, error) {
+		return datasink.NewS3(fs, conf)
+	}, datasink.DefaultS3Config)
 	register.DataSink(stdoutSinkKey, datasink.NewStdout)
 	register.DataSink(stderrSinkKey, datasink.NewStderr)
 	AddSinkConfigHook(func(str string) (ok bool, pluginType string, _ map[string]interface{}) {
@@ -55,15 +60,43 @@ func Import(fs afero.Fs) {
 	})
 	const (
 		stdinSourceKey = "stdin"
+		httpSourceKey  = "http"
+		filesSourceKey = "files"
 	)
-	register.DataSource(stdinSourceKey, datasource.NewStdin)
-	AddSinkConfigHook(func(str string) (ok bool, pluginType string, _ map[string]interface{}) {
+	register.DataSource(stdinSourceKey, datasource.NewStdinConf)
+	AddSourceConfigHook(func(str string) (ok bool, pluginType string, _ map[string]interface{}) {
 		if str != stdinSourceKey {
 			return
 		}
 		return true, stdinSourceKey, nil
 	})
 	register.DataSource("inline", datasource.NewInline)
+	register.DataSource(httpSourceKey, func(conf datasource.HTTPConfig) (core.DataSource, error) {
+		return datasource.NewHTTP(fs, conf)
+	}, datasource.DefaultHTTPConfig)
+	register.DataSource(s3DataKey, func(conf datasource.S3Config) (core.DataSource, error) {
+		return datasource.NewS3(fs, conf)
+	}, datasource.DefaultS3Config)
+	register.DataSource(filesSourceKey, func(conf datasource.FilesConfig) (core.DataSource, error) {
+		return datasource.NewFiles(fs, conf)
+	}, datasource.DefaultFilesConfig)
+	AddSourceConfigHook(func(str string) (ok bool, pluginType string, conf map[string]interface{}) {
+		if !strings.HasPrefix(str, "http://") && !strings.HasPrefix(str, "https://") {
+			return
+		}
+		return true, httpSourceKey, map[string]interface{}{"url": str}
+	})
+	// Glob hook goes after URL hooks, because URL query may contain glob meta characters.
+	// Existing file, which name contains glob meta characters, is file source.
+	AddSourceConfigHook(func(str string) (ok bool, pluginType string, conf map[string]interface{}) {
+		if !strings.ContainsAny(str, "*?[") || strings.Contains(str, "://") {
+			return
+		}
+		if exists, _ := afero.Exists(fs, str); exists {
+			return
+		}
+		return true, filesSourceKey, map[string]interface{}{"paths": []string{str}}
+	})
 
 	// NOTE(skipor): json provider SHOULD NOT used normally. Register your own, that will return
 	// type that you need, but untyped map.
@@ -91,6 +124,7 @@ func Import(fs afero.Fs) {
 	register.Limiter(compositeScheduleKey, schedule.NewCompositeConf)
 
 	config.AddTypeHook(sinkStringHook)
+	config.AddTypeHook(sourceStringHook)
 	config.AddTypeHook(scheduleSliceToCompositeConfigHook)
 
 	confutil.RegisterTagResolver("", confutil.EnvTagResolver)
@@ -2,6 +2,9 @@ package coreimport
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -121,7 +124,62 @@ func TestProviderJSONLine(t *testing.T) {
 	testutil.AssertFileEqual(t, fs, filename, "[0,1,2]\n")
 }
 
-// TODO(skipor): test datasources
+func TestSource(t *testing.T) {
+	defer resetGlobals()
+	fs := afero.NewMemMapFs()
+	const filename = "/xxx"
+	Import(fs)
+	require.NoError(t, afero.WriteFile(fs, "/ammo[1].json", []byte("literal"), 0644))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("http" + r.URL.Query().Get("q")))
+	}))
+	defer server.Close()
+
+	input := testConfig(
+		"stdin", "stdin",
+		"file", filename,
+		"http", server.URL,
+		"httpquery", server.URL+"/ammo?q=*[1]",
+		"glob", "/glob/*.txt",
+		"literal", "/ammo[1].json",
+	)
+	var conf struct {
+		Stdin     func() core.DataSource
+		File      core.DataSource
+		HTTP      core.DataSource
+		HTTPQuery core.DataSource
+		Glob      core.DataSource
+		Literal   core.DataSource
+	}
+	err := config.Decode(input, &conf)
+	require.NoError(t, err)
+	coretest.AssertSourceEqualStdStream(t, &os.Stdin, conf.Stdin)
+	coretest.AssertSourceEqualFile(t, fs, filename, conf.File)
+	coretest.AssertSourceEqualFile(t, fs, "/ammo[1].json", conf.Literal)
+
+	rc, err := conf.HTTP.OpenSource()
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "http", string(data))
+
+	rc, err = conf.HTTPQuery.OpenSource()
+	require.NoError(t, err, "URL with glob meta characters in query is http source")
+	defer rc.Close()
+	data, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "http*[1]", string(data))
+
+	require.NoError(t, afero.WriteFile(fs, "/glob/1.txt", []byte("1\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/glob/2.txt", []byte("2\n"), 0644))
+	rc, err = conf.Glob.OpenSource()
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n2\n", string(data))
+}
 
 func testConfig(keyValuePairs ...interface{}) map[string]interface{} {
 	if len(keyValuePairs)%2 != 0 {
@@ -139,5 +197,7 @@ func testConfig(keyValuePairs ...interface{}) map[string]interface{} {
 func resetGlobals() {
 	plugin.SetDefaultRegistry(plugin.NewRegistry())
 	config.SetHooks(config.DefaultHooks())
+	dataSinkConfigHooks = nil
+	dataSourceConfigHooks = nil
 	testutil.ReplaceGlobalLogger()
 }
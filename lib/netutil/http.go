@@ -0,0 +1,17 @@
+package netutil
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// NewHTTPClient returns client, that limits connect and waiting for response headers by timeout.
+// http.Client.Timeout is not used, because it includes request body write and response body read,
+// that take long for large files. Zero timeout means no limit.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.ResponseHeaderTimeout = timeout
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,133 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+)
+
+// example returns schema example, or value generated from schema.
+func (d *Document) example(s *Schema) any {
+	return d.generate(s, map[string]bool{})
+}
+
+// generate returns value of schema. Expanding refs are tracked, so recursive schemas, like tree nodes,
+// are generated without recursive fields.
+func (d *Document) generate(s *Schema, expanding map[string]bool) any {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if expanding[s.Ref] {
+			return nil
+		}
+		expanding[s.Ref] = true
+		defer delete(expanding, s.Ref)
+		return d.generate(d.Components.Schemas[refName(s.Ref)], expanding)
+	}
+	switch {
+	case s.Example != nil:
+		return normalize(s.Example)
+	case s.Default != nil:
+		return normalize(s.Default)
+	case len(s.Enum) > 0:
+		return normalize(s.Enum[0])
+	case len(s.AllOf) > 0:
+		merged := map[string]any{}
+		for _, sub := range s.AllOf {
+			if fields, ok := d.generate(sub, expanding).(map[string]any); ok {
+				for k, v := range fields {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	case len(s.OneOf) > 0:
+		return d.generate(s.OneOf[0], expanding)
+	case len(s.AnyOf) > 0:
+		return d.generate(s.AnyOf[0], expanding)
+	}
+	switch s.Type {
+	case "string":
+		return stringExample(s.Format)
+	case "integer":
+		if s.Minimum != nil && *s.Minimum > 1 {
+			return int64(*s.Minimum)
+		}
+		return 1
+	case "number":
+		if s.Minimum != nil && *s.Minimum > 1 {
+			return *s.Minimum
+		}
+		return 1.5
+	case "boolean":
+		return true
+	case "array":
+		item := d.generate(s.Items, expanding)
+		if item == nil {
+			return []any{}
+		}
+		return []any{item}
+	case "object", "":
+		if s.Type == "" && len(s.Properties) == 0 {
+			return nil
+		}
+		fields := map[string]any{}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop := s.Properties[name]
+			if prop.ReadOnly {
+				continue
+			}
+			if v := d.generate(prop, expanding); v != nil {
+				fields[name] = v
+			}
+		}
+		return fields
+	}
+	return nil
+}
+
+func stringExample(format string) string {
+	switch format {
+	case "date":
+		return "2026-01-01"
+	case "date-time":
+		return "2026-01-01T00:00:00Z"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000001"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com/"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+	return "string"
+}
+
+// normalize converts YAML maps to JSON compatible ones.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalize(item)
+		}
+		return m
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = normalize(item)
+		}
+		return items
+	}
+	return v
+}
@@ -0,0 +1,380 @@
+// Package openapi makes HTTP requests from OpenAPI 3 document: one request per operation,
+// with parameters and bodies from examples, or generated from schemas.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Document is subset of OpenAPI 3 document, that is needed to make requests.
+// See https://spec.openapis.org/oas/v3.0.3
+type Document struct {
+	OpenAPI    string              `yaml:"openapi"`
+	Servers    []Server            `yaml:"servers"`
+	Paths      map[string]PathItem `yaml:"paths"`
+	Components Components          `yaml:"components"`
+}
+
+type Server struct {
+	URL       string                    `yaml:"url"`
+	Variables map[string]ServerVariable `yaml:"variables"`
+}
+
+type ServerVariable struct {
+	Default string `yaml:"default"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas"`
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+}
+
+type PathItem struct {
+	Parameters []*Parameter `yaml:"parameters"`
+	Get        *Operation   `yaml:"get"`
+	Put        *Operation   `yaml:"put"`
+	Post       *Operation   `yaml:"post"`
+	Delete     *Operation   `yaml:"delete"`
+	Options    *Operation   `yaml:"options"`
+	Head       *Operation   `yaml:"head"`
+	Patch      *Operation   `yaml:"patch"`
+}
+
+type Operation struct {
+	OperationID string       `yaml:"operationId"`
+	Parameters  []*Parameter `yaml:"parameters"`
+	RequestBody *RequestBody `yaml:"requestBody"`
+}
+
+type Parameter struct {
+	Ref      string     `yaml:"$ref"`
+	Name     string     `yaml:"name"`
+	In       string     `yaml:"in"`
+	Required bool       `yaml:"required"`
+	Schema   *Schema    `yaml:"schema"`
+	Example  any        `yaml:"example"`
+	Examples exampleMap `yaml:"examples"`
+}
+
+type RequestBody struct {
+	Ref     string               `yaml:"$ref"`
+	Content map[string]MediaType `yaml:"content"`
+}
+
+type MediaType struct {
+	Schema   *Schema    `yaml:"schema"`
+	Example  any        `yaml:"example"`
+	Examples exampleMap `yaml:"examples"`
+}
+
+type exampleMap map[string]struct {
+	Value any `yaml:"value"`
+}
+
+// first returns value of first example by name, to be deterministic.
+func (m exampleMap) first() (any, bool) {
+	if len(m) == 0 {
+		return nil, false
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return m[names[0]].Value, true
+}
+
+type Schema struct {
+	Ref        string             `yaml:"$ref"`
+	Type       string             `yaml:"type"`
+	Format     string             `yaml:"format"`
+	Properties map[string]*Schema `yaml:"properties"`
+	Items      *Schema            `yaml:"items"`
+	AllOf      []*Schema          `yaml:"allOf"`
+	OneOf      []*Schema          `yaml:"oneOf"`
+	AnyOf      []*Schema          `yaml:"anyOf"`
+	Enum       []any              `yaml:"enum"`
+	Example    any                `yaml:"example"`
+	Default    any                `yaml:"default"`
+	Minimum    *float64           `yaml:"minimum"`
+	ReadOnly   bool               `yaml:"readOnly"`
+}
+
+// Parse reads OpenAPI 3 document in YAML or JSON.
+func Parse(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("openapi document parse failed: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("only OpenAPI 3 documents are supported, got version %q", doc.OpenAPI)
+	}
+	return &doc, nil
+}
+
+// Request is HTTP request of operation.
+type Request struct {
+	// Name is operationId, or method and path, if operation has no id. It is valid identifier,
+	// so it can be used as request name in scenario.
+	Name   string
+	Method string
+	// URI is path with query. Path starts with path of first server, if document has servers.
+	URI     string
+	Headers map[string]string
+	Body    string
+}
+
+var methods = []struct {
+	name string
+	op   func(PathItem) *Operation
+}{
+	{"GET", func(p PathItem) *Operation { return p.Get }},
+	{"HEAD", func(p PathItem) *Operation { return p.Head }},
+	{"POST", func(p PathItem) *Operation { return p.Post }},
+	{"PUT", func(p PathItem) *Operation { return p.Put }},
+	{"PATCH", func(p PathItem) *Operation { return p.Patch }},
+	{"DELETE", func(p PathItem) *Operation { return p.Delete }},
+	{"OPTIONS", func(p PathItem) *Operation { return p.Options }},
+}
+
+// Requests returns request for every operation of document, sorted by path and method.
+func (d *Document) Requests() ([]Request, error) {
+	prefix, err := d.basePath()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(d.Paths))
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var requests []Request
+	names := map[string]bool{}
+	for _, path := range paths {
+		item := d.Paths[path]
+		for _, m := range methods {
+			op := m.op(item)
+			if op == nil {
+				continue
+			}
+			req, err := d.request(prefix, path, m.name, item, op)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", m.name, path, err)
+			}
+			if names[req.Name] {
+				return nil, fmt.Errorf("%s %s: duplicate operation name %q", m.name, path, req.Name)
+			}
+			names[req.Name] = true
+			requests = append(requests, req)
+		}
+	}
+	return requests, nil
+}
+
+// basePath returns path of first server URL without trailing slash.
+func (d *Document) basePath() (string, error) {
+	if len(d.Servers) == 0 {
+		return "", nil
+	}
+	server := d.Servers[0]
+	rawURL := server.URL
+	for name, v := range server.Variables {
+		rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", v.Default)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server url %q: %w", server.URL, err)
+	}
+	return strings.TrimSuffix(u.Path, "/"), nil
+}
+
+var nonIdentifierChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+func operationName(method, path string, op *Operation) string {
+	name := op.OperationID
+	if name == "" {
+		// GET /items/{id} is named get_items_id.
+		name = strings.ToLower(method) + "_" + path
+	}
+	return strings.Trim(nonIdentifierChars.ReplaceAllString(name, "_"), "_")
+}
+
+func (d *Document) request(prefix, path, method string, item PathItem, op *Operation) (Request, error) {
+	req := Request{
+		Name:    operationName(method, path, op),
+		Method:  method,
+		Headers: map[string]string{},
+	}
+	params, err := d.parameters(item.Parameters, op.Parameters)
+	if err != nil {
+		return req, err
+	}
+	query := url.Values{}
+	for _, p := range params {
+		value, ok := d.parameterValue(p)
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(value))
+		case "query":
+			if ok || p.Required {
+				query.Set(p.Name, value)
+			}
+		case "header":
+			if ok || p.Required {
+				req.Headers[p.Name] = value
+			}
+		}
+	}
+	req.URI = prefix + path
+	if len(query) > 0 {
+		req.URI += "?" + query.Encode()
+	}
+	if op.RequestBody != nil {
+		body := op.RequestBody
+		if body.Ref != "" {
+			body = d.Components.RequestBodies[refName(body.Ref)]
+			if body == nil {
+				return req, fmt.Errorf("unresolved request body reference %q", op.RequestBody.Ref)
+			}
+		}
+		contentType, content, err := d.body(body)
+		if err != nil {
+			return req, err
+		}
+		if contentType != "" {
+			req.Headers["Content-Type"] = contentType
+			req.Body = content
+		}
+	}
+	return req, nil
+}
+
+// parameters merges path item and operation parameters. Operation parameters override path item ones.
+func (d *Document) parameters(lists ...[]*Parameter) ([]*Parameter, error) {
+	var result []*Parameter
+	index := map[string]int{}
+	for _, list := range lists {
+		for _, p := range list {
+			if p.Ref != "" {
+				resolved := d.Components.Parameters[refName(p.Ref)]
+				if resolved == nil {
+					return nil, fmt.Errorf("unresolved parameter reference %q", p.Ref)
+				}
+				p = resolved
+			}
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				result[i] = p
+				continue
+			}
+			index[key] = len(result)
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// parameterValue returns value of parameter example, or value generated from schema.
+// Second result is false, if value is generated.
+func (d *Document) parameterValue(p *Parameter) (string, bool) {
+	if p.Example != nil {
+		return formatParameter(normalize(p.Example)), true
+	}
+	if v, ok := p.Examples.first(); ok {
+		return formatParameter(normalize(v)), true
+	}
+	if p.Schema != nil && (p.Schema.Example != nil || p.Schema.Default != nil) {
+		return formatParameter(d.example(p.Schema)), true
+	}
+	return formatParameter(d.example(p.Schema)), false
+}
+
+// formatParameter formats value in default, simple or form style: arrays are comma separated.
+func formatParameter(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatParameter(item)
+		}
+		return strings.Join(items, ",")
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// body returns content type and content of request body. JSON content is preferred.
+func (d *Document) body(body *RequestBody) (string, string, error) {
+	if len(body.Content) == 0 {
+		return "", "", nil
+	}
+	types := make([]string, 0, len(body.Content))
+	for t := range body.Content {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return bodyTypePriority(types[i]) < bodyTypePriority(types[j]) ||
+			bodyTypePriority(types[i]) == bodyTypePriority(types[j]) && types[i] < types[j]
+	})
+	contentType := types[0]
+	media := body.Content[contentType]
+	value := normalize(media.Example)
+	if value == nil {
+		var ok bool
+		if value, ok = media.Examples.first(); ok {
+			value = normalize(value)
+		} else {
+			value = d.example(media.Schema)
+		}
+	}
+	switch {
+	case isJSON(contentType):
+		data, err := json.Marshal(value)
+		return contentType, string(data), err
+	case contentType == "application/x-www-form-urlencoded":
+		form := url.Values{}
+		if fields, ok := value.(map[string]any); ok {
+			for k, v := range fields {
+				form.Set(k, formatParameter(v))
+			}
+		}
+		return contentType, form.Encode(), nil
+	}
+	return contentType, formatParameter(value), nil
+}
+
+func bodyTypePriority(contentType string) int {
+	switch {
+	case isJSON(contentType):
+		return 0
+	case contentType == "application/x-www-form-urlencoded":
+		return 1
+	case strings.HasPrefix(contentType, "text/"):
+		return 2
+	}
+	return 3
+}
+
+func isJSON(contentType string) bool {
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
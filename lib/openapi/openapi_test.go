@@ -0,0 +1,112 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `openapi: 3.0.3
+info: {title: Shop, version: "1"}
+servers:
+  - url: https://{env}.example.com/v1/
+    variables:
+      env: {default: api}
+paths:
+  /items/{id}:
+    parameters:
+      - $ref: '#/components/parameters/ItemID'
+    get:
+      operationId: getItem
+      parameters:
+        - {name: full, in: query, schema: {type: boolean}}
+        - {name: lang, in: query, required: true, schema: {type: string, enum: [en, ru]}}
+        - {name: X-Request-ID, in: header, required: true, schema: {type: string, format: uuid}}
+    delete:
+      responses: {"204": {description: ok}}
+  /items:
+    post:
+      operationId: createItem
+      requestBody:
+        $ref: '#/components/requestBodies/Item'
+  /login:
+    post:
+      operationId: login
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                user: {type: string, example: admin}
+                pass: {type: string}
+components:
+  parameters:
+    ItemID: {name: id, in: path, required: true, schema: {type: integer}, example: 42}
+  requestBodies:
+    Item:
+      content:
+        text/plain:
+          schema: {type: string}
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Item'
+  schemas:
+    Item:
+      allOf:
+        - $ref: '#/components/schemas/Base'
+        - type: object
+          properties:
+            name: {type: string, example: "Tea"}
+            price: {type: number, minimum: 10}
+            tags: {type: array, items: {type: string}}
+            created: {type: string, format: date-time}
+            parent: {$ref: '#/components/schemas/Item'}
+    Base:
+      type: object
+      properties:
+        id: {type: integer, readOnly: true}
+        kind: {type: string, default: item}
+`
+
+func TestDocument_Requests(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testDocument))
+	require.NoError(t, err)
+	requests, err := doc.Requests()
+	require.NoError(t, err)
+	assert.Equal(t, []Request{
+		{
+			Name:    "createItem",
+			Method:  "POST",
+			URI:     "/v1/items",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    `{"created":"2026-01-01T00:00:00Z","kind":"item","name":"Tea","price":10,"tags":["string"]}`,
+		},
+		{
+			Name:    "getItem",
+			Method:  "GET",
+			URI:     "/v1/items/42?lang=en",
+			Headers: map[string]string{"X-Request-ID": "00000000-0000-0000-0000-000000000001"},
+		},
+		{
+			Name:    "delete_items_id",
+			Method:  "DELETE",
+			URI:     "/v1/items/42",
+			Headers: map[string]string{},
+		},
+		{
+			Name:    "login",
+			Method:  "POST",
+			URI:     "/v1/login",
+			Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			Body:    "pass=string&user=admin",
+		},
+	}, requests)
+}
+
+func TestParse_Version(t *testing.T) {
+	_, err := Parse(strings.NewReader(`{"swagger": "2.0", "paths": {}}`))
+	assert.ErrorContains(t, err, "only OpenAPI 3")
+}
@@ -0,0 +1,254 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	client = netip.MustParseAddrPort("10.0.0.1:50000")
+	server = netip.MustParseAddrPort("10.0.0.2:80")
+	start  = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+)
+
+// tcpIP makes IP packet with TCP segment. Checksums are not set: reader doesn't check them.
+func tcpIP(src, dst netip.AddrPort, seq uint32, flags byte, payload string) []byte {
+	tcp := make([]byte, 20, 20+len(payload))
+	binary.BigEndian.PutUint16(tcp[0:2], src.Port())
+	binary.BigEndian.PutUint16(tcp[2:4], dst.Port())
+	binary.BigEndian.PutUint32(tcp[4:8], seq)
+	tcp[12] = 5 << 4
+	tcp[13] = flags
+	tcp = append(tcp, payload...)
+	if src.Addr().Is6() {
+		ip := make([]byte, 40)
+		ip[0] = 6 << 4
+		binary.BigEndian.PutUint16(ip[4:6], uint16(len(tcp)))
+		ip[6] = ipProtocolTCP
+		s, d := src.Addr().As16(), dst.Addr().As16()
+		copy(ip[8:24], s[:])
+		copy(ip[24:40], d[:])
+		return append(ip, tcp...)
+	}
+	ip := make([]byte, 20)
+	ip[0] = 4<<4 | 5
+	binary.BigEndian.PutUint16(ip[2:4], uint16(20+len(tcp)))
+	ip[9] = ipProtocolTCP
+	s, d := src.Addr().As4(), dst.Addr().As4()
+	copy(ip[12:16], s[:])
+	copy(ip[16:20], d[:])
+	return append(ip, tcp...)
+}
+
+func ethernet(etherType uint16, vlan bool, payload []byte) []byte {
+	frame := make([]byte, 12, 18+len(payload))
+	if vlan {
+		frame = binary.BigEndian.AppendUint16(frame, etherTypeVLAN)
+		frame = binary.BigEndian.AppendUint16(frame, 42)
+	}
+	frame = binary.BigEndian.AppendUint16(frame, etherType)
+	return append(frame, payload...)
+}
+
+func writePcap(order binary.AppendByteOrder, nanos bool, linkType int, packets []Packet) []byte {
+	magic := uint32(pcapMagicMicros)
+	if nanos {
+		magic = pcapMagicNanos
+	}
+	var buf []byte
+	buf = order.AppendUint32(buf, magic)
+	buf = order.AppendUint16(buf, 2)
+	buf = order.AppendUint16(buf, 4)
+	buf = append(buf, make([]byte, 8)...)
+	buf = order.AppendUint32(buf, 65535)
+	buf = order.AppendUint32(buf, uint32(linkType))
+	for _, p := range packets {
+		frac := p.Time.Nanosecond()
+		if !nanos {
+			frac /= 1000
+		}
+		buf = order.AppendUint32(buf, uint32(p.Time.Unix()))
+		buf = order.AppendUint32(buf, uint32(frac))
+		buf = order.AppendUint32(buf, uint32(len(p.Data)))
+		buf = order.AppendUint32(buf, uint32(len(p.Data)))
+		buf = append(buf, p.Data...)
+	}
+	return buf
+}
+
+// writePcapng writes section with interface of nanosecond resolution.
+func writePcapng(order binary.AppendByteOrder, linkType int, packets []Packet) []byte {
+	var buf []byte
+	block := func(blockType uint32, body []byte) {
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+		length := uint32(12 + len(body))
+		buf = order.AppendUint32(buf, blockType)
+		buf = order.AppendUint32(buf, length)
+		buf = append(buf, body...)
+		buf = order.AppendUint32(buf, length)
+	}
+	var shb []byte
+	shb = order.AppendUint32(shb, pcapngByteOrderMagic)
+	shb = order.AppendUint16(shb, 1)
+	shb = order.AppendUint16(shb, 0)
+	shb = order.AppendUint64(shb, ^uint64(0))
+	block(pcapngSectionHeader, shb)
+	// Name resolution block should be skipped.
+	block(4, make([]byte, 4))
+	var idb []byte
+	idb = order.AppendUint16(idb, uint16(linkType))
+	idb = order.AppendUint16(idb, 0)
+	idb = order.AppendUint32(idb, 0)
+	idb = order.AppendUint16(idb, pcapngOptionIfTSResol)
+	idb = order.AppendUint16(idb, 1)
+	idb = append(idb, 9, 0, 0, 0)
+	idb = order.AppendUint32(idb, pcapngOptionEnd)
+	block(pcapngInterfaceDesc, idb)
+	for _, p := range packets {
+		ts := uint64(p.Time.UnixNano())
+		var epb []byte
+		epb = order.AppendUint32(epb, 0)
+		epb = order.AppendUint32(epb, uint32(ts>>32))
+		epb = order.AppendUint32(epb, uint32(ts))
+		epb = order.AppendUint32(epb, uint32(len(p.Data)))
+		epb = order.AppendUint32(epb, uint32(len(p.Data)))
+		epb = append(epb, p.Data...)
+		block(pcapngEnhancedPacket, epb)
+	}
+	return buf
+}
+
+func readStreams(t *testing.T, data []byte) []*Stream {
+	r, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	streams, err := ReadStreams(r)
+	require.NoError(t, err)
+	return streams
+}
+
+func TestReadStreams_Pcap(t *testing.T) {
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	packet := func(ms int, ip []byte) Packet {
+		return Packet{Time: at(ms), Data: ethernet(etherTypeIPv4, ms == 0, ip)}
+	}
+	packets := []Packet{
+		packet(0, tcpIP(client, server, 999, tcpFlagSYN, "")),
+		packet(1, tcpIP(server, client, 4999, tcpFlagSYN, "")),
+		// Segments are reordered.
+		packet(2, tcpIP(client, server, 1010, 0, "HTTP/1.1\r\n\r\n")),
+		packet(3, tcpIP(client, server, 1000, 0, "GET /item ")),
+		// Retransmission, that overlaps received data.
+		packet(4, tcpIP(client, server, 1005, 0, "item HTTP/")),
+		packet(5, tcpIP(server, client, 5000, 0, "HTTP/1.1 200 OK\r\n\r\n")),
+		packet(6, tcpIP(client, server, 1022, tcpFlagFIN, "")),
+	}
+	for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, nanos := range []bool{false, true} {
+			streams := readStreams(t, writePcap(order, nanos, LinkTypeEthernet, packets))
+			require.Len(t, streams, 2)
+			assert.Equal(t, client, streams[0].Src)
+			assert.Equal(t, server, streams[0].Dst)
+			assert.Equal(t, "GET /item HTTP/1.1\r\n\r\n", string(streams[0].Data))
+			assert.WithinDuration(t, at(3), streams[0].TimeAt(0), 0)
+			assert.WithinDuration(t, at(2), streams[0].TimeAt(15), 0)
+			assert.Equal(t, "HTTP/1.1 200 OK\r\n\r\n", string(streams[1].Data))
+		}
+	}
+}
+
+func TestReadStreams_Pcapng(t *testing.T) {
+	v6client := netip.MustParseAddrPort("[2001:db8::1]:40000")
+	v6server := netip.MustParseAddrPort("[2001:db8::2]:8080")
+	packets := []Packet{
+		// Capture started in the middle of connection.
+		{Time: start.Add(1), Data: tcpIP(v6client, v6server, 7, 0, "second")},
+		{Time: start.Add(2), Data: tcpIP(v6client, v6server, 1, 0, "first ")},
+		// Lost segment makes gap, so the rest of stream is dropped.
+		{Time: start.Add(3), Data: tcpIP(v6client, v6server, 20, 0, "lost")},
+		// Connection is opened again from the same port.
+		{Time: start.Add(4), Data: tcpIP(v6client, v6server, 100, tcpFlagSYN, "")},
+		{Time: start.Add(5), Data: tcpIP(v6client, v6server, 101, 0, "new")},
+	}
+	for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+		streams := readStreams(t, writePcapng(order, LinkTypeRaw, packets))
+		require.Len(t, streams, 2)
+		assert.Equal(t, v6client, streams[0].Src)
+		assert.Equal(t, "first second", string(streams[0].Data))
+		assert.WithinDuration(t, start.Add(2), streams[0].TimeAt(0), 0)
+		assert.WithinDuration(t, start.Add(1), streams[0].TimeAt(6), 0)
+		assert.Equal(t, "new", string(streams[1].Data))
+		assert.WithinDuration(t, start.Add(5), streams[1].TimeAt(0), 0)
+	}
+}
+
+func TestDecodeTCP_LinkTypes(t *testing.T) {
+	ip := tcpIP(client, server, 1, 0, "data")
+	for name, p := range map[string]Packet{
+		"null":     {LinkType: LinkTypeNull, Data: append([]byte{2, 0, 0, 0}, ip...)},
+		"sll":      {LinkType: LinkTypeLinuxSLL, Data: append(binary.BigEndian.AppendUint16(make([]byte, 14), etherTypeIPv4), ip...)},
+		"sll2":     {LinkType: LinkTypeSLL2, Data: append(binary.BigEndian.AppendUint16(nil, etherTypeIPv4), append(make([]byte, 18), ip...)...)},
+		"ipv4":     {LinkType: LinkTypeIPv4, Data: ip},
+		"padding":  {LinkType: LinkTypeEthernet, Data: append(ethernet(etherTypeIPv4, false, ip), 0, 0, 0)},
+		"ethernet": {LinkType: LinkTypeEthernet, Data: ethernet(etherTypeIPv4, true, ip)},
+	} {
+		s, ok := DecodeTCP(p)
+		require.True(t, ok, name)
+		assert.Equal(t, client, s.Src, name)
+		assert.Equal(t, server, s.Dst, name)
+		assert.Equal(t, "data", string(s.Payload), name)
+	}
+
+	fragment := tcpIP(client, server, 1, 0, "data")
+	fragment[6] = 0x20 // More fragments.
+	for name, p := range map[string]Packet{
+		"arp":       {LinkType: LinkTypeEthernet, Data: ethernet(0x0806, false, ip)},
+		"fragment":  {LinkType: LinkTypeRaw, Data: fragment},
+		"truncated": {LinkType: LinkTypeRaw, Data: ip[:30]},
+		"unknown":   {LinkType: 147, Data: ip},
+	} {
+		_, ok := DecodeTCP(p)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestNewReader_Errors(t *testing.T) {
+	_, err := NewReader(strings.NewReader("GET / HTTP/1.1\r\n"))
+	assert.ErrorContains(t, err, "unknown capture format")
+
+	data := writePcap(binary.LittleEndian, false, LinkTypeRaw, []Packet{{Time: start, Data: []byte("packet")}})
+	r, err := NewReader(bytes.NewReader(data[:len(data)-2]))
+	require.NoError(t, err)
+	_, err = ReadStreams(r)
+	assert.ErrorContains(t, err, "packet 1 read failed: truncated pcap record")
+
+	data = writePcapng(binary.LittleEndian, LinkTypeRaw, nil)
+	emptyBlock := func(blockType uint32) []byte {
+		var b []byte
+		b = binary.LittleEndian.AppendUint32(b, blockType)
+		b = binary.LittleEndian.AppendUint32(b, 12)
+		return binary.LittleEndian.AppendUint32(b, 12)
+	}
+	for _, tt := range []struct {
+		name, wantErr string
+		tail          []byte
+	}{
+		{"simple packet", "pcapng simple packet block is too short", emptyBlock(pcapngSimplePacket)},
+		{"enhanced packet", "pcapng enhanced packet block is too short", emptyBlock(pcapngEnhancedPacket)},
+		{"interface description", "pcapng interface description block is too short", emptyBlock(pcapngInterfaceDesc)},
+		{"truncated", "truncated pcapng block", emptyBlock(pcapngSimplePacket)[:10]},
+	} {
+		r, err := NewReader(bytes.NewReader(append(bytes.Clone(data), tt.tail...)))
+		require.NoError(t, err)
+		_, err = ReadStreams(r)
+		assert.ErrorContains(t, err, tt.wantErr, tt.name)
+	}
+}
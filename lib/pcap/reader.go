@@ -0,0 +1,264 @@
+// Package pcap reads pcap and pcapng capture files and reassembles TCP streams from them.
+// It is pure Go, so no libpcap is needed.
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Link types. See https://www.tcpdump.org/linktypes.html
+const (
+	LinkTypeNull     = 0
+	LinkTypeEthernet = 1
+	LinkTypeRaw      = 101
+	LinkTypeLinuxSLL = 113
+	LinkTypeIPv4     = 228
+	LinkTypeIPv6     = 229
+	LinkTypeSLL2     = 276
+)
+
+const (
+	pcapMagicMicros = 0xa1b2c3d4
+	pcapMagicNanos  = 0xa1b23c4d
+
+	pcapngSectionHeader       = 0x0a0d0d0a
+	pcapngInterfaceDesc       = 0x00000001
+	pcapngSimplePacket        = 0x00000003
+	pcapngEnhancedPacket      = 0x00000006
+	pcapngByteOrderMagic      = 0x1a2b3c4d
+	pcapngOptionEnd           = 0
+	pcapngOptionIfTSResol     = 9
+	pcapngDefaultTSResolution = 6 // Microseconds.
+	pcapngMaxBlockSize        = 64 << 20
+)
+
+// Packet is captured link layer frame.
+type Packet struct {
+	// Time is zero for pcapng simple packet blocks, that have no timestamp.
+	Time     time.Time
+	LinkType int
+	Data     []byte
+}
+
+// Reader reads packets from pcap or pcapng file. Format is detected by magic number.
+type Reader struct {
+	r    *bufio.Reader
+	next func() (Packet, error)
+
+	// pcap state.
+	order    binary.ByteOrder
+	nanos    bool
+	linkType int
+
+	// pcapng state.
+	interfaces []pcapngInterface
+}
+
+type pcapngInterface struct {
+	linkType int
+	// tsPerSecond is number of timestamp units in second.
+	tsPerSecond uint64
+}
+
+// NewReader reads capture file header.
+func NewReader(r io.Reader) (*Reader, error) {
+	pr := &Reader{r: bufio.NewReader(r)}
+	magic, err := pr.r.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("capture header read failed: %w", err)
+	}
+	switch {
+	case binary.BigEndian.Uint32(magic) == pcapngSectionHeader:
+		pr.next = pr.nextPcapng
+		return pr, nil
+	case binary.LittleEndian.Uint32(magic) == pcapMagicMicros || binary.LittleEndian.Uint32(magic) == pcapMagicNanos:
+		pr.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(magic) == pcapMagicMicros || binary.BigEndian.Uint32(magic) == pcapMagicNanos:
+		pr.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("unknown capture format: magic %x", magic)
+	}
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(pr.r, header); err != nil {
+		return nil, fmt.Errorf("pcap header read failed: %w", err)
+	}
+	pr.nanos = pr.order.Uint32(header[0:4]) == pcapMagicNanos
+	pr.linkType = int(pr.order.Uint32(header[20:24]) & 0xffff)
+	pr.next = pr.nextPcap
+	return pr, nil
+}
+
+// Next returns next packet, or io.EOF, if there is no more packets.
+func (r *Reader) Next() (Packet, error) {
+	return r.next()
+}
+
+func (r *Reader) nextPcap() (Packet, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return Packet{}, fmt.Errorf("truncated pcap record header: %w", err)
+		}
+		return Packet{}, err
+	}
+	sec := int64(r.order.Uint32(header[0:4]))
+	frac := int64(r.order.Uint32(header[4:8]))
+	capLen := r.order.Uint32(header[8:12])
+	if capLen > pcapngMaxBlockSize {
+		return Packet{}, fmt.Errorf("pcap record is too big: %d bytes", capLen)
+	}
+	data := make([]byte, capLen)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return Packet{}, fmt.Errorf("truncated pcap record: %w", err)
+	}
+	if !r.nanos {
+		frac *= int64(time.Microsecond)
+	}
+	return Packet{Time: time.Unix(sec, frac), LinkType: r.linkType, Data: data}, nil
+}
+
+func (r *Reader) nextPcapng() (Packet, error) {
+	for {
+		blockType, body, err := r.readPcapngBlock()
+		if err != nil {
+			return Packet{}, err
+		}
+		switch blockType {
+		case pcapngSectionHeader:
+			// Interfaces are numbered from zero in every section.
+			r.interfaces = nil
+		case pcapngInterfaceDesc:
+			if len(body) < 8 {
+				return Packet{}, errors.New("pcapng interface description block is too short")
+			}
+			iface := pcapngInterface{linkType: int(r.order.Uint16(body[0:2]))}
+			iface.setResolution(pcapngDefaultTSResolution)
+			r.forEachOption(body[8:], func(code uint16, value []byte) {
+				if code == pcapngOptionIfTSResol && len(value) > 0 {
+					iface.setResolution(value[0])
+				}
+			})
+			if iface.tsPerSecond == 0 {
+				return Packet{}, errors.New("pcapng interface has invalid timestamp resolution")
+			}
+			r.interfaces = append(r.interfaces, iface)
+		case pcapngEnhancedPacket:
+			if len(body) < 20 {
+				return Packet{}, errors.New("pcapng enhanced packet block is too short")
+			}
+			id := int(r.order.Uint32(body[0:4]))
+			if id >= len(r.interfaces) {
+				return Packet{}, fmt.Errorf("pcapng packet of unknown interface %d", id)
+			}
+			iface := r.interfaces[id]
+			ts := uint64(r.order.Uint32(body[4:8]))<<32 | uint64(r.order.Uint32(body[8:12]))
+			capLen := int(r.order.Uint32(body[12:16]))
+			if 20+capLen > len(body) {
+				return Packet{}, errors.New("pcapng packet data exceeds block")
+			}
+			return Packet{Time: iface.time(ts), LinkType: iface.linkType, Data: body[20 : 20+capLen]}, nil
+		case pcapngSimplePacket:
+			if len(body) < 4 {
+				return Packet{}, errors.New("pcapng simple packet block is too short")
+			}
+			if len(r.interfaces) == 0 {
+				return Packet{}, errors.New("pcapng simple packet without interface")
+			}
+			data := body[4:]
+			if origLen := int(r.order.Uint32(body[0:4])); origLen < len(data) {
+				data = data[:origLen]
+			}
+			return Packet{LinkType: r.interfaces[0].linkType, Data: data}, nil
+		}
+		// Other blocks, like statistics or name resolution, are skipped.
+	}
+}
+
+// readPcapngBlock reads block, and returns its type and body without length fields.
+func (r *Reader) readPcapngBlock() (uint32, []byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, fmt.Errorf("truncated pcapng block header: %w", err)
+		}
+		return 0, nil, err
+	}
+	blockType := binary.BigEndian.Uint32(header[0:4])
+	if blockType == pcapngSectionHeader {
+		// Byte order is defined by section header, that follows block length.
+		bom, err := r.r.Peek(4)
+		if err != nil {
+			return 0, nil, fmt.Errorf("pcapng section header read failed: %w", err)
+		}
+		switch {
+		case binary.LittleEndian.Uint32(bom) == pcapngByteOrderMagic:
+			r.order = binary.LittleEndian
+		case binary.BigEndian.Uint32(bom) == pcapngByteOrderMagic:
+			r.order = binary.BigEndian
+		default:
+			return 0, nil, fmt.Errorf("invalid pcapng byte order magic %x", bom)
+		}
+	} else {
+		blockType = r.order.Uint32(header[0:4])
+	}
+	length := r.order.Uint32(header[4:8])
+	if length < 12 || length%4 != 0 || length > pcapngMaxBlockSize {
+		return 0, nil, fmt.Errorf("invalid pcapng block length %d", length)
+	}
+	rest := make([]byte, length-8)
+	if _, err := io.ReadFull(r.r, rest); err != nil {
+		return 0, nil, fmt.Errorf("truncated pcapng block: %w", err)
+	}
+	return blockType, rest[:len(rest)-4], nil
+}
+
+func (r *Reader) forEachOption(options []byte, f func(code uint16, value []byte)) {
+	for len(options) >= 4 {
+		code := r.order.Uint16(options[0:2])
+		length := int(r.order.Uint16(options[2:4]))
+		if code == pcapngOptionEnd || 4+length > len(options) {
+			return
+		}
+		f(code, options[4:4+length])
+		next := 4 + (length+3)/4*4
+		if next > len(options) {
+			return
+		}
+		options = options[next:]
+	}
+}
+
+// setResolution sets timestamp resolution from if_tsresol option: power of 10, or of 2, if high bit is set.
+func (i *pcapngInterface) setResolution(resol byte) {
+	exp := uint64(resol & 0x7f)
+	if resol&0x80 != 0 {
+		i.tsPerSecond = 0
+		if exp < 64 {
+			i.tsPerSecond = 1 << exp
+		}
+		return
+	}
+	if exp > 19 {
+		// Doesn't fit uint64.
+		i.tsPerSecond = 0
+		return
+	}
+	i.tsPerSecond = 1
+	for ; exp > 0; exp-- {
+		i.tsPerSecond *= 10
+	}
+}
+
+func (i *pcapngInterface) time(ts uint64) time.Time {
+	sec, frac := ts/i.tsPerSecond, ts%i.tsPerSecond
+	nsec := int64(float64(frac) / float64(i.tsPerSecond) * float64(time.Second))
+	if uint64(time.Second)%i.tsPerSecond == 0 {
+		nsec = int64(frac * (uint64(time.Second) / i.tsPerSecond))
+	}
+	return time.Unix(int64(sec), nsec)
+}
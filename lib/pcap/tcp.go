@@ -0,0 +1,310 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"time"
+)
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
+
+	ipProtocolTCP = 6
+
+	tcpFlagFIN = 0x01
+	tcpFlagSYN = 0x02
+	tcpFlagRST = 0x04
+)
+
+// Segment is TCP segment of captured packet.
+type Segment struct {
+	Time     time.Time
+	Src, Dst netip.AddrPort
+	Seq      uint32
+	SYN      bool
+	FIN      bool
+	RST      bool
+	Payload  []byte
+}
+
+// DecodeTCP decodes link, IP and TCP headers of packet.
+// It returns false, if packet is not TCP, is IP fragment, or has unsupported link type.
+func DecodeTCP(p Packet) (Segment, bool) {
+	data, etherType, ok := decodeLink(p.LinkType, p.Data)
+	if !ok {
+		return Segment{}, false
+	}
+	var (
+		src, dst netip.Addr
+		payload  []byte
+	)
+	switch etherType {
+	case etherTypeIPv4:
+		if len(data) < 20 || data[0]>>4 != 4 {
+			return Segment{}, false
+		}
+		headerLen := int(data[0]&0x0f) * 4
+		totalLen := int(binary.BigEndian.Uint16(data[2:4]))
+		fragment := binary.BigEndian.Uint16(data[6:8])
+		// More fragments flag, or fragment offset.
+		if fragment&0x3fff != 0 || data[9] != ipProtocolTCP || headerLen < 20 || totalLen < headerLen {
+			return Segment{}, false
+		}
+		if totalLen < len(data) {
+			// Ethernet frame padding.
+			data = data[:totalLen]
+		}
+		if headerLen > len(data) {
+			return Segment{}, false
+		}
+		src = netip.AddrFrom4([4]byte(data[12:16]))
+		dst = netip.AddrFrom4([4]byte(data[16:20]))
+		payload = data[headerLen:]
+	case etherTypeIPv6:
+		if len(data) < 40 || data[0]>>4 != 6 {
+			return Segment{}, false
+		}
+		if payloadLen := int(binary.BigEndian.Uint16(data[4:6])); 40+payloadLen < len(data) {
+			data = data[:40+payloadLen]
+		}
+		src = netip.AddrFrom16([16]byte(data[8:24]))
+		dst = netip.AddrFrom16([16]byte(data[24:40]))
+		next := data[6]
+		payload = data[40:]
+		for next != ipProtocolTCP {
+			switch next {
+			case 0, 43, 60: // Hop-by-hop, routing and destination options.
+				if len(payload) < 8 {
+					return Segment{}, false
+				}
+				extLen := (int(payload[1]) + 1) * 8
+				if extLen > len(payload) {
+					return Segment{}, false
+				}
+				next = payload[0]
+				payload = payload[extLen:]
+			default:
+				// Fragments and other protocols.
+				return Segment{}, false
+			}
+		}
+	default:
+		return Segment{}, false
+	}
+	if len(payload) < 20 {
+		return Segment{}, false
+	}
+	dataOffset := int(payload[12]>>4) * 4
+	if dataOffset < 20 || dataOffset > len(payload) {
+		return Segment{}, false
+	}
+	flags := payload[13]
+	return Segment{
+		Time:    p.Time,
+		Src:     netip.AddrPortFrom(src, binary.BigEndian.Uint16(payload[0:2])),
+		Dst:     netip.AddrPortFrom(dst, binary.BigEndian.Uint16(payload[2:4])),
+		Seq:     binary.BigEndian.Uint32(payload[4:8]),
+		SYN:     flags&tcpFlagSYN != 0,
+		FIN:     flags&tcpFlagFIN != 0,
+		RST:     flags&tcpFlagRST != 0,
+		Payload: payload[dataOffset:],
+	}, true
+}
+
+// decodeLink returns network layer data and its ether type.
+func decodeLink(linkType int, data []byte) ([]byte, int, bool) {
+	switch linkType {
+	case LinkTypeEthernet:
+		if len(data) < 14 {
+			return nil, 0, false
+		}
+		etherType := int(binary.BigEndian.Uint16(data[12:14]))
+		data = data[14:]
+		for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+			if len(data) < 4 {
+				return nil, 0, false
+			}
+			etherType = int(binary.BigEndian.Uint16(data[2:4]))
+			data = data[4:]
+		}
+		return data, etherType, true
+	case LinkTypeNull:
+		// Address family is in byte order of capturing host. IPv6 has different values on different systems.
+		if len(data) < 4 {
+			return nil, 0, false
+		}
+		family := binary.LittleEndian.Uint32(data[0:4])
+		if family > 0xffff {
+			family = binary.BigEndian.Uint32(data[0:4])
+		}
+		switch family {
+		case 2:
+			return data[4:], etherTypeIPv4, true
+		case 24, 28, 30:
+			return data[4:], etherTypeIPv6, true
+		}
+		return nil, 0, false
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+		if len(data) == 0 {
+			return nil, 0, false
+		}
+		switch data[0] >> 4 {
+		case 4:
+			return data, etherTypeIPv4, true
+		case 6:
+			return data, etherTypeIPv6, true
+		}
+		return nil, 0, false
+	case LinkTypeLinuxSLL:
+		if len(data) < 16 {
+			return nil, 0, false
+		}
+		return data[16:], int(binary.BigEndian.Uint16(data[14:16])), true
+	case LinkTypeSLL2:
+		if len(data) < 20 {
+			return nil, 0, false
+		}
+		return data[20:], int(binary.BigEndian.Uint16(data[0:2])), true
+	}
+	return nil, 0, false
+}
+
+// Stream is reassembled data, that was sent in one direction of TCP connection.
+type Stream struct {
+	Src, Dst netip.AddrPort
+	Data     []byte
+	chunks   []chunk
+}
+
+// chunk is part of stream data, that came in one segment.
+type chunk struct {
+	offset int
+	time   time.Time
+}
+
+// TimeAt returns capture time of segment, that contains stream byte at offset.
+func (s *Stream) TimeAt(offset int) time.Time {
+	i := sort.Search(len(s.chunks), func(i int) bool { return s.chunks[i].offset > offset })
+	if i == 0 {
+		return time.Time{}
+	}
+	return s.chunks[i-1].time
+}
+
+type flowKey struct {
+	src, dst netip.AddrPort
+}
+
+type flow struct {
+	src, dst netip.AddrPort
+	// base is sequence number of first data byte.
+	base     uint32
+	hasBase  bool
+	synSeen  bool
+	segments []flowSegment
+}
+
+type flowSegment struct {
+	offset int64
+	time   time.Time
+	data   []byte
+}
+
+// ReadStreams reads all packets and reassembles TCP streams of every connection direction.
+// Retransmitted and reordered segments are handled. Stream is cut at first gap, that lost segment makes.
+// Streams are returned in order of their first segment; streams without data are skipped.
+func ReadStreams(r *Reader) ([]*Stream, error) {
+	var (
+		flows   = map[flowKey]*flow{}
+		order   []*flow
+		streams []*Stream
+	)
+	for n := 1; ; n++ {
+		p, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("packet %d read failed: %w", n, err)
+		}
+		s, ok := DecodeTCP(p)
+		if !ok {
+			continue
+		}
+		key := flowKey{s.Src, s.Dst}
+		f := flows[key]
+		if f != nil && s.SYN && len(f.segments) > 0 {
+			// Connection with the same address and port is opened again.
+			f = nil
+		}
+		if f == nil {
+			f = &flow{src: s.Src, dst: s.Dst}
+			flows[key] = f
+			order = append(order, f)
+		}
+		f.add(s)
+	}
+	for _, f := range order {
+		if stream := f.assemble(); len(stream.Data) > 0 {
+			streams = append(streams, stream)
+		}
+	}
+	return streams, nil
+}
+
+func (f *flow) add(s Segment) {
+	seq := s.Seq
+	if s.SYN {
+		// SYN takes one sequence number.
+		seq++
+		f.base = seq
+		f.hasBase = true
+		f.synSeen = true
+	}
+	if len(s.Payload) == 0 {
+		return
+	}
+	if !f.hasBase {
+		f.base = seq
+		f.hasBase = true
+	}
+	f.segments = append(f.segments, flowSegment{
+		offset: int64(int32(seq - f.base)),
+		time:   s.Time,
+		data:   s.Payload,
+	})
+}
+
+func (f *flow) assemble() *Stream {
+	stream := &Stream{Src: f.src, Dst: f.dst}
+	if len(f.segments) == 0 {
+		return stream
+	}
+	sort.SliceStable(f.segments, func(i, j int) bool { return f.segments[i].offset < f.segments[j].offset })
+	var cur int64
+	if !f.synSeen {
+		// Capture started in the middle of connection, and first captured segment could be reordered.
+		cur = f.segments[0].offset
+	}
+	for _, s := range f.segments {
+		end := s.offset + int64(len(s.data))
+		if end <= cur {
+			// Retransmission.
+			continue
+		}
+		if s.offset > cur {
+			break
+		}
+		stream.chunks = append(stream.chunks, chunk{offset: len(stream.Data), time: s.time})
+		stream.Data = append(stream.Data, s.data[cur-s.offset:]...)
+		cur = end
+	}
+	return stream
+}
@@ -0,0 +1,186 @@
+package phout
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	StatusOK         = "ok"
+	StatusRegression = "regression"
+	// StatusMissing means that tag is present in baseline, but not in candidate.
+	StatusMissing = "missing"
+	// StatusNew means that tag is present in candidate, but not in baseline.
+	StatusNew = "new"
+)
+
+// Tolerances define how much candidate can be worse than baseline, before it is
+// considered as regression. Negative value disables check.
+type Tolerances struct {
+	// Quantile is maximum relative RTT quantile growth. 0.1 means +10%.
+	Quantile float64 `json:"quantile"`
+	// RPS is maximum relative RPS drop. 0.1 means -10%.
+	RPS float64 `json:"rps"`
+	// ErrorRate is maximum absolute error rate growth. 0.01 means +1 percentage point.
+	ErrorRate float64 `json:"error_rate"`
+}
+
+func DefaultTolerances() Tolerances {
+	return Tolerances{
+		Quantile:  0.1,
+		RPS:       0.1,
+		ErrorRate: 0.01,
+	}
+}
+
+type CompareConfig struct {
+	// Quantiles in [0, 1] to compare.
+	Quantiles  []float64
+	Tolerances Tolerances
+	// AllowMissing disables regression for tags, that are present in baseline, but not in candidate.
+	AllowMissing bool
+}
+
+func DefaultCompareConfig() CompareConfig {
+	return CompareConfig{
+		Quantiles:  []float64{0.5, 0.9, 0.95, 0.99},
+		Tolerances: DefaultTolerances(),
+	}
+}
+
+type Comparison struct {
+	Tolerances Tolerances `json:"tolerances"`
+	Tags       []TagDelta `json:"tags"`
+	Regression bool       `json:"regression"`
+}
+
+type TagDelta struct {
+	Tag       string          `json:"tag"`
+	Status    string          `json:"status"`
+	Baseline  *TagSummary     `json:"baseline,omitempty"`
+	Candidate *TagSummary     `json:"candidate,omitempty"`
+	Quantiles []QuantileDelta `json:"quantiles,omitempty"`
+	// RPSDelta is relative RPS change.
+	RPSDelta float64 `json:"rps_delta"`
+	// ErrorRateDelta is absolute error rate change.
+	ErrorRateDelta float64 `json:"error_rate_delta"`
+	// Regressions describe exceeded tolerances.
+	Regressions []string `json:"regressions,omitempty"`
+}
+
+type TagSummary struct {
+	Count     int     `json:"count"`
+	Errors    int     `json:"errors"`
+	RPS       float64 `json:"rps"`
+	ErrorRate float64 `json:"error_rate"`
+	// ErrorKinds counts failed samples by error kind.
+	ErrorKinds map[string]int `json:"error_kinds,omitempty"`
+	// Quantiles are RTT quantiles in microseconds in order of CompareConfig.Quantiles.
+	Quantiles []int64 `json:"quantiles_us"`
+}
+
+type QuantileDelta struct {
+	Quantile  float64 `json:"quantile"`
+	Baseline  int64   `json:"baseline_us"`
+	Candidate int64   `json:"candidate_us"`
+	// Delta is relative quantile change.
+	Delta float64 `json:"delta"`
+}
+
+// Compare aligns baseline and candidate tags and checks candidate for regression.
+// Total summary goes first, then tags in lexicographical order.
+func Compare(baseline, candidate *Stats, conf CompareConfig) *Comparison {
+	res := &Comparison{Tolerances: conf.Tolerances}
+	res.add(compareTag(TotalTag, baseline, baseline.Total, candidate, candidate.Total, conf))
+	for _, tag := range baseline.SortedTags() {
+		res.add(compareTag(tag, baseline, baseline.Tags[tag], candidate, candidate.Tags[tag], conf))
+	}
+	for _, tag := range candidate.SortedTags() {
+		if _, ok := baseline.Tags[tag]; ok {
+			continue
+		}
+		res.add(compareTag(tag, baseline, nil, candidate, candidate.Tags[tag], conf))
+	}
+	return res
+}
+
+func (c *Comparison) add(delta TagDelta) {
+	c.Tags = append(c.Tags, delta)
+	if len(delta.Regressions) > 0 {
+		c.Regression = true
+	}
+}
+
+func compareTag(tag string, baseline *Stats, base *TagStats, candidate *Stats, cand *TagStats, conf CompareConfig) TagDelta {
+	delta := TagDelta{Tag: tag}
+	if base != nil {
+		delta.Baseline = summarize(baseline, base, conf.Quantiles)
+	}
+	if cand != nil {
+		delta.Candidate = summarize(candidate, cand, conf.Quantiles)
+	}
+	switch {
+	case delta.Baseline == nil:
+		delta.Status = StatusNew
+		return delta
+	case delta.Candidate == nil:
+		delta.Status = StatusMissing
+		if !conf.AllowMissing {
+			delta.Regressions = append(delta.Regressions, "tag is missing in candidate")
+		}
+		return delta
+	}
+	tol := conf.Tolerances
+	for i, q := range conf.Quantiles {
+		qd := QuantileDelta{
+			Quantile:  q,
+			Baseline:  delta.Baseline.Quantiles[i],
+			Candidate: delta.Candidate.Quantiles[i],
+		}
+		qd.Delta = relativeDelta(float64(qd.Baseline), float64(qd.Candidate))
+		if tol.Quantile >= 0 && qd.Delta > tol.Quantile {
+			delta.Regressions = append(delta.Regressions,
+				fmt.Sprintf("q%g RTT grown by %.1f%% (tolerance %.1f%%)", q*100, qd.Delta*100, tol.Quantile*100))
+		}
+		delta.Quantiles = append(delta.Quantiles, qd)
+	}
+	delta.RPSDelta = relativeDelta(delta.Baseline.RPS, delta.Candidate.RPS)
+	if tol.RPS >= 0 && -delta.RPSDelta > tol.RPS {
+		delta.Regressions = append(delta.Regressions,
+			fmt.Sprintf("RPS dropped by %.1f%% (tolerance %.1f%%)", -delta.RPSDelta*100, tol.RPS*100))
+	}
+	delta.ErrorRateDelta = delta.Candidate.ErrorRate - delta.Baseline.ErrorRate
+	if tol.ErrorRate >= 0 && delta.ErrorRateDelta > tol.ErrorRate {
+		delta.Regressions = append(delta.Regressions,
+			fmt.Sprintf("error rate grown by %.2f pp (tolerance %.2f pp)", delta.ErrorRateDelta*100, tol.ErrorRate*100))
+	}
+	delta.Status = StatusOK
+	if len(delta.Regressions) > 0 {
+		delta.Status = StatusRegression
+	}
+	return delta
+}
+
+func summarize(stats *Stats, tag *TagStats, quantiles []float64) *TagSummary {
+	s := &TagSummary{
+		Count:      tag.Count,
+		Errors:     tag.Errors,
+		RPS:        stats.RPS(tag),
+		ErrorRate:  tag.ErrorRate(),
+		ErrorKinds: tag.ErrorKinds,
+	}
+	for _, q := range quantiles {
+		s.Quantiles = append(s.Quantiles, int64(tag.Quantile(q)/time.Microsecond))
+	}
+	return s
+}
+
+func relativeDelta(baseline, candidate float64) float64 {
+	if baseline == 0 {
+		if candidate == 0 {
+			return 0
+		}
+		return 1
+	}
+	return (candidate - baseline) / baseline
+}
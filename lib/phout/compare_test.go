@@ -0,0 +1,120 @@
+package phout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStats(tag string, rtts []time.Duration, errors int) *Stats {
+	s := NewStats()
+	start := time.Unix(1484660999, 0)
+	for i, rtt := range rtts {
+		rec := &Record{
+			Time:      start.Add(time.Duration(i) * time.Second / time.Duration(len(rtts))),
+			Tag:       tag,
+			RTT:       rtt,
+			ProtoCode: 200,
+		}
+		if i < errors {
+			rec.ProtoCode = 500
+		}
+		s.Add(rec)
+	}
+	return s
+}
+
+func ms(values ...int) []time.Duration {
+	res := make([]time.Duration, len(values))
+	for i, v := range values {
+		res[i] = time.Duration(v) * time.Millisecond
+	}
+	return res
+}
+
+func TestTagStatsQuantile(t *testing.T) {
+	s := newTestStats("a", ms(5, 1, 4, 2, 3, 6, 7, 8, 9, 10), 1)
+	tag := s.Tags["a"]
+	assert.InEpsilon(t, 5*time.Millisecond, tag.Quantile(0.5), 0.004)
+	assert.InEpsilon(t, 9*time.Millisecond, tag.Quantile(0.9), 0.004)
+	assert.Equal(t, 10*time.Millisecond, tag.Quantile(0.99))
+	assert.Equal(t, 1*time.Millisecond, tag.Quantile(0))
+	assert.InDelta(t, 0.1, tag.ErrorRate(), 1e-9)
+	assert.Equal(t, 10, s.Total.Count)
+	assert.Nil(t, tag.ErrorKinds)
+
+	s.Add(&Record{Tag: "a", NetCode: 110, ErrorKind: "read_timeout"})
+	assert.Equal(t, map[string]int{"read_timeout": 1}, tag.ErrorKinds)
+	assert.Equal(t, map[string]int{"read_timeout": 1}, s.Total.ErrorKinds)
+}
+
+func TestCompare(t *testing.T) {
+	conf := DefaultCompareConfig()
+	conf.Quantiles = []float64{0.5}
+
+	t.Run("ok", func(t *testing.T) {
+		baseline := newTestStats("a", ms(10, 10, 10, 10), 0)
+		candidate := newTestStats("a", ms(10, 10, 10, 11), 0)
+		res := Compare(baseline, candidate, conf)
+		assert.False(t, res.Regression)
+		require.Len(t, res.Tags, 2)
+		assert.Equal(t, TotalTag, res.Tags[0].Tag)
+		assert.Equal(t, StatusOK, res.Tags[1].Status)
+	})
+
+	t.Run("quantile regression", func(t *testing.T) {
+		baseline := newTestStats("a", ms(10, 10, 10, 10), 0)
+		candidate := newTestStats("a", ms(20, 20, 20, 20), 0)
+		res := Compare(baseline, candidate, conf)
+		assert.True(t, res.Regression)
+		assert.Equal(t, StatusRegression, res.Tags[1].Status)
+		assert.InDelta(t, 1.0, res.Tags[1].Quantiles[0].Delta, 1e-9)
+	})
+
+	t.Run("error rate regression", func(t *testing.T) {
+		baseline := newTestStats("a", ms(10, 10, 10, 10), 0)
+		candidate := newTestStats("a", ms(10, 10, 10, 10), 1)
+		res := Compare(baseline, candidate, conf)
+		assert.True(t, res.Regression)
+		assert.InDelta(t, 0.25, res.Tags[1].ErrorRateDelta, 1e-9)
+	})
+
+	t.Run("disabled tolerance", func(t *testing.T) {
+		conf := conf
+		conf.Tolerances.ErrorRate = -1
+		baseline := newTestStats("a", ms(10, 10, 10, 10), 0)
+		candidate := newTestStats("a", ms(10, 10, 10, 10), 1)
+		res := Compare(baseline, candidate, conf)
+		assert.False(t, res.Regression)
+	})
+
+	t.Run("rps regression", func(t *testing.T) {
+		baseline := newTestStats("a", ms(10, 10, 10, 10), 0)
+		candidate := newTestStats("a", ms(10, 10), 0)
+		res := Compare(baseline, candidate, conf)
+		assert.True(t, res.Regression)
+		assert.InDelta(t, -0.5, res.Tags[1].RPSDelta, 1e-9)
+	})
+
+	t.Run("tags aligned", func(t *testing.T) {
+		baseline := newTestStats("a", ms(10), 0)
+		candidate := newTestStats("b", ms(10), 0)
+		res := Compare(baseline, candidate, conf)
+		require.Len(t, res.Tags, 3)
+		assert.Equal(t, StatusMissing, res.Tags[1].Status)
+		assert.Equal(t, "a", res.Tags[1].Tag)
+		assert.Equal(t, StatusNew, res.Tags[2].Status)
+		assert.Equal(t, "b", res.Tags[2].Tag)
+		assert.True(t, res.Regression, "missing tag is regression")
+		assert.Equal(t, []string{"tag is missing in candidate"}, res.Tags[1].Regressions)
+
+		conf := conf
+		conf.AllowMissing = true
+		res = Compare(baseline, candidate, conf)
+		assert.False(t, res.Regression)
+		assert.Equal(t, StatusMissing, res.Tags[1].Status)
+		assert.Empty(t, res.Tags[1].Regressions)
+	})
+}
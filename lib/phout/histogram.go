@@ -0,0 +1,91 @@
+package phout
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// histogramSubBits sets histogram precision: every power of two range of microseconds is split
+// to 1<<(histogramSubBits-1) buckets, so bucket value differs from sample by less than 0.4%.
+const histogramSubBits = 8
+
+// histogram counts RTTs in log-linear buckets of microseconds, so memory doesn't grow with samples count.
+// Values less than 1<<histogramSubBits microseconds are exact. Min and max are kept exactly.
+type histogram struct {
+	counts []int64
+	total  int64
+	min    time.Duration
+	max    time.Duration
+}
+
+func (h *histogram) add(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	if h.total == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.total++
+	i := histogramIndex(uint64(d / time.Microsecond))
+	if i >= len(h.counts) {
+		counts := make([]int64, i+1, 2*(i+1))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+}
+
+// quantile returns RTT quantile using nearest-rank method. q should be in [0, 1].
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(h.total)))
+	if rank < 1 {
+		return h.min
+	}
+	if rank >= h.total {
+		return h.max
+	}
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			d := histogramValue(i)
+			switch {
+			case d < h.min:
+				return h.min
+			case d > h.max:
+				return h.max
+			}
+			return d
+		}
+	}
+	return h.max
+}
+
+// histogramIndex returns bucket of microseconds value. Values less than 1<<histogramSubBits have own buckets,
+// greater values are bucketed by their histogramSubBits-1 bits after the highest one.
+func histogramIndex(us uint64) int {
+	shift := bits.Len64(us) - histogramSubBits
+	if shift <= 0 {
+		return int(us)
+	}
+	return shift<<(histogramSubBits-1) + int(us>>shift)
+}
+
+// histogramValue returns middle of bucket range.
+func histogramValue(i int) time.Duration {
+	const half = 1 << (histogramSubBits - 1)
+	if i < 2*half {
+		return time.Duration(i) * time.Microsecond
+	}
+	shift := i/half - 1
+	mantissa := uint64(i - shift*half)
+	us := mantissa<<shift + 1<<(shift-1)
+	return time.Duration(us) * time.Microsecond
+}
@@ -0,0 +1,44 @@
+package phout
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramIndex(t *testing.T) {
+	prev := -1
+	for us := uint64(0); us < 1<<20; us++ {
+		i := histogramIndex(us)
+		assert.True(t, i == prev || i == prev+1, "index of %d is %d after %d", us, i, prev)
+		prev = i
+		v := uint64(histogramValue(i) / time.Microsecond)
+		if us < 1<<histogramSubBits {
+			assert.Equal(t, us, v)
+		} else {
+			assert.InEpsilon(t, us, v, 1.0/(1<<histogramSubBits))
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	var h histogram
+	assert.Equal(t, time.Duration(0), h.quantile(0.5))
+
+	r := rand.New(rand.NewSource(1))
+	rtts := make([]time.Duration, 100000)
+	for i := range rtts {
+		rtts[i] = time.Duration(r.ExpFloat64() * float64(50*time.Millisecond))
+		h.add(rtts[i])
+	}
+	sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+	for _, q := range []float64{0.5, 0.9, 0.95, 0.99, 0.999} {
+		exact := rtts[int(q*float64(len(rtts)))-1]
+		assert.InEpsilon(t, exact, h.quantile(q), 0.004, "quantile %v", q)
+	}
+	assert.Equal(t, rtts[0], h.quantile(0))
+	assert.Equal(t, rtts[len(rtts)-1], h.quantile(1))
+}
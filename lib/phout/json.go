@@ -0,0 +1,125 @@
+package phout
+
+import (
+	"fmt"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// JSONReader is streaming reader of netsample samples written by jsonlines aggregator.
+// Unknown fields are skipped. JSONReader is NOT goroutine safe.
+type JSONReader struct {
+	lines lineReader
+	iter  *jsoniter.Iterator
+}
+
+var _ RecordReader = (*JSONReader)(nil)
+
+func NewJSONReader(r io.Reader) *JSONReader {
+	return &JSONReader{
+		lines: newLineReader(r),
+		iter:  jsoniter.NewIterator(jsoniter.ConfigDefault),
+	}
+}
+
+// Read reads next record into rec. Empty lines are skipped.
+// Returns io.EOF, when there is no records left.
+func (r *JSONReader) Read(rec *Record) error {
+	line, err := r.lines.next()
+	if err != nil {
+		return err
+	}
+	err = r.parse(line, rec)
+	if err != nil {
+		return fmt.Errorf("jsonlines line %d: %w", r.lines.num, err)
+	}
+	return nil
+}
+
+// ParseJSONLine parses one jsonlines sample.
+func ParseJSONLine(line []byte, rec *Record) error {
+	return (&JSONReader{iter: jsoniter.NewIterator(jsoniter.ConfigDefault)}).parse(line, rec)
+}
+
+func (r *JSONReader) parse(line []byte, rec *Record) error {
+	*rec = Record{}
+	iter := r.iter
+	iter.ResetBytes(line)
+	var hasTimestamp bool
+	for field := iter.ReadObject(); field != ""; field = iter.ReadObject() {
+		switch field {
+		case "ts":
+			ts, err := parseTimestamp([]byte(iter.ReadNumber()))
+			if err != nil {
+				return err
+			}
+			rec.Time = ts
+			hasTimestamp = true
+		case "tag":
+			rec.Tag = iter.ReadString()
+		case "id":
+			rec.ID = iter.ReadUint64()
+		case "rtt_us":
+			rec.RTT = micro(iter.ReadInt64())
+		case "connect_us":
+			rec.ConnectTime = micro(iter.ReadInt64())
+		case "send_us":
+			rec.SendTime = micro(iter.ReadInt64())
+		case "latency_us":
+			rec.Latency = micro(iter.ReadInt64())
+		case "receive_us":
+			rec.ReceiveTime = micro(iter.ReadInt64())
+		case "interval_event_us":
+			rec.IntervalEvent = micro(iter.ReadInt64())
+		case "request_bytes":
+			rec.RequestBytes = iter.ReadInt()
+		case "response_bytes":
+			rec.ResponseBytes = iter.ReadInt()
+		case "net_code":
+			rec.NetCode = iter.ReadInt()
+		case "proto_code":
+			rec.ProtoCode = iter.ReadInt()
+		case "dns_us":
+			rec.DNSTime = micro(iter.ReadInt64())
+		case "tcp_connect_us":
+			rec.TCPConnectTime = micro(iter.ReadInt64())
+		case "tls_handshake_us":
+			rec.TLSHandshakeTime = micro(iter.ReadInt64())
+		case "ttfb_us":
+			rec.TTFB = micro(iter.ReadInt64())
+		case "response_us":
+			rec.ResponseTime = micro(iter.ReadInt64())
+		case "error":
+			rec.Error = iter.ReadString()
+		case "error_kind":
+			rec.ErrorKind = iter.ReadString()
+		case "labels":
+			for key := iter.ReadObject(); key != ""; key = iter.ReadObject() {
+				if rec.Labels == nil {
+					rec.Labels = map[string]string{}
+				}
+				rec.Labels[key] = iter.ReadString()
+			}
+		case "metrics":
+			for name := iter.ReadObject(); name != ""; name = iter.ReadObject() {
+				if rec.Metrics == nil {
+					rec.Metrics = map[string]float64{}
+				}
+				rec.Metrics[name] = iter.ReadFloat64()
+			}
+		default:
+			iter.Skip()
+		}
+		if iter.Error != nil {
+			break
+		}
+	}
+	if iter.Error != nil {
+		return fmt.Errorf("invalid sample: %w", iter.Error)
+	}
+	if !hasTimestamp {
+		return fmt.Errorf("no ts field")
+	}
+	return nil
+}
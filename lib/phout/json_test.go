@@ -0,0 +1,88 @@
+package phout
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONReader(t *testing.T) {
+	const input = `{"ts":1484660999.002001,"tag":"tag1|tag2","id":42,"rtt_us":333333,"connect_us":1,"send_us":2,` +
+		`"latency_us":3,"receive_us":4,"interval_event_us":5,"request_bytes":6,"response_bytes":7,` +
+		`"net_code":13,"proto_code":999,"error":"connection refused","unknown":{"a":[1,2]}}` + "\n" +
+		"\n" +
+		`{"ts":1484661000.1,"tag":"tag1","rtt_us":1000,"proto_code":200}`
+
+	r := NewJSONReader(strings.NewReader(input))
+	var rec Record
+	require.NoError(t, r.Read(&rec))
+	assert.Equal(t, Record{
+		Time:          time.Unix(1484660999, 2001*int64(time.Microsecond)),
+		Tag:           "tag1|tag2",
+		ID:            42,
+		RTT:           333333 * time.Microsecond,
+		ConnectTime:   1 * time.Microsecond,
+		SendTime:      2 * time.Microsecond,
+		Latency:       3 * time.Microsecond,
+		ReceiveTime:   4 * time.Microsecond,
+		IntervalEvent: 5 * time.Microsecond,
+		RequestBytes:  6,
+		ResponseBytes: 7,
+		NetCode:       13,
+		ProtoCode:     999,
+		Error:         "connection refused",
+	}, rec)
+
+	require.NoError(t, r.Read(&rec))
+	assert.Equal(t, Record{
+		Time:      time.Unix(1484661000, 100*int64(time.Millisecond)),
+		Tag:       "tag1",
+		RTT:       time.Millisecond,
+		ProtoCode: 200,
+	}, rec)
+
+	assert.Equal(t, io.EOF, r.Read(&rec))
+}
+
+func TestParseJSONLineErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"not object", `[1]`},
+		{"no timestamp", `{"tag":"a"}`},
+		{"invalid timestamp", `{"ts":"x"}`},
+		{"invalid field", `{"ts":1.1,"rtt_us":"x"}`},
+		{"truncated", `{"ts":1.1,"rtt_us":1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rec Record
+			assert.Error(t, ParseJSONLine([]byte(tt.line), &rec))
+		})
+	}
+}
+
+func TestNewAutoReader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"phout", "1484661000.100\ttag\t1000\t0\t0\t0\t0\t0\t0\t0\t0\t200\n"},
+		{"jsonlines", "\n  " + `{"ts":1484661000.1,"tag":"tag","rtt_us":1000,"proto_code":200}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewAutoReader(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			stats, err := CollectStats(r)
+			require.NoError(t, err)
+			assert.Equal(t, 1, stats.Total.Count)
+			assert.Equal(t, time.Millisecond, stats.Tags["tag"].Quantile(1))
+		})
+	}
+}
@@ -0,0 +1,282 @@
+// Package phout reads shooting results written by netsample phout and jsonlines aggregators
+// into typed records. Phout format is tab separated lines compatible with Yandex.Tank.
+package phout
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	delimiter   = '\t'
+	tagIDPrefix = '#'
+	fieldsNum   = 10
+	// extendedFieldsNum is number of fields in phout written with extended timings.
+	extendedFieldsNum = 14
+	// noErrorKind is written in error kind column of successful samples.
+	noErrorKind = "-"
+)
+
+// Record is one parsed phout line.
+type Record struct {
+	Time time.Time
+	Tag  string
+	// ID is ammo ID. Zero, if phout was written without ids.
+	ID            uint64
+	RTT           time.Duration
+	ConnectTime   time.Duration
+	SendTime      time.Duration
+	Latency       time.Duration
+	ReceiveTime   time.Duration
+	IntervalEvent time.Duration
+	RequestBytes  int
+	ResponseBytes int
+	NetCode       int
+	ProtoCode     int
+	// Connection timings breakdown. Present in jsonlines and in phout written with extended timings.
+	DNSTime          time.Duration
+	TCPConnectTime   time.Duration
+	TLSHandshakeTime time.Duration
+	// TTFB is time since start of getting connection till first response byte.
+	TTFB time.Duration
+	// ErrorKind is classified shoot error. For example: dns_failure or read_timeout.
+	// Present in jsonlines and in phout written with error kind column. Empty for successful samples.
+	ErrorKind string
+	// ResponseTime is time since scheduled shot start. Present only in jsonlines output,
+	// when shot scheduled time is known. Zero otherwise.
+	ResponseTime time.Duration
+	// Error is shoot error message. Present only in jsonlines output.
+	Error string
+	// Labels and Metrics are custom sample attributes. Present only in jsonlines output.
+	// Nil, if sample has no labels or metrics.
+	Labels  map[string]string
+	Metrics map[string]float64
+}
+
+// Failed returns true, if shoot failed on network level or got protocol error code.
+func (r *Record) Failed() bool {
+	return r.NetCode != 0 || r.ProtoCode >= 400
+}
+
+// RecordReader is implemented by phout and jsonlines readers.
+type RecordReader interface {
+	// Read reads next record into rec. Returns io.EOF, when there is no records left.
+	Read(rec *Record) error
+}
+
+// Reader is streaming phout reader. Reader is NOT goroutine safe.
+type Reader struct {
+	lines lineReader
+}
+
+var _ RecordReader = (*Reader)(nil)
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{lines: newLineReader(r)}
+}
+
+// Read reads next record into rec. Empty lines are skipped.
+// Returns io.EOF, when there is no records left.
+func (r *Reader) Read(rec *Record) error {
+	line, err := r.lines.next()
+	if err != nil {
+		return err
+	}
+	err = ParseLine(line, rec)
+	if err != nil {
+		return fmt.Errorf("phout line %d: %w", r.lines.num, err)
+	}
+	return nil
+}
+
+// NewAutoReader detects format by first non-blank character: jsonlines records start with '{'.
+func NewAutoReader(r io.Reader) (RecordReader, error) {
+	br := bufio.NewReaderSize(r, readerBufferSize)
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return NewReader(br), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			continue
+		}
+		_ = br.UnreadByte()
+		if c == '{' {
+			return NewJSONReader(br), nil
+		}
+		return NewReader(br), nil
+	}
+}
+
+const readerBufferSize = 64 * 1024
+
+// lineReader reads non-empty lines without trailing new line.
+type lineReader struct {
+	r *bufio.Reader
+	// num is number of last read line.
+	num int
+}
+
+func newLineReader(r io.Reader) lineReader {
+	return lineReader{r: bufio.NewReaderSize(r, readerBufferSize)}
+}
+
+// next returns line, that is valid only until next call.
+func (r *lineReader) next() ([]byte, error) {
+	for {
+		line, err := r.r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// Very long line. Fallback to allocation.
+			rest, restErr := r.r.ReadBytes('\n')
+			line = append(append([]byte(nil), line...), rest...)
+			err = restErr
+		}
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+		r.num++
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			continue
+		}
+		return line, nil
+	}
+}
+
+// ParseLine parses one phout line without trailing new line.
+func ParseLine(line []byte, rec *Record) error {
+	tsField, rest, ok := cut(line)
+	if !ok {
+		return fmt.Errorf("no tag field")
+	}
+	ts, err := parseTimestamp(tsField)
+	if err != nil {
+		return err
+	}
+	tagField, rest, ok := cut(rest)
+	if !ok {
+		return fmt.Errorf("no value fields")
+	}
+	*rec = Record{Time: ts}
+	rec.Tag, rec.ID = parseTag(tagField)
+
+	n := bytes.Count(rest, []byte{delimiter}) + 1
+	// Optional error kind column follows value fields. Error kind is never a number.
+	withErrorKind := false
+	if n == fieldsNum+1 || n == extendedFieldsNum+1 {
+		_, err = parseInt(rest[bytes.LastIndexByte(rest, delimiter)+1:])
+		withErrorKind = err != nil
+	}
+	if withErrorKind {
+		n--
+	}
+	if n != fieldsNum && n != extendedFieldsNum {
+		return fmt.Errorf("expected %d or %d value fields, got %d", fieldsNum, extendedFieldsNum, n)
+	}
+	var fields [extendedFieldsNum]int64
+	for i := 0; i < n; i++ {
+		var field []byte
+		field, rest, _ = cut(rest)
+		fields[i], err = parseInt(field)
+		if err != nil {
+			return fmt.Errorf("field %d: %w", i+3, err)
+		}
+	}
+	rec.RTT = micro(fields[0])
+	rec.ConnectTime = micro(fields[1])
+	rec.SendTime = micro(fields[2])
+	rec.Latency = micro(fields[3])
+	rec.ReceiveTime = micro(fields[4])
+	rec.IntervalEvent = micro(fields[5])
+	rec.RequestBytes = int(fields[6])
+	rec.ResponseBytes = int(fields[7])
+	rec.NetCode = int(fields[8])
+	rec.ProtoCode = int(fields[9])
+	rec.DNSTime = micro(fields[10])
+	rec.TCPConnectTime = micro(fields[11])
+	rec.TLSHandshakeTime = micro(fields[12])
+	rec.TTFB = micro(fields[13])
+	if withErrorKind && string(rest) != noErrorKind {
+		rec.ErrorKind = string(rest)
+	}
+	return nil
+}
+
+func cut(s []byte) (before, after []byte, found bool) {
+	return bytes.Cut(s, []byte{delimiter})
+}
+
+func micro(v int64) time.Duration {
+	return time.Duration(v) * time.Microsecond
+}
+
+// parseTag splits ammo id, that was written with id option. Example: tag1|tag2#42.
+func parseTag(field []byte) (tag string, id uint64) {
+	idx := bytes.LastIndexByte(field, tagIDPrefix)
+	if idx < 0 || idx == len(field)-1 {
+		return string(field), 0
+	}
+	parsed, err := parseInt(field[idx+1:])
+	if err != nil || parsed < 0 {
+		return string(field), 0
+	}
+	return string(field[:idx]), uint64(parsed)
+}
+
+// parseTimestamp parses timestamp in format 1335524833.562
+func parseTimestamp(field []byte) (time.Time, error) {
+	secField, msecField, _ := bytes.Cut(field, []byte{'.'})
+	sec, err := parseInt(secField)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", field, err)
+	}
+	var nsec int64
+	if len(msecField) > 9 {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: too precise", field)
+	}
+	if len(msecField) > 0 {
+		frac, err := parseInt(msecField)
+		if err != nil || frac < 0 {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", field)
+		}
+		nsec = frac
+		for i := len(msecField); i < 9; i++ {
+			nsec *= 10
+		}
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// parseInt is allocation free strconv.ParseInt(string(b), 10, 64) analogue.
+func parseInt(b []byte) (int64, error) {
+	if len(b) == 0 {
+		return 0, fmt.Errorf("empty number")
+	}
+	neg := b[0] == '-'
+	if neg {
+		b = b[1:]
+		if len(b) == 0 {
+			return 0, fmt.Errorf("invalid number %q", "-")
+		}
+	}
+	var n int64
+	for _, c := range b {
+		if c < '0' || c > '9' || n > (1<<63-1)/10 {
+			return 0, fmt.Errorf("invalid number %q", b)
+		}
+		n = n*10 + int64(c-'0')
+	}
+	if neg {
+		n = -n
+	}
+	return n, nil
+}
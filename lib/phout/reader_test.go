@@ -0,0 +1,108 @@
+package phout
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	const input = "1484660999.002\ttag1|tag2#42\t333333\t1\t2\t3\t4\t5\t6\t7\t13\t999\n" +
+		"\n" +
+		"1484661000.100\ttag1\t1000\t0\t0\t0\t0\t0\t0\t0\t0\t200"
+
+	r := NewReader(strings.NewReader(input))
+	var rec Record
+	require.NoError(t, r.Read(&rec))
+	assert.Equal(t, Record{
+		Time:          time.Unix(1484660999, 2*int64(time.Millisecond)),
+		Tag:           "tag1|tag2",
+		ID:            42,
+		RTT:           333333 * time.Microsecond,
+		ConnectTime:   1 * time.Microsecond,
+		SendTime:      2 * time.Microsecond,
+		Latency:       3 * time.Microsecond,
+		ReceiveTime:   4 * time.Microsecond,
+		IntervalEvent: 5 * time.Microsecond,
+		RequestBytes:  6,
+		ResponseBytes: 7,
+		NetCode:       13,
+		ProtoCode:     999,
+	}, rec)
+	assert.True(t, rec.Failed())
+
+	require.NoError(t, r.Read(&rec))
+	assert.Equal(t, "tag1", rec.Tag)
+	assert.Zero(t, rec.ID)
+	assert.Equal(t, time.Millisecond, rec.RTT)
+	assert.False(t, rec.Failed())
+
+	assert.Equal(t, io.EOF, r.Read(&rec))
+}
+
+func TestParseLineExtendedTimings(t *testing.T) {
+	var rec Record
+	err := ParseLine([]byte("1484660999.002\ttag\t1000\t500\t0\t0\t0\t0\t0\t0\t0\t200\t100\t200\t300\t800"), &rec)
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Microsecond, rec.ConnectTime)
+	assert.Equal(t, 200, rec.ProtoCode)
+	assert.Equal(t, 100*time.Microsecond, rec.DNSTime)
+	assert.Equal(t, 200*time.Microsecond, rec.TCPConnectTime)
+	assert.Equal(t, 300*time.Microsecond, rec.TLSHandshakeTime)
+	assert.Equal(t, 800*time.Microsecond, rec.TTFB)
+}
+
+func TestParseLineErrorKind(t *testing.T) {
+	var rec Record
+	err := ParseLine([]byte("1484660999.002\ttag\t1000\t0\t0\t0\t0\t0\t0\t0\t111\t0\tconnect_refused"), &rec)
+	require.NoError(t, err)
+	assert.Equal(t, 111, rec.NetCode)
+	assert.Equal(t, "connect_refused", rec.ErrorKind)
+
+	err = ParseLine([]byte("1484660999.002\ttag\t1000\t0\t0\t0\t0\t0\t0\t0\t0\t200\t1\t2\t3\t4\t-"), &rec)
+	require.NoError(t, err)
+	assert.Equal(t, 4*time.Microsecond, rec.TTFB)
+	assert.Empty(t, rec.ErrorKind)
+}
+
+func TestParseLineErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"no tag", "1484660999.002"},
+		{"invalid timestamp", "14846x0999.002\ttag\t1\t0\t0\t0\t0\t0\t0\t0\t0\t200"},
+		{"too few fields", "1484660999.002\ttag\t1\t0\t0"},
+		{"too many fields", "1484660999.002\ttag\t1\t0\t0\t0\t0\t0\t0\t0\t0\t200\t1"},
+		{"too few extended fields", "1484660999.002\ttag\t1\t0\t0\t0\t0\t0\t0\t0\t0\t200\t1\t2\t3"},
+		{"invalid number", "1484660999.002\ttag\t1\t0\t0\t0\tx\t0\t0\t0\t0\t200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rec Record
+			assert.Error(t, ParseLine([]byte(tt.line), &rec))
+		})
+	}
+}
+
+func TestReaderReportsLine(t *testing.T) {
+	r := NewReader(strings.NewReader("1484660999.002\ttag\t1\t0\t0\t0\t0\t0\t0\t0\t0\t200\nbroken\n"))
+	var rec Record
+	require.NoError(t, r.Read(&rec))
+	err := r.Read(&rec)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func BenchmarkParseLine(b *testing.B) {
+	line := []byte("1484660999.002\ttag1|tag2#42\t333333\t1\t2\t3\t4\t5\t6\t7\t13\t999")
+	var rec Record
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = ParseLine(line, &rec)
+	}
+}
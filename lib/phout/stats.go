@@ -0,0 +1,118 @@
+package phout
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"time"
+)
+
+// TotalTag is a tag of Stats.Total summary.
+const TotalTag = "[total]"
+
+// Stats is per tag shooting summary.
+type Stats struct {
+	Start    time.Time
+	Finish   time.Time
+	Total    *TagStats
+	Tags     map[string]*TagStats
+	Duration time.Duration
+}
+
+// TagStats accumulates samples of one tag.
+type TagStats struct {
+	Tag    string
+	Count  int
+	Errors int
+	// ErrorKinds counts failed samples by error kind. Nil, if there are no samples with error kind.
+	ErrorKinds map[string]int
+	rtts       histogram
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		Total: &TagStats{Tag: TotalTag},
+		Tags:  map[string]*TagStats{},
+	}
+}
+
+// CollectStats reads all records from r.
+func CollectStats(r RecordReader) (*Stats, error) {
+	stats := NewStats()
+	var rec Record
+	for {
+		err := r.Read(&rec)
+		if errors.Is(err, io.EOF) {
+			return stats, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		stats.Add(&rec)
+	}
+}
+
+func (s *Stats) Add(rec *Record) {
+	if s.Start.IsZero() || rec.Time.Before(s.Start) {
+		s.Start = rec.Time
+	}
+	if finish := rec.Time.Add(rec.RTT); finish.After(s.Finish) {
+		s.Finish = finish
+	}
+	s.Duration = s.Finish.Sub(s.Start)
+	tag, ok := s.Tags[rec.Tag]
+	if !ok {
+		tag = &TagStats{Tag: rec.Tag}
+		s.Tags[rec.Tag] = tag
+	}
+	tag.add(rec)
+	s.Total.add(rec)
+}
+
+// RPS returns tag samples per second of whole shooting duration.
+// Shooting shorter than second is considered as one second long.
+func (s *Stats) RPS(tag *TagStats) float64 {
+	d := s.Duration
+	if d < time.Second {
+		d = time.Second
+	}
+	return float64(tag.Count) / d.Seconds()
+}
+
+// SortedTags returns tags in lexicographical order.
+func (s *Stats) SortedTags() []string {
+	tags := make([]string, 0, len(s.Tags))
+	for tag := range s.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (t *TagStats) add(rec *Record) {
+	t.Count++
+	if rec.Failed() {
+		t.Errors++
+	}
+	if rec.ErrorKind != "" {
+		if t.ErrorKinds == nil {
+			t.ErrorKinds = map[string]int{}
+		}
+		t.ErrorKinds[rec.ErrorKind]++
+	}
+	t.rtts.add(rec.RTT)
+}
+
+// ErrorRate returns failed samples fraction in [0, 1].
+func (t *TagStats) ErrorRate() float64 {
+	if t.Count == 0 {
+		return 0
+	}
+	return float64(t.Errors) / float64(t.Count)
+}
+
+// Quantile returns RTT quantile using nearest-rank method. q should be in [0, 1].
+// RTTs are kept in histogram, so quantile differs from exact one by less than 0.4%. Min and max are exact.
+func (t *TagStats) Quantile(q float64) time.Duration {
+	return t.rtts.quantile(q)
+}
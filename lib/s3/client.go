@@ -0,0 +1,165 @@
+// Package s3 is minimal client of S3 compatible object storage, that can get and put objects.
+// Requests are signed with AWS Signature Version 4, so it works with AWS S3, MinIO, Yandex Object Storage
+// and other compatible storages.
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yandex/pandora/lib/netutil"
+)
+
+type Config struct {
+	// Endpoint of storage. Example: https://storage.yandexcloud.net
+	Endpoint string `config:"endpoint" validate:"required,url"`
+	// Region is taken from AWS_REGION environment variable by default, or us-east-1, if it is not set.
+	Region string `config:"region"`
+	Bucket string `config:"bucket" validate:"required"`
+	Key    string `config:"key" validate:"required"`
+	// VirtualHostedStyle makes bucket part of host, like https://bucket.storage.yandexcloud.net/key.
+	// Otherwise, path-style URL is used: https://storage.yandexcloud.net/bucket/key
+	VirtualHostedStyle bool `config:"virtual-hosted-style"`
+	// Timeout of connect and of waiting for response headers. Object upload and download
+	// are not limited, because objects may be large. Zero means no timeout.
+	Timeout time.Duration `config:"timeout"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout: time.Minute,
+	}
+}
+
+// Credentials are taken from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+// environment variables. Requests are not signed, if access key is not set.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+type Client struct {
+	conf        Config
+	endpoint    *url.URL
+	credentials Credentials
+	client      *http.Client
+	now         func() time.Time
+}
+
+func NewClient(conf Config) (*Client, error) {
+	endpoint, err := url.Parse(conf.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme and host are required", conf.Endpoint)
+	}
+	if conf.Region == "" {
+		conf.Region = os.Getenv("AWS_REGION")
+	}
+	if conf.Region == "" {
+		conf.Region = "us-east-1"
+	}
+	return &Client{
+		conf:        conf,
+		endpoint:    endpoint,
+		credentials: CredentialsFromEnv(),
+		client:      netutil.NewHTTPClient(conf.Timeout),
+		now:         time.Now,
+	}, nil
+}
+
+// URL returns configured object URL.
+func (c *Client) URL() *url.URL {
+	u := *c.endpoint
+	if c.conf.VirtualHostedStyle {
+		u.Host = c.conf.Bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.conf.Key
+	} else {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.conf.Bucket + "/" + c.conf.Key
+	}
+	return &u
+}
+
+// Get returns configured object content. Caller SHOULD close it.
+func (c *Client) Get(ctx context.Context) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL().String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Put uploads body of size bytes as configured object.
+func (c *Client) Put(ctx context.Context, body io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.URL().String(), io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	res, err := c.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return res.Body.Close()
+}
+
+func (c *Client) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	if c.credentials.AccessKeyID != "" {
+		sign(req, c.credentials, c.conf.Region, payloadHash, c.now())
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s failed: %w", req.Method, req.URL, err)
+	}
+	if res.StatusCode/100 != 2 {
+		defer res.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s failed: %w", req.Method, req.URL, readError(res))
+	}
+	return res, nil
+}
+
+// Error is S3 REST API error response.
+type Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s (status %d)", e.Code, e.Message, e.StatusCode)
+}
+
+func readError(res *http.Response) error {
+	e := &Error{StatusCode: res.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	_ = xml.Unmarshal(body, e)
+	return e
+}
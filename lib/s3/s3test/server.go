@@ -0,0 +1,81 @@
+// Package s3test provides in-memory S3 compatible storage stand-in for tests.
+package s3test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Server serves path-style GET and PUT object requests. Objects are keyed by "<bucket>/<key>".
+// Requests without Authorization header are rejected, if AccessKeyID is set.
+type Server struct {
+	*httptest.Server
+	AccessKeyID string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func NewServer() *Server {
+	s := &Server{objects: map[string][]byte{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *Server) Object(bucket, key string) ([]byte, bool) {
+	return s.get(bucket + "/" + key)
+}
+
+func (s *Server) SetObject(bucket, key string, data []byte) {
+	s.set(bucket+"/"+key, data)
+}
+
+func (s *Server) get(name string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[name]
+	return data, ok
+}
+
+func (s *Server) set(name string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[name] = data
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if s.AccessKeyID != "" &&
+		!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/") {
+		writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied.")
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := s.get(name)
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		_, _ = w.Write(data)
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		s.set(name, data)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed.")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>`+code+
+		`</Code><Message>`+message+`</Message></Error>`)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 	"github.com/yandex/pandora/cli"
+	"github.com/yandex/pandora/components/aggregators"
 	grpc "github.com/yandex/pandora/components/grpc/import"
 	"github.com/yandex/pandora/components/guns"
 	phttpimport "github.com/yandex/pandora/components/phttp/import"
@@ -26,6 +27,7 @@ func importDependencies(fs afero.Fs) func() {
 		phttpimport.Import(fs)
 		grpc.Import(fs)
 		guns.Import(fs)
+		aggregators.Import(fs)
 	}
 }
 